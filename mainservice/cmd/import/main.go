@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/database/psql"
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/internal/services"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+// go run ./cmd/import -file events.csv -organizer "City Hall" -dry-run
+func main() {
+	filePath := flag.String("file", "", "file to import")
+	format := flag.String("format", "", "csv, geojson or ics, defaults to the file extension")
+	mappingStr := flag.String("mapping", "", `JSON object of source column/property -> event field, e.g. {"title":"name"}`)
+	dryRun := flag.Bool("dry-run", false, "validate and report without inserting")
+	userIDStr := flag.String("user", "", "user id set as created_by of every event")
+	organizer := flag.String("organizer", "", "organizer for rows without one")
+	timezone := flag.String("timezone", "", "timezone of dates without an offset, defaults to UTC")
+	flag.Parse()
+
+	if *filePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err := godotenv.Load("config.env")
+	assert.ErrorNil(err, "failed to load config.env")
+
+	lg, err := zap.NewProduction()
+	assert.ErrorNil(err, "lg creation error")
+
+	dbcon, err := psql.New(psql.Config{
+		Addr:     os.Getenv("POSTGRES_HOST"), //change for local and docker
+		Port:     os.Getenv("POSTGRES_PORT"),
+		User:     os.Getenv("POSTGRES_USER"),
+		Password: os.Getenv("POSTGRES_PASSWORD"),
+		DB:       os.Getenv("POSTGRES_DB"),
+	})
+	assert.ErrorNil(err, "failed db connection")
+
+	request := eventModel.ImportRequest{
+		Format:    *format,
+		DryRun:    *dryRun,
+		Organizer: *organizer,
+		Timezone:  *timezone,
+	}
+	if request.Format == "" {
+		request.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*filePath)), ".")
+		if request.Format == "json" {
+			request.Format = eventModel.ImportFormatGeoJSON
+		}
+	}
+	if *mappingStr != "" {
+		err = json.Unmarshal([]byte(*mappingStr), &request.Mapping)
+		assert.ErrorNil(err, "invalid -mapping")
+	}
+	if *userIDStr != "" {
+		userID, err := uuid.Parse(*userIDStr)
+		assert.ErrorNil(err, "invalid -user")
+		request.CreatedBy = &userID
+	}
+
+	file, err := os.Open(*filePath)
+	assert.ErrorNil(err, "failed to open file")
+	defer file.Close()
+
+	repos := repo.InitRepositories(lg, dbcon)
+	// imports from the command line are screened like uploads
+	result, err := services.InitServices(lg, repos).Import.Import(context.Background(), request, file)
+	assert.ErrorNil(err, "import failed")
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(result)
+	assert.ErrorNil(err, "failed to print result")
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	// user timezones must load on hosts without a zoneinfo database
+	_ "time/tzdata"
+
 	"github.com/quietguido/mapnu/mainservice/internal/app"
 )
 
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
@@ -18,6 +19,13 @@ import (
 	"github.com/quietguido/mapnu/mainservice/pkg/httpserver"
 )
 
+const (
+	trendingRefreshInterval   = 5 * time.Minute
+	seriesMaterializeInterval = time.Hour
+	reminderScheduleInterval  = time.Minute
+	searchDigestInterval      = 5 * time.Minute
+)
+
 func Execute() {
 	err := godotenv.Load("config.env")
 	assert.ErrorNil(err, "failed to load config.env")
@@ -37,6 +45,21 @@ func Execute() {
 	repos := repo.InitRepositories(lg, dbcon)
 	services := services.InitServices(lg, repos)
 	restHandler := rest.GetHandler(lg, services)
+
+	// background workers stop with the server
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	go services.Event.RunTrendingRefresher(workersCtx, trendingRefreshInterval)
+	go services.Event.RunSeriesMaterializer(workersCtx, seriesMaterializeInterval)
+	go services.Change.Run(workersCtx)
+	go services.Live.Run(workersCtx)
+	go services.Stream.Run(workersCtx)
+	go services.Outbox.Run(workersCtx)
+	go services.Reminder.Run(workersCtx, reminderScheduleInterval)
+	go services.Webhook.Run(workersCtx)
+	go services.SavedSearch.Run(workersCtx, searchDigestInterval)
+
 	server := httpserver.New(":8080", restHandler)
 
 	oschan := make(chan os.Signal, 1)
@@ -56,6 +79,7 @@ func Execute() {
 	}
 
 	// Gracefulshutdown
+	stopWorkers()
 
 	if err = server.Shutdown(20 * time.Second); err != nil {
 		exitcode = 1
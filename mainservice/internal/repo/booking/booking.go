@@ -2,6 +2,7 @@ package booking
 
 import (
 	"context"
+	dbsql "database/sql"
 	"strconv"
 
 	sq "github.com/Masterminds/squirrel"
@@ -11,6 +12,10 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/quietguido/mapnu/mainservice/internal/repo/booking/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/change"
+	changeModel "github.com/quietguido/mapnu/mainservice/internal/repo/change/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/outbox"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
 	"github.com/quietguido/mapnu/mainservice/pkg/assert"
 )
 
@@ -33,6 +38,12 @@ func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
 }
 
 func (rp *repository) CreateBooking(ctx context.Context, createBooking model.CreateBooking) (int, error) {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
 	insertQuery := rp.builder.
 		Insert(bookingTable).Columns(
 		"user_id",
@@ -42,18 +53,36 @@ func (rp *repository) CreateBooking(ctx context.Context, createBooking model.Cre
 		createBooking.UserID,
 		createBooking.EventID,
 		createBooking.Visibility,
-	).Suffix("RETURNING booking_id")
+	).Suffix("RETURNING booking_id, booking_status")
 
 	sql, args, err := insertQuery.ToSql()
 	assert.IsNil(err, "Failed to build SQL query")
 
 	var bookingID int
-	err = rp.db.QueryRowContext(ctx, sql, args...).Scan(&bookingID)
+	var status string
+	err = tx.QueryRowContext(ctx, sql, args...).Scan(&bookingID, &status)
 	if err != nil {
 		rp.lg.Warn(sql)
 		return 0, errors.Wrap(err, "Failed to execute SQL query")
 	}
 
+	bookingChange := changeModel.BookingChange{
+		Type:      changeModel.BookingCreated,
+		BookingID: int64(bookingID),
+		EventID:   createBooking.EventID,
+		UserID:    createBooking.UserID,
+		Status:    status,
+	}
+	if err := change.Emit(ctx, tx, changeModel.Change{Booking: &bookingChange}); err != nil {
+		return 0, err
+	}
+	if err := outbox.Enqueue(ctx, tx, outboxModel.TopicBookingCreated, bookingChange); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit transaction")
+	}
 	return bookingID, nil
 }
 
@@ -124,10 +153,17 @@ func (rp *repository) GetBookingsForUser(ctx context.Context, userID uuid.UUID)
 }
 
 func (rp *repository) ChangeBookingStatus(ctx context.Context, bookingId int, status string) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
 	updateQuery := rp.builder.
 		Update(bookingTable).
 		Set("booking_status", status).
-		Where(sq.Eq{"booking_id": bookingId})
+		Where(sq.Eq{"booking_id": bookingId}).
+		Suffix("RETURNING user_id, event_id")
 
 	sql, args, err := updateQuery.ToSql()
 	if err != nil {
@@ -135,21 +171,46 @@ func (rp *repository) ChangeBookingStatus(ctx context.Context, bookingId int, st
 		return errors.Wrap(err, "Failed to build SQL query")
 	}
 
-	result, err := rp.db.ExecContext(ctx, sql, args...)
+	bookingChange := changeModel.BookingChange{
+		Type:      changeModel.BookingStatusChanged,
+		BookingID: int64(bookingId),
+		Status:    status,
+	}
+	err = tx.QueryRowContext(ctx, sql, args...).Scan(&bookingChange.UserID, &bookingChange.EventID)
+	if errors.Is(err, dbsql.ErrNoRows) {
+		rp.lg.Warn("No booking found with the given ID", zap.Int("booking_id", bookingId))
+		return errors.New("No booking found with the given ID")
+	}
 	if err != nil {
 		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
 		return errors.Wrap(err, "Failed to update status")
 	}
 
-	num, err := result.RowsAffected()
-	if err != nil {
-		rp.lg.Error("Failed to get affected rows", zap.Error(err))
-		return errors.Wrap(err, "Failed to update status")
+	if err := change.Emit(ctx, tx, changeModel.Change{Booking: &bookingChange}); err != nil {
+		return err
 	}
-	if num == 0 {
-		rp.lg.Warn("No booking found with the given ID", zap.Int("booking_id", bookingId))
-		return errors.New("No booking found with the given ID")
+	if err := outbox.Enqueue(ctx, tx, outboxModel.TopicBookingStatusChanged, bookingChange); err != nil {
+		return err
 	}
 
-	return nil
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+// GetAttendeeIds returns the users with a pending or confirmed booking for the event
+func (rp *repository) GetAttendeeIds(ctx context.Context, eventId int64) ([]uuid.UUID, error) {
+	selectQuery := rp.builder.
+		Select("DISTINCT user_id").
+		From(bookingTable).
+		Where(sq.Eq{"event_id": eventId, "booking_status": []string{"pending", "confirmed"}}).
+		Where(sq.NotEq{"user_id": nil})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var userIds []uuid.UUID
+	if err := rp.db.SelectContext(ctx, &userIds, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch attendees")
+	}
+	return userIds, nil
 }
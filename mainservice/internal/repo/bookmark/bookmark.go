@@ -0,0 +1,111 @@
+package bookmark
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const bookmarkTable = "event_bookmarks"
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// AddBookmark saves the event for the user and bumps its interested counter in the same
+// transaction. Bookmarking twice is a no-op.
+func (rp *repository) AddBookmark(ctx context.Context, userID uuid.UUID, eventID int64) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	insertQuery := rp.builder.
+		Insert(bookmarkTable).
+		Columns("user_id", "event_id").
+		Values(userID, eventID).
+		Suffix("ON CONFLICT (user_id, event_id) DO NOTHING")
+
+	added, err := rp.execCount(ctx, tx, insertQuery)
+	if err != nil {
+		return err
+	}
+	if added {
+		if err := rp.adjustInterested(ctx, tx, eventID, 1); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+// RemoveBookmark is the reverse of AddBookmark, removing a missing bookmark is a no-op.
+func (rp *repository) RemoveBookmark(ctx context.Context, userID uuid.UUID, eventID int64) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	deleteQuery := rp.builder.
+		Delete(bookmarkTable).
+		Where(sq.Eq{"user_id": userID, "event_id": eventID})
+
+	removed, err := rp.execCount(ctx, tx, deleteQuery)
+	if err != nil {
+		return err
+	}
+	if removed {
+		if err := rp.adjustInterested(ctx, tx, eventID, -1); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+// execCount runs the statement and reports whether it touched a row
+func (rp *repository) execCount(ctx context.Context, tx *sqlx.Tx, statement sq.Sqlizer) (bool, error) {
+	query, args, err := statement.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return false, errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to get affected rows")
+	}
+	return num > 0, nil
+}
+
+func (rp *repository) adjustInterested(ctx context.Context, tx *sqlx.Tx, eventID int64, delta int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE event SET interested_count = GREATEST(interested_count + $1, 0) WHERE event_id = $2;
+	`, delta, eventID)
+	if err != nil {
+		rp.lg.Error("Failed to update interested counter", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return nil
+}
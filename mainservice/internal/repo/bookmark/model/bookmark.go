@@ -0,0 +1,9 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+type CreateBookmark struct {
+	UserID uuid.UUID `json:"user_id"`
+}
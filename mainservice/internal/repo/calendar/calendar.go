@@ -0,0 +1,81 @@
+package calendar
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/calendar/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	calendarTokenTable = "calendar_tokens"
+)
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// SetToken stores the user's token, replacing (and so revoking) the previous one
+func (rp *repository) SetToken(ctx context.Context, userID uuid.UUID, tokenHash string) error {
+	upsertQuery := rp.builder.
+		Insert(calendarTokenTable).
+		Columns("user_id", "token_hash").
+		Values(userID, tokenHash).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = CURRENT_TIMESTAMP")
+
+	sql, args, err := upsertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := rp.db.ExecContext(ctx, sql, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to store calendar token")
+	}
+	return nil
+}
+
+func (rp *repository) DeleteToken(ctx context.Context, userID uuid.UUID) error {
+	deleteQuery := rp.builder.
+		Delete(calendarTokenTable).
+		Where(sq.Eq{"user_id": userID})
+
+	sql, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := rp.db.ExecContext(ctx, sql, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to revoke calendar token")
+	}
+	return nil
+}
+
+func (rp *repository) GetTokenByHash(ctx context.Context, tokenHash string) (*model.CalendarToken, error) {
+	selectQuery := rp.builder.
+		Select("user_id", "token_hash", "created_at").
+		From(calendarTokenTable).
+		Where(sq.Eq{"token_hash": tokenHash})
+
+	sql, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var token model.CalendarToken
+	if err := rp.db.GetContext(ctx, &token, sql, args...); err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch calendar token")
+	}
+	return &token, nil
+}
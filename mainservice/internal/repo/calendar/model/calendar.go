@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CalendarToken struct {
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	TokenHash string    `json:"-" db:"token_hash"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type CreateCalendarToken struct {
+	UserID uuid.UUID `json:"user_id" db:"user_id"`
+}
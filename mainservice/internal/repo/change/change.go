@@ -0,0 +1,75 @@
+// Package change lets repositories emit domain changes from inside their
+// transactions. Changes are sent with pg_notify, which Postgres delivers to the
+// listeners of every instance only once the transaction commits, and never
+// when it rolls back.
+package change
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/change/model"
+)
+
+// Emit queues the change on the transaction
+func Emit(ctx context.Context, tx sqlx.ExecerContext, change model.Change) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode change")
+	}
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", model.Channel, string(data)); err != nil {
+		return errors.Wrap(err, "Failed to emit change")
+	}
+	return nil
+}
+
+// EmitEvents queues an event change for each of the events, built from their
+// current rows. Deletions have to emit before deleting.
+func EmitEvents(ctx context.Context, tx sqlx.ExecerContext, changeType string, eventIDs ...int64) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		SELECT pg_notify($1, json_build_object('event', json_build_object(
+			'type', $2::text,
+			'event_id', event_id,
+			'series_id', series_id,
+			'location_lat', ST_Y(location),
+			'location_lon', ST_X(location),
+			'start_date', start_date,
+			'end_date', end_date
+		))::text)
+		FROM event
+		WHERE event_id = ANY($3::bigint[]);
+	`, model.Channel, changeType, eventIDs)
+	if err != nil {
+		return errors.Wrap(err, "Failed to emit event changes")
+	}
+	return nil
+}
+
+// EmitSeries queues a series change located at the series' current location
+func EmitSeries(ctx context.Context, tx sqlx.ExecerContext, changeType string, seriesID int64, from, until time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		SELECT pg_notify($1, json_build_object('series', json_build_object(
+			'type', $2::text,
+			'series_id', series_id,
+			'location_lat', ST_Y(location),
+			'location_lon', ST_X(location),
+			'from', $4::timestamptz,
+			'until', $5::timestamptz
+		))::text)
+		FROM event_series
+		WHERE series_id = $3;
+	`, model.Channel, changeType, seriesID, from, until)
+	if err != nil {
+		return errors.Wrap(err, "Failed to emit series change")
+	}
+	return nil
+}
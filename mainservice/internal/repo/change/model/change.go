@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Channel is the notification channel changes are distributed on
+const Channel = "domain_changes"
+
+// event change types
+const (
+	EventCreated   = "created"
+	EventUpdated   = "updated"
+	EventCancelled = "cancelled"
+	EventVotes     = "votes"
+	EventHidden    = "hidden"
+	EventRestored  = "restored"
+)
+
+// series change types, they stand for many occurrences changing at once
+const (
+	SeriesCreated  = "created"
+	SeriesUpdated  = "updated"
+	SeriesExtended = "extended"
+)
+
+// booking change types
+const (
+	BookingCreated       = "created"
+	BookingStatusChanged = "status_changed"
+)
+
+// Change is the envelope sent over the channel, exactly one field is set
+type Change struct {
+	Event   *EventChange   `json:"event,omitempty"`
+	Series  *SeriesChange  `json:"series,omitempty"`
+	Booking *BookingChange `json:"booking,omitempty"`
+}
+
+// EventChange carries where and when the event takes place as of the change,
+// so consumers can filter without loading it and still know about deleted events.
+type EventChange struct {
+	Type         string     `json:"type"`
+	EventID      int64      `json:"event_id"`
+	SeriesID     *int64     `json:"series_id,omitempty"`
+	Location_lat float64    `json:"location_lat"`
+	Location_lon float64    `json:"location_lon"`
+	StartDate    time.Time  `json:"start_date"`
+	EndDate      *time.Time `json:"end_date,omitempty"`
+}
+
+// SeriesChange covers the occurrences of the series at the location between From and Until
+type SeriesChange struct {
+	Type         string    `json:"type"`
+	SeriesID     int64     `json:"series_id"`
+	Location_lat float64   `json:"location_lat"`
+	Location_lon float64   `json:"location_lon"`
+	From         time.Time `json:"from"`
+	Until        time.Time `json:"until"`
+}
+
+type BookingChange struct {
+	Type      string    `json:"type"`
+	BookingID int64     `json:"booking_id"`
+	EventID   int64     `json:"event_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Status    string    `json:"booking_status"`
+}
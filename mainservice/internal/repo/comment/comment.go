@@ -0,0 +1,235 @@
+package comment
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/comment/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	commentTable = "event_comments"
+)
+
+// commentColumns is the select list for model.Comment, the table must be aliased as "c"
+const commentColumns = `
+			c.comment_id,
+			c.event_id,
+			c.root_id,
+			c.parent_id,
+			c.user_id,
+			c.body,
+			to_jsonb(c.mentions) AS mentions,
+			c.pinned,
+			c.status,
+			c.created_at,
+			c.edited_at`
+
+// authorNotHidden leaves out comments of users hidden by moderation
+const authorNotHidden = "NOT EXISTS (SELECT 1 FROM users hu WHERE hu.id = c.user_id AND hu.hidden)"
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// CreateComment stores the comment, rootID is the thread it belongs to (nil for a new thread)
+func (rp *repository) CreateComment(ctx context.Context, createComment model.CreateComment, rootID *int64) (int64, error) {
+	insertQuery := rp.builder.
+		Insert(commentTable).Columns(
+		"event_id",
+		"root_id",
+		"parent_id",
+		"user_id",
+		"body",
+		"mentions",
+		"status",
+	).Values(
+		createComment.EventID,
+		rootID,
+		createComment.ParentID,
+		createComment.UserID,
+		createComment.Body,
+		sq.Expr("?::uuid[]", uuidStrings(createComment.Mentions)),
+		createComment.Status,
+	).Suffix("RETURNING comment_id")
+
+	query, args, err := insertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var commentID int64
+	if err := rp.db.QueryRowxContext(ctx, query, args...).Scan(&commentID); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to create comment")
+	}
+	return commentID, nil
+}
+
+func (rp *repository) GetCommentById(ctx context.Context, commentID int64) (*model.Comment, error) {
+	selectQuery := rp.builder.
+		Select(commentColumns).
+		From(commentTable + " c").
+		Where(sq.Eq{"c.comment_id": commentID})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var comment model.Comment
+	if err := rp.db.GetContext(ctx, &comment, query, args...); err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch comment")
+	}
+	return &comment, nil
+}
+
+// GetThreads returns top level comments of the event oldest first. Hidden comments and comments
+// of hidden users are left out, deleted ones stay as placeholders so their replies keep their context.
+func (rp *repository) GetThreads(ctx context.Context, params model.GetCommentsQueryParams) ([]model.Comment, error) {
+	selectQuery := rp.builder.
+		Select(
+			commentColumns,
+			`(SELECT COUNT(*) FROM event_comments r
+				WHERE r.root_id = c.comment_id AND r.status = 'visible'
+					AND NOT EXISTS (SELECT 1 FROM users hu WHERE hu.id = r.user_id AND hu.hidden)
+			) AS reply_count`,
+		).
+		From(commentTable+" c").
+		Where(sq.Eq{"c.event_id": params.EventID, "c.root_id": nil}).
+		Where(sq.NotEq{"c.status": model.StatusHidden}).
+		Where(authorNotHidden).
+		Where("(c.created_at, c.comment_id) > (?, ?)", params.AfterCreated, params.AfterID).
+		OrderBy("c.created_at ASC", "c.comment_id ASC").
+		Limit(uint64(params.Limit))
+
+	return rp.selectComments(ctx, selectQuery)
+}
+
+// GetReplies returns visible replies of the thread by users that are not hidden, oldest first
+func (rp *repository) GetReplies(ctx context.Context, params model.GetCommentsQueryParams) ([]model.Comment, error) {
+	selectQuery := rp.builder.
+		Select(commentColumns).
+		From(commentTable+" c").
+		Where(sq.Eq{"c.root_id": params.RootID, "c.status": model.StatusVisible}).
+		Where(authorNotHidden).
+		Where("(c.created_at, c.comment_id) > (?, ?)", params.AfterCreated, params.AfterID).
+		OrderBy("c.created_at ASC", "c.comment_id ASC").
+		Limit(uint64(params.Limit))
+
+	return rp.selectComments(ctx, selectQuery)
+}
+
+// GetPinnedReplies returns the visible pinned replies of the given threads
+func (rp *repository) GetPinnedReplies(ctx context.Context, rootIDs []int64) ([]model.Comment, error) {
+	if len(rootIDs) == 0 {
+		return nil, nil
+	}
+
+	selectQuery := rp.builder.
+		Select(commentColumns).
+		From(commentTable+" c").
+		Where(sq.Eq{"c.root_id": rootIDs, "c.pinned": true, "c.status": model.StatusVisible}).
+		Where(authorNotHidden).
+		OrderBy("c.created_at ASC", "c.comment_id ASC")
+
+	return rp.selectComments(ctx, selectQuery)
+}
+
+func (rp *repository) UpdateComment(ctx context.Context, commentID int64, update model.UpdateComment) error {
+	updateQuery := rp.builder.
+		Update(commentTable).
+		Set("body", update.Body).
+		Set("mentions", sq.Expr("?::uuid[]", uuidStrings(update.Mentions))).
+		Set("edited_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"comment_id": commentID}).
+		Where(sq.NotEq{"status": model.StatusDeleted})
+
+	return rp.execUpdate(ctx, updateQuery)
+}
+
+// DeleteComment soft deletes the comment, the row stays so replies keep their thread
+func (rp *repository) DeleteComment(ctx context.Context, commentID int64) error {
+	updateQuery := rp.builder.
+		Update(commentTable).
+		Set("status", model.StatusDeleted).
+		Set("body", "").
+		Set("mentions", sq.Expr("'{}'")).
+		Set("pinned", false).
+		Where(sq.Eq{"comment_id": commentID})
+
+	return rp.execUpdate(ctx, updateQuery)
+}
+
+func (rp *repository) SetPinned(ctx context.Context, commentID int64, pinned bool) error {
+	updateQuery := rp.builder.
+		Update(commentTable).
+		Set("pinned", pinned).
+		Where(sq.Eq{"comment_id": commentID, "status": model.StatusVisible})
+
+	return rp.execUpdate(ctx, updateQuery)
+}
+
+// SetStatus hides or restores a comment, used by moderation
+func (rp *repository) SetStatus(ctx context.Context, commentID int64, status string) error {
+	updateQuery := rp.builder.
+		Update(commentTable).
+		Set("status", status).
+		Where(sq.Eq{"comment_id": commentID}).
+		Where(sq.NotEq{"status": model.StatusDeleted})
+
+	return rp.execUpdate(ctx, updateQuery)
+}
+
+func (rp *repository) selectComments(ctx context.Context, selectQuery sq.SelectBuilder) ([]model.Comment, error) {
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var comments []model.Comment
+	if err := rp.db.SelectContext(ctx, &comments, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch comments")
+	}
+	return comments, nil
+}
+
+func (rp *repository) execUpdate(ctx context.Context, updateQuery sq.UpdateBuilder) error {
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get affected rows")
+	}
+	if num == 0 {
+		return errors.New("No rows were updated")
+	}
+	return nil
+}
+
+// uuidStrings passes UUIDs as text, cast to uuid[] in the query
+func uuidStrings(ids []uuid.UUID) []string {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, id.String())
+	}
+	return strs
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// comment statuses, must stay in sync with the CHECK constraint on event_comments.status
+const (
+	StatusVisible = "visible"
+	StatusHidden  = "hidden" // held back by moderation
+	StatusDeleted = "deleted"
+)
+
+type Comment struct {
+	CommentID  int64      `json:"comment_id" db:"comment_id"`             // BIGSERIAL Primary Key
+	EventID    int64      `json:"event_id" db:"event_id"`                 // BIGINT NOT NULL
+	RootID     *int64     `json:"root_id,omitempty" db:"root_id"`         // top level comment of the thread
+	ParentID   *int64     `json:"parent_id,omitempty" db:"parent_id"`     // comment replied to
+	UserID     *uuid.UUID `json:"user_id,omitempty" db:"user_id"`         // UUID (Nullable, FK to users)
+	Body       string     `json:"body" db:"body"`                         // TEXT NOT NULL, empty once deleted
+	Mentions   UserIDs    `json:"mentions" db:"mentions"`                 // UUID[] NOT NULL DEFAULT '{}'
+	Pinned     bool       `json:"pinned" db:"pinned"`                     // BOOLEAN NOT NULL DEFAULT FALSE
+	Status     string     `json:"status" db:"status"`                     // "visible", "hidden", "deleted"
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`             // TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+	EditedAt   *time.Time `json:"edited_at,omitempty" db:"edited_at"`     // TIMESTAMP WITH TIME ZONE (Nullable)
+	ReplyCount int        `json:"reply_count,omitempty" db:"reply_count"` // visible replies, top level comments only
+}
+
+// Thread is a top level comment with its pinned replies
+type Thread struct {
+	Comment
+	PinnedReplies []Comment `json:"pinned_replies"`
+}
+
+type CreateComment struct {
+	EventID  int64       `json:"-"`
+	ParentID *int64      `json:"parent_id,omitempty"`
+	UserID   uuid.UUID   `json:"user_id"`
+	Body     string      `json:"body"`
+	Mentions []uuid.UUID `json:"-"`
+	Status   string      `json:"-"` // set by moderation hooks
+}
+
+type UpdateComment struct {
+	UserID   uuid.UUID   `json:"user_id"`
+	Body     string      `json:"body"`
+	Mentions []uuid.UUID `json:"-"`
+}
+
+type PinComment struct {
+	UserID uuid.UUID `json:"user_id"`
+	Pinned bool      `json:"pinned"`
+}
+
+// GetCommentsQueryParams pages through top level comments of an event or replies of a thread,
+// the page starts after (AfterCreated, AfterID)
+type GetCommentsQueryParams struct {
+	EventID      int64
+	RootID       int64
+	AfterCreated time.Time
+	AfterID      int64
+	Limit        int
+}
+
+type ThreadPage struct {
+	Threads    []Thread `json:"threads"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+type ReplyPage struct {
+	Replies    []Comment `json:"replies"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// UserIDs scans a UUID[] column selected as JSON (to_jsonb(mentions))
+type UserIDs []uuid.UUID
+
+func (u *UserIDs) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*u = UserIDs{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for user ids: %T", src)
+	}
+
+	var ids []uuid.UUID
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return err
+	}
+	*u = ids
+	return nil
+}
@@ -0,0 +1,48 @@
+package event
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+// GetBookmarkedEvents returns the events the user bookmarked ordered by (start_date, event_id)
+// for keyset pagination. Events that already ended are left out unless IncludePast is set.
+func (rp *repository) GetBookmarkedEvents(ctx context.Context, params model.GetBookmarksQueryParams) ([]model.BookmarkedEvent, error) {
+	selectQuery := rp.builder.
+		Select(eventColumns, "bm.created_at AS bookmarked_at").
+		From("event e").
+		Join("event_bookmarks bm ON bm.event_id = e.event_id").
+		Where(sq.Eq{"bm.user_id": params.UserID}).
+		Where(notHidden).
+		Where(sq.Or{
+			sq.NotEq{"e.visibility": model.VisibilityInviteOnly},
+			accessibleTo(params.UserID), // the user may have been taken off the invite list since
+		}).
+		Where("(e.start_date, e.event_id) > (?, ?)", params.AfterStart, params.AfterID)
+	if !params.IncludePast {
+		selectQuery = selectQuery.Where(
+			"e.start_date >= ? AND COALESCE(e.end_date, e.start_date) >= ?",
+			params.Now.Add(-model.MaxDuration),
+			params.Now,
+		)
+	}
+	selectQuery = selectQuery.
+		OrderBy("e.start_date ASC", "e.event_id ASC").
+		Limit(uint64(params.Limit))
+
+	selectquery, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var events []model.BookmarkedEvent
+	if err := rp.db.SelectContext(ctx, &events, selectquery, args...); err != nil {
+		rp.lg.Error("Failed to execute GetBookmarkedEvents query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return events, nil
+}
@@ -2,15 +2,22 @@ package event
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strconv"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	"github.com/quietguido/mapnu/mainservice/internal/repo/change"
+	changeModel "github.com/quietguido/mapnu/mainservice/internal/repo/change/model"
 	"github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/outbox"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/report"
 	"github.com/quietguido/mapnu/mainservice/pkg/assert"
 )
 
@@ -18,6 +25,9 @@ const (
 	eventTable = "event"
 )
 
+// errOccurrenceExists is returned when the occurrence of a series was materialized before
+var errOccurrenceExists = errors.New("Occurrence already exists")
+
 type repository struct {
 	lg      *zap.Logger
 	db      *sqlx.DB
@@ -39,55 +49,106 @@ FOR VALUES FROM ('2025-03-15 00:00:00') TO ('2025-03-16 00:00:00');
 */
 
 func (rp *repository) CreateEvent(ctx context.Context, createEvent model.CreateEvent) (int, error) {
-	partition_event_table := getPartition(createEvent.StartDate)
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	eventID, err := rp.insertEvent(ctx, tx, createEvent)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := change.EmitEvents(ctx, tx, changeModel.EventCreated, eventID); err != nil {
+		return 0, err
+	}
+	if err := outbox.EnqueueEvents(ctx, tx, outboxModel.TopicEventCreated, eventID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit transaction")
+	}
+	return int(eventID), nil
+}
+
+// insertEvent writes the event through the parent table, creating its daily partition when needed
+func (rp *repository) insertEvent(ctx context.Context, tx *sqlx.Tx, createEvent model.CreateEvent) (int64, error) {
+	if err := ensurePartition(ctx, tx, createEvent.StartDate); err != nil {
+		rp.lg.Error("Failed to ensure partition", zap.Error(err))
+		return 0, err
+	}
 
 	insertQuery := rp.builder.
-		Insert(partition_event_table).Columns(
+		Insert(eventTable).Columns(
 		"name",
 		"description",
 		"created_by",
 		"location",
 		"start_date",
+		"end_date",
 		"organizer",
+		"category",
+		"tags",
+		"series_id",
+		"occurrence_date",
+		"venue_id",
+		"organizer_id",
+		"visibility",
+		"hidden",
 	).Values(
 		createEvent.Name,
 		createEvent.Description,
 		createEvent.CreatedBy,
 		sq.Expr("ST_SetSRID(ST_Point(?, ?), 4326)", createEvent.Location_lon, createEvent.Location_lat),
 		createEvent.StartDate,
+		createEvent.EndDate,
 		createEvent.Organizer,
-	).Suffix("RETURNING event_id")
+		createEvent.Category,
+		createEvent.Tags,
+		createEvent.SeriesID,
+		createEvent.OccurrenceDate,
+		createEvent.VenueID,
+		createEvent.OrganizerID,
+		createEvent.Visibility,
+		createEvent.Hidden,
+	)
+	if createEvent.SeriesID != nil {
+		// another instance may have materialized the occurrence already
+		insertQuery = insertQuery.Suffix("ON CONFLICT DO NOTHING RETURNING event_id")
+	} else {
+		insertQuery = insertQuery.Suffix("RETURNING event_id")
+	}
 
-	sql, args, err := insertQuery.ToSql()
+	query, args, err := insertQuery.ToSql()
 	assert.IsNil(err, "Failed to build SQL query")
 
-	var eventID int
-	err = rp.db.QueryRowContext(ctx, sql, args...).Scan(&eventID)
+	var eventID int64
+	err = tx.QueryRowxContext(ctx, query, args...).Scan(&eventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errOccurrenceExists
+	}
 	if err != nil {
-		rp.lg.Warn(sql)
+		rp.lg.Warn(query)
 		return 0, errors.Wrap(err, "Failed to execute SQL query")
 	}
 
+	if createEvent.Hidden && createEvent.HoldReason != "" {
+		if err := report.FileScreeningReport(ctx, tx, eventID, createEvent.HoldReason); err != nil {
+			return 0, err
+		}
+	}
+
 	return eventID, nil
 }
 
 func (rp *repository) GetEventById(ctx context.Context, eventId int) (*model.Event, error) {
-	selectquery := `
-		SELECT
-			event_id,
-			name,
-			description,
-			created_by,
-			ST_X(location) AS location_lon, -- Ensure longitude is first (PostGIS standard)
-			ST_Y(location) AS location_lat, -- Latitude second
-			start_date,
-			organizer,
-			upvote,
-			downvote,
-			created_at
-		FROM event
-		WHERE event_id = $1;
-	`
+	selectquery := fmt.Sprintf(`
+		SELECT %s
+		FROM event e
+		WHERE e.event_id = $1;
+	`, eventColumns)
 
 	// Execute the query
 	row := rp.db.QueryRowxContext(ctx, selectquery, eventId)
@@ -102,43 +163,82 @@ func (rp *repository) GetEventById(ctx context.Context, eventId int) (*model.Eve
 }
 
 func (rp *repository) GetMapForQuadrant(ctx context.Context, mapQuery model.GetMapQueryParams) ([]model.Event, error) {
-	// ✅ Dynamically get partition table name
-	partitionTable := getPartition(mapQuery.Date) // Format: "event_YYYY_MM_DD"
+	// ✅ Query the parent table, the start_date bounds let Postgres prune partitions
+	// while still finding multi-day events that started on an earlier day
+	selectQuery := rp.builder.
+		Select(eventColumns).
+		From("event e").
+		Where(
+			withinEnvelope,         // Bounding box for spatial query
+			mapQuery.FirstQuadLon,  // Min Longitude
+			mapQuery.FirstQuadLat,  // Min Latitude
+			mapQuery.SecondQuadLon, // Max Longitude
+			mapQuery.SecondQuadLat, // Max Latitude
+		)
+	selectQuery = applyTimeWindow(selectQuery, mapQuery.From, mapQuery.To)
+	selectQuery = applyEventFilters(selectQuery, mapQuery.EventFilters).
+		Where(notHidden).
+		Where(listedFor(mapQuery.Viewer))
 
-	selectquery := fmt.Sprintf(`
-		SELECT
-			event_id,
-			name,
-			description,
-			created_by,
-			ST_X(location) AS location_lon, -- Longitude
-			ST_Y(location) AS location_lat, -- Latitude
-			start_date,
-			organizer,
-			upvote,
-			downvote,
-			created_at
-		FROM %s
-		WHERE
-			ST_Within(
-				location,
-				ST_SetSRID(
-					ST_MakeEnvelope($1, $2, $3, $4, 4326), -- Bounding box for spatial query
-					4326
-				)
-			)
-	`, partitionTable) // ✅ Inject partition table name
+	selectquery, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
 
-	args := []interface{}{
-		mapQuery.FirstQuadLon,  // $1 - Min Longitude
-		mapQuery.FirstQuadLat,  // $2 - Min Latitude
-		mapQuery.SecondQuadLon, // $3 - Max Longitude
-		mapQuery.SecondQuadLat, // $4 - Max Latitude
+	rows, err := rp.db.QueryxContext(ctx, selectquery, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute GetMapForQuadrant query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
 	}
+	defer rows.Close()
+
+	var events []model.Event
+	for rows.Next() {
+		var event model.Event
+		err := rows.StructScan(&event)
+		if err != nil {
+			rp.lg.Error("Failed to scan row", zap.Error(err))
+			return nil, errors.Wrap(err, "Failed to scan row")
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		rp.lg.Error("Row iteration error", zap.Error(err))
+		return nil, errors.Wrap(err, "Row iteration error")
+	}
+
+	return events, nil
+}
+
+func (rp *repository) SearchEvents(ctx context.Context, params model.SearchQueryParams) ([]model.Event, error) {
+	selectQuery := rp.builder.
+		Select(eventColumns).
+		From("event e")
+	if params.Query != "" {
+		pattern := containsPattern(params.Query)
+		selectQuery = selectQuery.Where(sq.Or{
+			sq.ILike{"e.name": pattern},
+			sq.ILike{"e.description": pattern},
+		})
+	}
+	if !params.From.IsZero() && !params.To.IsZero() {
+		selectQuery = applyTimeWindow(selectQuery, params.From, params.To)
+	} else if !params.From.IsZero() {
+		selectQuery = selectQuery.Where(sq.GtOrEq{"COALESCE(e.end_date, e.start_date)": params.From})
+	} else if !params.To.IsZero() {
+		selectQuery = selectQuery.Where(sq.Lt{"e.start_date": params.To})
+	}
+	selectQuery = applyEventFilters(selectQuery, params.EventFilters).
+		Where(notHidden).
+		Where(listedFor(params.Viewer)).
+		OrderBy("e.start_date ASC").
+		Limit(uint64(params.Limit))
+
+	selectquery, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
 
 	rows, err := rp.db.QueryxContext(ctx, selectquery, args...)
 	if err != nil {
-		rp.lg.Error("Failed to execute GetMapForQuadrant query", zap.Error(err))
+		rp.lg.Error("Failed to execute SearchEvents query", zap.Error(err))
 		return nil, errors.Wrap(err, "Failed to execute SQL query")
 	}
 	defer rows.Close()
@@ -161,3 +261,155 @@ func (rp *repository) GetMapForQuadrant(ctx context.Context, mapQuery model.GetM
 
 	return events, nil
 }
+
+func (rp *repository) GetEventsByIds(ctx context.Context, eventIds []int64) ([]model.Event, error) {
+	if len(eventIds) == 0 {
+		return nil, nil
+	}
+
+	selectQuery := rp.builder.
+		Select(eventColumns).
+		From("event e").
+		Where(sq.Eq{"e.event_id": eventIds}).
+		OrderBy("e.start_date ASC")
+
+	selectquery, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var events []model.Event
+	if err := rp.db.SelectContext(ctx, &events, selectquery, args...); err != nil {
+		rp.lg.Error("Failed to execute GetEventsByIds query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return events, nil
+}
+
+// GetUpcomingForVenues returns up to perVenue events per venue still running at from,
+// ordered by venue and start.
+func (rp *repository) GetUpcomingForVenues(ctx context.Context, venueIds []int64, from time.Time, perVenue int) ([]model.Event, error) {
+	if len(venueIds) == 0 {
+		return nil, nil
+	}
+
+	selectquery := `
+		SELECT ` + eventColumns + `
+		FROM unnest($1::bigint[]) AS v(venue_id)
+		CROSS JOIN LATERAL (
+			SELECT *
+			FROM event
+			WHERE event.venue_id = v.venue_id
+				AND event.start_date >= $3
+				AND COALESCE(event.end_date, event.start_date) >= $2
+				AND NOT event.hidden
+				AND NOT EXISTS (SELECT 1 FROM users hu WHERE hu.id = event.created_by AND hu.hidden)
+				AND event.visibility = 'public'
+			ORDER BY event.start_date
+			LIMIT $4
+		) e
+		ORDER BY e.venue_id, e.start_date;
+	`
+
+	var events []model.Event
+	err := rp.db.SelectContext(ctx, &events, selectquery, venueIds, from, from.Add(-model.MaxDuration), perVenue)
+	if err != nil {
+		rp.lg.Error("Failed to execute GetUpcomingForVenues query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return events, nil
+}
+
+// GetUpcomingForOrganizer returns the organizer's events still running at from, soonest first
+func (rp *repository) GetUpcomingForOrganizer(ctx context.Context, organizerId int64, from time.Time, limit int) ([]model.Event, error) {
+	selectQuery := rp.builder.
+		Select(eventColumns).
+		From("event e").
+		Where(sq.Eq{"e.organizer_id": organizerId}).
+		Where(notHidden).
+		Where(isPublic).
+		Where(sq.GtOrEq{"e.start_date": from.Add(-model.MaxDuration)}).
+		Where(sq.GtOrEq{"COALESCE(e.end_date, e.start_date)": from}).
+		OrderBy("e.start_date ASC").
+		Limit(uint64(limit))
+
+	selectquery, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var events []model.Event
+	if err := rp.db.SelectContext(ctx, &events, selectquery, args...); err != nil {
+		rp.lg.Error("Failed to execute GetUpcomingForOrganizer query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return events, nil
+}
+
+// SetEventHidden hides the event from listings or restores it, used by moderation.
+// Restoring an occurrence of a held series restores the series and all its occurrences,
+// restored events are published again so saved searches get to match them.
+func (rp *repository) SetEventHidden(ctx context.Context, eventId int64, hidden bool) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	updateQuery := rp.builder.
+		Update(eventTable).
+		Set("hidden", hidden).
+		Where(sq.Eq{"event_id": eventId})
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SetEventHidden query", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get affected rows")
+	}
+	if num == 0 {
+		return errors.New("No event found with the given ID")
+	}
+
+	changeType := changeModel.EventRestored
+	if hidden {
+		changeType = changeModel.EventHidden
+	}
+	eventIds := []int64{eventId}
+
+	// restoring an occurrence of a series held by screening publishes the whole series
+	if !hidden {
+		var restored []int64
+		err := tx.SelectContext(ctx, &restored, `
+			WITH series AS (
+				UPDATE event_series s SET hidden = FALSE
+				FROM event e
+				WHERE e.event_id = $1 AND s.series_id = e.series_id AND s.hidden
+				RETURNING s.series_id
+			)
+			UPDATE event e SET hidden = FALSE
+			FROM series
+			WHERE e.series_id = series.series_id AND e.hidden
+			RETURNING e.event_id;
+		`, eventId)
+		if err != nil {
+			rp.lg.Error("Failed to restore series occurrences", zap.Error(err))
+			return errors.Wrap(err, "Failed to execute SQL query")
+		}
+		eventIds = append(eventIds, restored...)
+	}
+
+	if err := change.EmitEvents(ctx, tx, changeType, eventIds...); err != nil {
+		return err
+	}
+	if !hidden {
+		if err := outbox.EnqueueEvents(ctx, tx, outboxModel.TopicEventPublished, eventIds...); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
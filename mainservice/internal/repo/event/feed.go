@@ -0,0 +1,65 @@
+package event
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+)
+
+// GetFeed returns upcoming events of followed organizers and venues and events friends
+// have public bookings for, ordered by (start_date, event_id) for keyset pagination.
+func (rp *repository) GetFeed(ctx context.Context, params model.GetFeedQueryParams) ([]model.FeedItem, error) {
+	selectquery := `
+		WITH followed_organizers AS (
+			SELECT organizer_id FROM organizer_follows WHERE user_id = $1
+		), followed_venues AS (
+			SELECT venue_id FROM venue_follows WHERE user_id = $1
+		), friends AS (
+			SELECT CASE WHEN user1_id = $1 THEN user2_id ELSE user1_id END AS friend_id
+			FROM friendships
+			WHERE (user1_id = $1 OR user2_id = $1) AND status = 'accepted'
+		), friend_bookings AS (
+			SELECT b.event_id, array_agg(DISTINCT b.user_id) AS friend_ids
+			FROM bookings b
+			JOIN friends f ON f.friend_id = b.user_id
+			WHERE b.visibility = 'public' AND b.booking_status <> 'rejected'
+			GROUP BY b.event_id
+		)
+		SELECT ` + eventColumns + `,
+			COALESCE(e.organizer_id IN (SELECT organizer_id FROM followed_organizers), FALSE) AS via_organizer,
+			COALESCE(e.venue_id IN (SELECT venue_id FROM followed_venues), FALSE) AS via_venue,
+			to_jsonb(fb.friend_ids) AS friend_ids
+		FROM event e
+		LEFT JOIN friend_bookings fb ON fb.event_id = e.event_id
+		WHERE e.start_date >= $3
+			AND COALESCE(e.end_date, e.start_date) >= $2
+			AND (e.start_date, e.event_id) > ($4, $5)
+			AND NOT ` + hiddenCondition + `
+			AND e.visibility = 'public'
+			AND (
+				e.organizer_id IN (SELECT organizer_id FROM followed_organizers)
+				OR e.venue_id IN (SELECT venue_id FROM followed_venues)
+				OR fb.event_id IS NOT NULL
+			)
+		ORDER BY e.start_date ASC, e.event_id ASC
+		LIMIT $6;
+	`
+
+	var items []model.FeedItem
+	err := rp.db.SelectContext(ctx, &items, selectquery,
+		params.UserID,
+		params.Now,
+		params.Now.Add(-model.MaxDuration),
+		params.AfterStart,
+		params.AfterID,
+		params.Limit,
+	)
+	if err != nil {
+		rp.lg.Error("Failed to execute GetFeed query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return items, nil
+}
@@ -0,0 +1,182 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/change"
+	changeModel "github.com/quietguido/mapnu/mainservice/internal/repo/change/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/outbox"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/report"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	// events closer than this with the same name and start are duplicates
+	duplicateDistanceMeters = 50
+
+	// keep each multi-row insert well under the bind parameter limit
+	insertBatchSize = 500
+)
+
+// FindDuplicates reports for each event whether an event with the same name and start
+// already exists nearby.
+func (rp *repository) FindDuplicates(ctx context.Context, events []model.CreateEvent) ([]bool, error) {
+	duplicates := make([]bool, len(events))
+	if len(events) == 0 {
+		return duplicates, nil
+	}
+
+	names := make([]string, 0, len(events))
+	starts := make([]time.Time, 0, len(events))
+	lons := make([]float64, 0, len(events))
+	lats := make([]float64, 0, len(events))
+	for _, event := range events {
+		names = append(names, event.Name)
+		starts = append(starts, event.StartDate)
+		lons = append(lons, event.Location_lon)
+		lats = append(lats, event.Location_lat)
+	}
+
+	selectquery := `
+		SELECT c.idx
+		FROM unnest($1::text[], $2::timestamptz[], $3::float8[], $4::float8[])
+			WITH ORDINALITY AS c(name, start_date, lon, lat, idx)
+		WHERE EXISTS (
+			SELECT 1
+			FROM event e
+			WHERE e.start_date = c.start_date
+				AND lower(e.name) = lower(c.name)
+				AND ST_DWithin(
+					e.location::geography,
+					ST_SetSRID(ST_Point(c.lon, c.lat), 4326)::geography,
+					$5
+				)
+		);
+	`
+
+	var indexes []int
+	err := rp.db.SelectContext(ctx, &indexes, selectquery, names, starts, lons, lats, duplicateDistanceMeters)
+	if err != nil {
+		rp.lg.Error("Failed to execute FindDuplicates query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	for _, idx := range indexes {
+		duplicates[idx-1] = true // ORDINALITY starts at 1
+	}
+	return duplicates, nil
+}
+
+// CreateEvents inserts the events in one transaction, batched per daily partition
+// so each batch is routed to a single partition.
+// The returned ids are in the order of events.
+func (rp *repository) CreateEvents(ctx context.Context, events []model.CreateEvent) ([]int64, error) {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	byPartition := make(map[string][]int)
+	var partitions []string
+	for i, event := range events {
+		partition := getPartition(event.StartDate)
+		if _, ok := byPartition[partition]; !ok {
+			if err := ensurePartition(ctx, tx, event.StartDate); err != nil {
+				rp.lg.Error("Failed to ensure partition", zap.Error(err))
+				return nil, err
+			}
+			partitions = append(partitions, partition)
+		}
+		byPartition[partition] = append(byPartition[partition], i)
+	}
+
+	ids := make([]int64, len(events))
+	for _, partition := range partitions {
+		indexes := byPartition[partition]
+
+		for start := 0; start < len(indexes); start += insertBatchSize {
+			batch := indexes[start:min(start+insertBatchSize, len(indexes))]
+
+			insertQuery := rp.builder.
+				Insert(eventTable).Columns(
+				"name",
+				"description",
+				"created_by",
+				"location",
+				"start_date",
+				"end_date",
+				"organizer",
+				"category",
+				"tags",
+				"venue_id",
+				"organizer_id",
+				"visibility",
+				"hidden",
+			)
+			for _, i := range batch {
+				event := events[i]
+				insertQuery = insertQuery.Values(
+					event.Name,
+					event.Description,
+					event.CreatedBy,
+					sq.Expr("ST_SetSRID(ST_Point(?, ?), 4326)", event.Location_lon, event.Location_lat),
+					event.StartDate,
+					event.EndDate,
+					event.Organizer,
+					event.Category,
+					event.Tags,
+					event.VenueID,
+					event.OrganizerID,
+					event.Visibility,
+					event.Hidden,
+				)
+			}
+			insertQuery = insertQuery.Suffix("RETURNING event_id")
+
+			sql, args, err := insertQuery.ToSql()
+			assert.IsNil(err, "Failed to build SQL query")
+
+			var batchIds []int64
+			if err := tx.SelectContext(ctx, &batchIds, sql, args...); err != nil {
+				rp.lg.Error("Failed to insert events batch", zap.String("partition", partition), zap.Error(err))
+				return nil, errors.Wrap(err, "Failed to execute SQL query")
+			}
+			if len(batchIds) != len(batch) {
+				return nil, errors.New("Inserted rows do not match the batch")
+			}
+
+			// a single multi-row INSERT returns rows in VALUES order
+			for j, i := range batch {
+				ids[i] = batchIds[j]
+			}
+		}
+	}
+
+	for i, event := range events {
+		if event.Hidden && event.HoldReason != "" {
+			if err := report.FileScreeningReport(ctx, tx, ids[i], event.HoldReason); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := change.EmitEvents(ctx, tx, changeModel.EventCreated, ids...); err != nil {
+		return nil, err
+	}
+	if err := outbox.EnqueueEvents(ctx, tx, outboxModel.TopicEventCreated, ids...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "Failed to commit transaction")
+	}
+	return ids, nil
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GetBookmarksQueryParams struct {
+	UserID      uuid.UUID
+	Now         time.Time
+	IncludePast bool // otherwise only events still running at Now
+	// keyset cursor, the page starts after (AfterStart, AfterID)
+	AfterStart time.Time
+	AfterID    int64
+	Limit      int
+}
+
+// BookmarkedEvent is an event the user saved
+type BookmarkedEvent struct {
+	Event
+	BookmarkedAt time.Time `json:"bookmarked_at" db:"bookmarked_at"`
+}
+
+type BookmarkPage struct {
+	Items      []BookmarkedEvent `json:"items"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
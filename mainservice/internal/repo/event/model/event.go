@@ -4,29 +4,98 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	imageModel "github.com/quietguido/mapnu/mainservice/internal/repo/image/model"
+)
+
+// MaxDuration bounds how long an event may last, so overlap queries only
+// have to look this far back into earlier start_date partitions
+const MaxDuration = 31 * 24 * time.Hour
+
+// event visibility levels
+const (
+	VisibilityPublic     = "public"      // listed everywhere
+	VisibilityUnlisted   = "unlisted"    // anyone with the link, never listed
+	VisibilityInviteOnly = "invite_only" // the creator, organizer members and invited users
 )
 
 type Event struct {
-	EventID      int64      `json:"event_id" db:"event_id"`               // BIGSERIAL Primary Key
-	Name         string     `json:"name" db:"name"`                       // VARCHAR(255) NOT NULL
-	Description  string     `json:"description" db:"description"`         // TEXT
-	CreatedBy    *uuid.UUID `json:"created_by,omitempty" db:"created_by"` // UUID (Nullable, FK to users)
-	Location_lat float64    `json:"location_lat" db:"location_lat"`       // For PostGIS geometry data
-	Location_lon float64    `json:"location_lon" db:"location_lon"`       // For PostGIS geometry data
-	StartDate    time.Time  `json:"start_date" db:"start_date"`           // TIMESTAMP WITH TIME ZONE NOT NULL
-	Organizer    string     `json:"organizer" db:"organizer"`             // VARCHAR(255) NOT NULL
-	Upvote       int        `json:"upvote" db:"upvote"`                   // INTEGER DEFAULT 0
-	Downvote     int        `json:"downvote" db:"downvote"`               // INTEGER DEFAULT 0
-	CreatedAt    time.Time  `json:"created_at" db:"created_at"`           // TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+	EventID        int64      `json:"event_id" db:"event_id"`                         // BIGSERIAL Primary Key
+	Name           string     `json:"name" db:"name"`                                 // VARCHAR(255) NOT NULL
+	Description    string     `json:"description" db:"description"`                   // TEXT
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty" db:"created_by"`           // UUID (Nullable, FK to users)
+	Location_lat   float64    `json:"location_lat" db:"location_lat"`                 // For PostGIS geometry data
+	Location_lon   float64    `json:"location_lon" db:"location_lon"`                 // For PostGIS geometry data
+	StartDate      time.Time  `json:"start_date" db:"start_date"`                     // TIMESTAMP WITH TIME ZONE NOT NULL
+	EndDate        *time.Time `json:"end_date,omitempty" db:"end_date"`               // TIMESTAMP WITH TIME ZONE (Nullable)
+	Organizer      string     `json:"organizer" db:"organizer"`                       // VARCHAR(255) NOT NULL
+	Upvote         int        `json:"upvote" db:"upvote"`                             // INTEGER DEFAULT 0
+	Downvote       int        `json:"downvote" db:"downvote"`                         // INTEGER DEFAULT 0
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`                     // TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+	Category       string     `json:"category" db:"category"`                         // VARCHAR(32) NOT NULL DEFAULT 'other'
+	Tags           Tags       `json:"tags" db:"tags"`                                 // TEXT[] NOT NULL DEFAULT '{}'
+	SeriesID       *int64     `json:"series_id,omitempty" db:"series_id"`             // BIGINT (Nullable, FK to event_series)
+	OccurrenceDate *time.Time `json:"occurrence_date,omitempty" db:"occurrence_date"` // start generated by the series rule
+	VenueID        *int64     `json:"venue_id,omitempty" db:"venue_id"`               // BIGINT (Nullable, FK to venues)
+	OrganizerID    *int64     `json:"organizer_id,omitempty" db:"organizer_id"`       // BIGINT (Nullable, FK to organizers)
+	RatingCount    int        `json:"rating_count" db:"rating_count"`                 // INTEGER NOT NULL DEFAULT 0
+	Rating         *float64   `json:"rating,omitempty" db:"rating"`                   // average review rating, nil without reviews
+	Interested     int        `json:"interested" db:"interested_count"`               // INTEGER NOT NULL DEFAULT 0, users who bookmarked it
+	Visibility     string     `json:"visibility" db:"visibility"`                     // "public", "unlisted", "invite_only"
+	Hidden         bool       `json:"hidden,omitempty" db:"hidden"`                   // hidden by moderation
+	MyVote         string     `json:"my_vote,omitempty" db:"-"`                       // "up", "down" for the requesting user
+
+	Images []imageModel.ImageURLs `json:"images,omitempty" db:"-"` // uploaded images, oldest first
 }
 
 // ✅ CreateEvent struct (for inserting new events)
 type CreateEvent struct {
-	Name         string     `json:"name" db:"name"`                       // VARCHAR(255) NOT NULL
-	Description  string     `json:"description" db:"description"`         // TEXT
-	CreatedBy    *uuid.UUID `json:"created_by,omitempty" db:"created_by"` // UUID (Nullable, FK to users)
-	Location_lat float64    `json:"location_lat" db:"location_lat"`       // For PostGIS geometry data
-	Location_lon float64    `json:"location_lon" db:"location_lon"`       // For PostGIS geometry data
-	StartDate    time.Time  `json:"start_date" db:"start_date"`           // TIMESTAMP WITH TIME ZONE NOT NULL
-	Organizer    string     `json:"organizer" db:"organizer"`             // VARCHAR(255) NOT NULL
+	Name           string     `json:"name" db:"name"`                           // VARCHAR(255) NOT NULL
+	Description    string     `json:"description" db:"description"`             // TEXT
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty" db:"created_by"`     // UUID (Nullable, FK to users)
+	Location_lat   float64    `json:"location_lat" db:"location_lat"`           // For PostGIS geometry data
+	Location_lon   float64    `json:"location_lon" db:"location_lon"`           // For PostGIS geometry data
+	StartDate      time.Time  `json:"start_date" db:"start_date"`               // TIMESTAMP WITH TIME ZONE NOT NULL
+	EndDate        *time.Time `json:"end_date,omitempty" db:"end_date"`         // TIMESTAMP WITH TIME ZONE (Nullable)
+	Organizer      string     `json:"organizer" db:"organizer"`                 // VARCHAR(255) NOT NULL
+	Category       string     `json:"category" db:"category"`                   // VARCHAR(32) NOT NULL DEFAULT 'other'
+	Tags           []string   `json:"tags" db:"tags"`                           // TEXT[] NOT NULL DEFAULT '{}'
+	Visibility     string     `json:"visibility" db:"visibility"`               // defaults to "public"
+	VenueID        *int64     `json:"venue_id,omitempty" db:"venue_id"`         // location is taken from the venue when set
+	OrganizerID    *int64     `json:"organizer_id,omitempty" db:"organizer_id"` // organizer is taken from the profile when set
+	SeriesID       *int64     `json:"-" db:"series_id"`                         // set for occurrences of a series
+	OccurrenceDate *time.Time `json:"-" db:"occurrence_date"`                   // set for occurrences of a series
+	Hidden         bool       `json:"-" db:"hidden"`                            // held for moderation by screening
+	HoldReason     string     `json:"-" db:"-"`                                 // files a screening report with the hidden event
+}
+
+// TrendingEvent is an event together with its cached trending score
+type TrendingEvent struct {
+	Event
+	Score float64 `json:"score" db:"score"`
+}
+
+// TrendingCandidate holds the raw signals the trending score is computed from
+type TrendingCandidate struct {
+	EventID        int64     `db:"event_id"`
+	Upvote         int       `db:"upvote"`
+	Downvote       int       `db:"downvote"`
+	RecentBookings int       `db:"recent_bookings"`
+	Interested     int       `db:"interested_count"`
+	CreatedAt      time.Time `db:"created_at"`
+	RatingCount    int       `db:"rating_count"`
+	RatingSum      int       `db:"rating_sum"`
+	// reviews of all events by the same creator
+	CreatorRatingCount int `db:"creator_rating_count"`
+	CreatorRatingSum   int `db:"creator_rating_sum"`
+}
+
+type TrendingScore struct {
+	EventID int64   `db:"event_id"`
+	Score   float64 `db:"score"`
+}
+
+type SetVisibility struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Visibility string    `json:"visibility"` // "public", "unlisted", "invite_only"
 }
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// reasons an event shows up in a feed
+const (
+	FeedReasonOrganizer     = "organizer"
+	FeedReasonVenue         = "venue"
+	FeedReasonFriendBooking = "friend_booking"
+)
+
+type GetFeedQueryParams struct {
+	UserID uuid.UUID
+	Now    time.Time // only events still running at Now
+	// keyset cursor, the page starts after (AfterStart, AfterID)
+	AfterStart time.Time
+	AfterID    int64
+	Limit      int
+}
+
+// FeedItem is an event with the reasons it is in the user's feed
+type FeedItem struct {
+	Event
+	ViaOrganizer bool     `json:"-" db:"via_organizer"`
+	ViaVenue     bool     `json:"-" db:"via_venue"`
+	Friends      UserIDs  `json:"friends,omitempty" db:"friend_ids"` // friends with a public booking
+	Reasons      []string `json:"reasons" db:"-"`
+}
+
+type FeedPage struct {
+	Items      []FeedItem `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// UserIDs scans a UUID[] column selected as JSON
+type UserIDs []uuid.UUID
+
+func (u *UserIDs) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*u = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for user ids: %T", src)
+	}
+
+	var ids []uuid.UUID
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return err
+	}
+	*u = ids
+	return nil
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	ImportFormatCSV     = "csv"
+	ImportFormatGeoJSON = "geojson"
+	ImportFormatICS     = "ics"
+
+	ImportRowValid     = "valid"
+	ImportRowInvalid   = "invalid"
+	ImportRowDuplicate = "duplicate"
+	ImportRowImported  = "imported"
+)
+
+type ImportRequest struct {
+	Format    string            `json:"format" form:"format"`       // "csv", "geojson", "ics"
+	DryRun    bool              `json:"dry_run" form:"dry_run"`     // validate and preview without inserting
+	Mapping   map[string]string `json:"mapping" form:"mapping"`     // source column/property -> event field
+	CreatedBy *uuid.UUID        `json:"created_by" form:"user_id"`  // owner of every imported event
+	Organizer string            `json:"organizer" form:"organizer"` // used when a row has no organizer
+	Timezone  string            `json:"timezone" form:"timezone"`   // for dates without an offset, defaults to UTC
+}
+
+type ImportRow struct {
+	Line    int         `json:"line"` // CSV line, GeoJSON feature index or ICS line of BEGIN:VEVENT
+	Status  string      `json:"status"`
+	Error   string      `json:"error,omitempty"`
+	EventID int64       `json:"event_id,omitempty"`
+	Held    bool        `json:"held,omitempty"` // created hidden until a moderator reviews it
+	Event   CreateEvent `json:"event"`
+}
+
+type ImportResult struct {
+	Format     string      `json:"format"`
+	DryRun     bool        `json:"dry_run"`
+	Total      int         `json:"total"`
+	Valid      int         `json:"valid"`
+	Invalid    int         `json:"invalid"`
+	Duplicates int         `json:"duplicates"`
+	Imported   int         `json:"imported"`
+	Held       int         `json:"held"`
+	Rows       []ImportRow `json:"rows"`
+}
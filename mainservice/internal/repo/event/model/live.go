@@ -0,0 +1,71 @@
+package model
+
+import (
+	"time"
+)
+
+// map delta types
+const (
+	DeltaCreated   = "created"
+	DeltaUpdated   = "updated"
+	DeltaCancelled = "cancelled"
+	DeltaVotes     = "votes"
+	// many events in the area changed, clients refetch the map
+	DeltaRefresh = "refresh"
+)
+
+// MapDelta is a change of an event as sent to live map clients. Refresh deltas
+// carry the area and time range that changed instead of an event.
+type MapDelta struct {
+	Type         string     `json:"type"`
+	EventID      int64      `json:"event_id,omitempty"`
+	SeriesID     *int64     `json:"series_id,omitempty"`
+	Name         string     `json:"name,omitempty"`
+	Category     string     `json:"category,omitempty"`
+	Location_lat float64    `json:"location_lat"`
+	Location_lon float64    `json:"location_lon"`
+	StartDate    time.Time  `json:"start_date"`
+	EndDate      *time.Time `json:"end_date,omitempty"`
+	Upvote       int        `json:"upvote"`
+	Downvote     int        `json:"downvote"`
+}
+
+func NewMapDelta(deltaType string, event Event) MapDelta {
+	return MapDelta{
+		Type:         deltaType,
+		EventID:      event.EventID,
+		SeriesID:     event.SeriesID,
+		Name:         event.Name,
+		Category:     event.Category,
+		Location_lat: event.Location_lat,
+		Location_lon: event.Location_lon,
+		StartDate:    event.StartDate,
+		EndDate:      event.EndDate,
+		Upvote:       event.Upvote,
+		Downvote:     event.Downvote,
+	}
+}
+
+// Viewport is the part of the map a live client is looking at, named like GetMapQueryParams
+type Viewport struct {
+	FirstQuadLon  float64   `json:"firstlon"`
+	FirstQuadLat  float64   `json:"firstlat"`
+	SecondQuadLon float64   `json:"secondlon"`
+	SecondQuadLat float64   `json:"secondlat"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+}
+
+// Contains reports whether the delta is located in the viewport and overlaps its time range
+func (v Viewport) Contains(delta MapDelta) bool {
+	if delta.Location_lat < min(v.FirstQuadLat, v.SecondQuadLat) || delta.Location_lat > max(v.FirstQuadLat, v.SecondQuadLat) ||
+		delta.Location_lon < min(v.FirstQuadLon, v.SecondQuadLon) || delta.Location_lon > max(v.FirstQuadLon, v.SecondQuadLon) {
+		return false
+	}
+
+	end := delta.StartDate
+	if delta.EndDate != nil {
+		end = *delta.EndDate
+	}
+	return delta.StartDate.Before(v.To) && !end.Before(v.From)
+}
@@ -2,14 +2,26 @@ package model
 
 import (
 	"time"
+
+	"github.com/google/uuid"
 )
 
+// EventFilters narrows event listings, empty fields are not applied
+type EventFilters struct {
+	Category string   `form:"category"`
+	Tags     []string `form:"tag"` // events must have all of the tags
+}
+
 type GetMapQueryParams struct {
 	FirstQuadLon  float64   `form:"firstlon"`
 	FirstQuadLat  float64   `form:"firstlat"`
 	SecondQuadLon float64   `form:"secondlon"`
 	SecondQuadLat float64   `form:"secondlat"`
-	Date          time.Time `form:"date"`
+	Date          time.Time `form:"date"` // whole day, used when From/To are not set
+	From          time.Time `form:"from"`
+	To            time.Time `form:"to"`
+	EventFilters
+	Viewer *uuid.UUID `form:"-"` // invite-only events are listed for users with access
 }
 
 // func (st *GetMapQueryParams) GetFromTime() time.Time {
@@ -29,3 +41,24 @@ type GetMapQueryParams struct {
 // 	}
 // 	return parsedTime
 // }
+
+type GetTrendingQueryParams struct {
+	FirstQuadLon  float64   `form:"firstlon"`
+	FirstQuadLat  float64   `form:"firstlat"`
+	SecondQuadLon float64   `form:"secondlon"`
+	SecondQuadLat float64   `form:"secondlat"`
+	From          time.Time `form:"from"`
+	To            time.Time `form:"to"`
+	Limit         int       `form:"limit"`
+	EventFilters
+	Viewer *uuid.UUID `form:"-"` // invite-only events are listed for users with access
+}
+
+type SearchQueryParams struct {
+	Query string    `form:"q"`
+	From  time.Time `form:"from"`
+	To    time.Time `form:"to"`
+	Limit int       `form:"limit"`
+	EventFilters
+	Viewer *uuid.UUID `form:"-"` // invite-only events are listed for users with access
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Series is a recurring event defined by an RFC 5545 RRULE. Occurrences are
+// materialized into the event table up to MaterializedUntil.
+type Series struct {
+	SeriesID          int64      `json:"series_id" db:"series_id"`                   // BIGSERIAL Primary Key
+	Name              string     `json:"name" db:"name"`                             // VARCHAR(255) NOT NULL
+	Description       string     `json:"description" db:"description"`               // TEXT
+	CreatedBy         *uuid.UUID `json:"created_by,omitempty" db:"created_by"`       // UUID (Nullable, FK to users)
+	Location_lat      float64    `json:"location_lat" db:"location_lat"`             // For PostGIS geometry data
+	Location_lon      float64    `json:"location_lon" db:"location_lon"`             // For PostGIS geometry data
+	Organizer         string     `json:"organizer" db:"organizer"`                   // VARCHAR(255) NOT NULL
+	Category          string     `json:"category" db:"category"`                     // VARCHAR(32) NOT NULL DEFAULT 'other'
+	Tags              Tags       `json:"tags" db:"tags"`                             // TEXT[] NOT NULL DEFAULT '{}'
+	StartDate         time.Time  `json:"start_date" db:"start_date"`                 // DTSTART of the first occurrence
+	EndDate           *time.Time `json:"end_date,omitempty" db:"end_date"`           // end of the first occurrence
+	Timezone          string     `json:"timezone" db:"timezone"`                     // TZID the rule is expanded in
+	RRule             string     `json:"rrule" db:"rrule"`                           // e.g. FREQ=WEEKLY;BYDAY=TU
+	ExDates           Dates      `json:"exdates" db:"exdates"`                       // skipped occurrences
+	MaterializedUntil time.Time  `json:"materialized_until" db:"materialized_until"` // occurrences exist up to here
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+	VenueID           *int64     `json:"venue_id,omitempty" db:"venue_id"`         // BIGINT (Nullable, FK to venues)
+	OrganizerID       *int64     `json:"organizer_id,omitempty" db:"organizer_id"` // BIGINT (Nullable, FK to organizers)
+	Visibility        string     `json:"visibility" db:"visibility"`               // copied to every occurrence
+	Hidden            bool       `json:"hidden,omitempty" db:"hidden"`             // held by screening, copied to every occurrence
+}
+
+// Template returns the event every occurrence is copied from
+func (s *Series) Template() CreateEvent {
+	return CreateEvent{
+		Name:         s.Name,
+		Description:  s.Description,
+		CreatedBy:    s.CreatedBy,
+		Location_lat: s.Location_lat,
+		Location_lon: s.Location_lon,
+		StartDate:    s.StartDate,
+		EndDate:      s.EndDate,
+		Organizer:    s.Organizer,
+		Category:     s.Category,
+		Tags:         s.Tags,
+		VenueID:      s.VenueID,
+		OrganizerID:  s.OrganizerID,
+		Visibility:   s.Visibility,
+		Hidden:       s.Hidden,
+	}
+}
+
+type CreateSeries struct {
+	CreateEvent             // first occurrence
+	Timezone    string      `json:"timezone"` // IANA name, defaults to UTC
+	RRule       string      `json:"rrule"`
+	ExDates     []time.Time `json:"exdates"`
+}
+
+// UpdateSeries replaces the series for every occurrence starting at From ("all future occurrences")
+type UpdateSeries struct {
+	CreateSeries
+	UserID uuid.UUID `json:"user_id"`
+	From   time.Time `json:"from"`
+}
+
+// UpdateOccurrence replaces a single occurrence ("this occurrence")
+type UpdateOccurrence struct {
+	CreateEvent
+	UserID uuid.UUID `json:"user_id"`
+}
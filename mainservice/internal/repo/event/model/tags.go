@@ -0,0 +1,57 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Tags scans a TEXT[] column selected as JSON (to_jsonb(tags))
+type Tags []string
+
+func (t *Tags) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*t = Tags{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for tags: %T", src)
+	}
+
+	var tags []string
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return err
+	}
+	*t = tags
+	return nil
+}
+
+// Dates scans a TIMESTAMPTZ[] column selected as JSON (to_jsonb(dates))
+type Dates []time.Time
+
+func (d *Dates) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*d = Dates{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for dates: %T", src)
+	}
+
+	var dates []time.Time
+	if err := json.Unmarshal(data, &dates); err != nil {
+		return err
+	}
+	*d = dates
+	return nil
+}
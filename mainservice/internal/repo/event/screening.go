@@ -0,0 +1,57 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+)
+
+// CountSimilar counts events with the same name and start close to the given one, created
+// by createdBy or by anyone for an anonymous event
+func (rp *repository) CountSimilar(ctx context.Context, createdBy *uuid.UUID, createEvent model.CreateEvent) (int, error) {
+	selectquery := `
+		SELECT COUNT(*)
+		FROM event e
+		WHERE ($1::uuid IS NULL OR e.created_by = $1)
+			AND e.start_date = $2
+			AND lower(e.name) = lower($3)
+			AND ST_DWithin(
+				e.location::geography,
+				ST_SetSRID(ST_Point($4, $5), 4326)::geography,
+				$6
+			);
+	`
+
+	var count int
+	err := rp.db.GetContext(ctx, &count, selectquery,
+		createdBy,
+		createEvent.StartDate,
+		createEvent.Name,
+		createEvent.Location_lon,
+		createEvent.Location_lat,
+		duplicateDistanceMeters,
+	)
+	if err != nil {
+		rp.lg.Error("Failed to execute CountSimilar query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return count, nil
+}
+
+// CountCreatedSince counts the events the user created after since
+func (rp *repository) CountCreatedSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	var count int
+	err := rp.db.GetContext(ctx, &count, `
+		SELECT COUNT(*) FROM event WHERE created_by = $1 AND created_at >= $2;
+	`, userID, since)
+	if err != nil {
+		rp.lg.Error("Failed to execute CountCreatedSince query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return count, nil
+}
@@ -0,0 +1,532 @@
+package event
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/change"
+	changeModel "github.com/quietguido/mapnu/mainservice/internal/repo/change/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/outbox"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	seriesTable = "event_series"
+)
+
+const seriesColumns = `
+			series_id,
+			name,
+			description,
+			created_by,
+			ST_X(location) AS location_lon,
+			ST_Y(location) AS location_lat,
+			organizer,
+			category,
+			to_jsonb(tags) AS tags,
+			start_date,
+			end_date,
+			timezone,
+			rrule,
+			to_jsonb(exdates) AS exdates,
+			materialized_until,
+			created_at,
+			updated_at,
+			venue_id,
+			organizer_id,
+			visibility,
+			hidden`
+
+// CreateSeries stores the series together with its first materialized occurrences.
+func (rp *repository) CreateSeries(ctx context.Context, createSeries model.CreateSeries, occurrences []model.CreateEvent, materializedUntil time.Time) (int64, error) {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	insertQuery := rp.builder.
+		Insert(seriesTable).Columns(
+		"name",
+		"description",
+		"created_by",
+		"location",
+		"organizer",
+		"category",
+		"tags",
+		"start_date",
+		"end_date",
+		"timezone",
+		"rrule",
+		"exdates",
+		"materialized_until",
+		"venue_id",
+		"organizer_id",
+		"visibility",
+		"hidden",
+	).Values(
+		createSeries.Name,
+		createSeries.Description,
+		createSeries.CreatedBy,
+		sq.Expr("ST_SetSRID(ST_Point(?, ?), 4326)", createSeries.Location_lon, createSeries.Location_lat),
+		createSeries.Organizer,
+		createSeries.Category,
+		createSeries.Tags,
+		createSeries.StartDate,
+		createSeries.EndDate,
+		createSeries.Timezone,
+		createSeries.RRule,
+		createSeries.ExDates,
+		materializedUntil,
+		createSeries.VenueID,
+		createSeries.OrganizerID,
+		createSeries.Visibility,
+		createSeries.Hidden,
+	).Suffix("RETURNING series_id")
+
+	query, args, err := insertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var seriesID int64
+	if err := tx.QueryRowxContext(ctx, query, args...).Scan(&seriesID); err != nil {
+		rp.lg.Warn(query)
+		return 0, errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	eventIDs, err := rp.insertOccurrences(ctx, tx, seriesID, occurrences)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := change.EmitSeries(ctx, tx, changeModel.SeriesCreated, seriesID, createSeries.StartDate, materializedUntil); err != nil {
+		return 0, err
+	}
+	// only a new series is announced, occurrences materialized later are not new events
+	if err := outbox.EnqueueEvents(ctx, tx, outboxModel.TopicEventCreated, eventIDs...); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit transaction")
+	}
+	return seriesID, nil
+}
+
+func (rp *repository) GetSeriesById(ctx context.Context, seriesID int64) (*model.Series, error) {
+	selectQuery := rp.builder.
+		Select(seriesColumns).
+		From(seriesTable).
+		Where(sq.Eq{"series_id": seriesID})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var series model.Series
+	if err := rp.db.GetContext(ctx, &series, query, args...); err != nil {
+		rp.lg.Error("Failed to execute GetSeriesById query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch series")
+	}
+	return &series, nil
+}
+
+// GetSeriesToMaterialize returns series whose occurrences are not generated up to horizon yet.
+func (rp *repository) GetSeriesToMaterialize(ctx context.Context, horizon time.Time) ([]model.Series, error) {
+	selectQuery := rp.builder.
+		Select(seriesColumns).
+		From(seriesTable).
+		Where(sq.Lt{"materialized_until": horizon}).
+		OrderBy("materialized_until ASC")
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var series []model.Series
+	if err := rp.db.SelectContext(ctx, &series, query, args...); err != nil {
+		rp.lg.Error("Failed to execute GetSeriesToMaterialize query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return series, nil
+}
+
+// AddOccurrences appends newly generated occurrences and moves the materialization horizon
+// from materializedFrom to materializedUntil. Nothing is added when the series was edited or
+// extended since materializedFrom was read, the next run starts from the new horizon.
+func (rp *repository) AddOccurrences(ctx context.Context, seriesID int64, occurrences []model.CreateEvent, materializedFrom, materializedUntil time.Time) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	// moving the horizon first locks the series row against concurrent edits
+	updateQuery := rp.builder.
+		Update(seriesTable).
+		Set("materialized_until", materializedUntil).
+		Where(sq.Eq{"series_id": seriesID, "materialized_until": materializedFrom}).
+		Suffix("RETURNING timezone")
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var timezone string
+	err = tx.GetContext(ctx, &timezone, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		rp.lg.Error("Failed to update materialization horizon", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return errors.Wrap(err, "Incorrect timezone")
+	}
+	// an edit capped at maxOccurrencesPerPass may have left occurrences of the old rule past
+	// its horizon, they are reconciled like the ones of an edit
+	inserted, err := rp.reconcileOccurrences(ctx, tx, seriesID, loc, sq.Gt{"occurrence_date": materializedFrom}, materializedUntil, occurrences)
+	if err != nil {
+		return err
+	}
+
+	if len(inserted) > 0 {
+		err := change.EmitSeries(ctx, tx, changeModel.SeriesExtended, seriesID, occurrences[0].StartDate, materializedUntil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+// UpdateOccurrence replaces a single occurrence and detaches it from later series edits.
+func (rp *repository) UpdateOccurrence(ctx context.Context, seriesID, eventID int64, update model.CreateEvent) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	// moving the occurrence to another day moves the row to that day's partition
+	if err := ensurePartition(ctx, tx, update.StartDate); err != nil {
+		return err
+	}
+
+	updateQuery := rp.setEventFields(rp.builder.Update(eventTable), update).
+		Set("is_exception", true).
+		Where(sq.Eq{"event_id": eventID, "series_id": seriesID})
+
+	if err := rp.execUpdate(ctx, tx, updateQuery); err != nil {
+		return err
+	}
+
+	if err := change.EmitEvents(ctx, tx, changeModel.EventUpdated, eventID); err != nil {
+		return err
+	}
+	if err := outbox.EnqueueEvents(ctx, tx, outboxModel.TopicEventUpdated, eventID); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+// UpdateSeriesFrom replaces the series and reconciles its occurrences from from up to
+// materializedUntil with the given ones, see reconcileOccurrences.
+func (rp *repository) UpdateSeriesFrom(ctx context.Context, seriesID int64, update model.CreateSeries, from time.Time, occurrences []model.CreateEvent, materializedUntil time.Time) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	// occurrences at the old location go away when the update moves the series
+	var moved bool
+	err = tx.GetContext(ctx, &moved, `
+		SELECT NOT ST_Equals(location, ST_SetSRID(ST_Point($2, $3), 4326)) FROM event_series WHERE series_id = $1;
+	`, seriesID, update.Location_lon, update.Location_lat)
+	if err != nil {
+		rp.lg.Error("Failed to fetch series location", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+	if moved {
+		if err := change.EmitSeries(ctx, tx, changeModel.SeriesUpdated, seriesID, from, materializedUntil); err != nil {
+			return err
+		}
+	}
+
+	updateQuery := rp.builder.
+		Update(seriesTable).
+		Set("name", update.Name).
+		Set("description", update.Description).
+		Set("location", sq.Expr("ST_SetSRID(ST_Point(?, ?), 4326)", update.Location_lon, update.Location_lat)).
+		Set("organizer", update.Organizer).
+		Set("category", update.Category).
+		Set("tags", update.Tags).
+		Set("start_date", update.StartDate).
+		Set("end_date", update.EndDate).
+		Set("timezone", update.Timezone).
+		Set("rrule", update.RRule).
+		Set("exdates", update.ExDates).
+		Set("materialized_until", materializedUntil).
+		Set("venue_id", update.VenueID).
+		Set("organizer_id", update.OrganizerID).
+		Set("visibility", update.Visibility).
+		Set("updated_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"series_id": seriesID})
+
+	if err := rp.execUpdate(ctx, tx, updateQuery); err != nil {
+		return err
+	}
+
+	loc, err := time.LoadLocation(update.Timezone)
+	if err != nil {
+		return errors.Wrap(err, "Incorrect timezone")
+	}
+	if _, err := rp.reconcileOccurrences(ctx, tx, seriesID, loc, sq.GtOrEq{"occurrence_date": from}, materializedUntil, occurrences); err != nil {
+		return err
+	}
+
+	if err := change.EmitSeries(ctx, tx, changeModel.SeriesUpdated, seriesID, from, materializedUntil); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+// CancelOccurrence deletes a single occurrence and records it as an EXDATE so it is not regenerated.
+func (rp *repository) CancelOccurrence(ctx context.Context, seriesID, eventID int64) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	// built from the row, so before it is gone
+	if err := change.EmitEvents(ctx, tx, changeModel.EventCancelled, eventID); err != nil {
+		return err
+	}
+	if err := outbox.EnqueueEvents(ctx, tx, outboxModel.TopicEventCancelled, eventID); err != nil {
+		return err
+	}
+
+	var occurrenceDate time.Time
+	err = tx.GetContext(ctx, &occurrenceDate, `
+		DELETE FROM event WHERE event_id = $1 AND series_id = $2 RETURNING occurrence_date;
+	`, eventID, seriesID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("No occurrence found with the given ID")
+	}
+	if err != nil {
+		rp.lg.Error("Failed to delete occurrence", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	updateQuery := rp.builder.
+		Update(seriesTable).
+		Set("exdates", sq.Expr("array_append(exdates, ?::timestamptz)", occurrenceDate)).
+		Set("updated_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"series_id": seriesID})
+
+	if err := rp.execUpdate(ctx, tx, updateQuery); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+// occurrenceKey identifies an occurrence by its calendar date in the series timezone and its
+// position on that day, so an occurrence keeps its row when the time of day is edited
+type occurrenceKey struct {
+	date    string
+	ordinal int
+}
+
+// occurrenceKeys keys the ascending occurrence dates
+func occurrenceKeys(dates []time.Time, loc *time.Location) []occurrenceKey {
+	keys := make([]occurrenceKey, 0, len(dates))
+	perDay := make(map[string]int)
+	for _, date := range dates {
+		day := date.In(loc).Format(time.DateOnly)
+		keys = append(keys, occurrenceKey{date: day, ordinal: perDay[day]})
+		perDay[day]++
+	}
+	return keys
+}
+
+// reconcileOccurrences makes the occurrences of the series matching after and up to until
+// the generated ones: occurrences still generated are updated in place, so bookings, votes
+// and comments stay attached, missing ones are inserted and the ones no longer generated
+// are removed. Occurrences edited on their own are left alone. It returns the inserted ids.
+func (rp *repository) reconcileOccurrences(ctx context.Context, tx *sqlx.Tx, seriesID int64, loc *time.Location, after sq.Sqlizer, until time.Time, occurrences []model.CreateEvent) ([]int64, error) {
+	type existingOccurrence struct {
+		EventID        int64     `db:"event_id"`
+		OccurrenceDate time.Time `db:"occurrence_date"`
+		IsException    bool      `db:"is_exception"`
+	}
+
+	selectQuery := rp.builder.
+		Select("event_id", "occurrence_date", "is_exception").
+		From(eventTable).
+		Where(sq.Eq{"series_id": seriesID}).
+		Where(after).
+		Where(sq.LtOrEq{"occurrence_date": until}).
+		OrderBy("occurrence_date ASC", "event_id ASC")
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var existing []existingOccurrence
+	if err := tx.SelectContext(ctx, &existing, query, args...); err != nil {
+		rp.lg.Error("Failed to fetch series occurrences", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	dates := make([]time.Time, 0, len(existing))
+	for _, occurrence := range existing {
+		dates = append(dates, occurrence.OccurrenceDate)
+	}
+	byKey := make(map[occurrenceKey]existingOccurrence, len(existing))
+	for i, key := range occurrenceKeys(dates, loc) {
+		byKey[key] = existing[i]
+	}
+
+	dates = dates[:0]
+	for _, occurrence := range occurrences {
+		dates = append(dates, *occurrence.OccurrenceDate)
+	}
+
+	type match struct {
+		eventID    int64
+		occurrence model.CreateEvent
+	}
+
+	var inserts []model.CreateEvent
+	var matches []match
+	var updated []int64
+	for i, key := range occurrenceKeys(dates, loc) {
+		current, ok := byKey[key]
+		if !ok {
+			inserts = append(inserts, occurrences[i])
+			continue
+		}
+		delete(byKey, key)
+		if !current.IsException {
+			matches = append(matches, match{eventID: current.EventID, occurrence: occurrences[i]})
+			updated = append(updated, current.EventID)
+		}
+	}
+
+	// whatever is left is no longer generated by the rule, removed first so its
+	// occurrence dates are free for the updated occurrences
+	var removed []int64
+	for _, occurrence := range byKey {
+		if !occurrence.IsException {
+			removed = append(removed, occurrence.EventID)
+		}
+	}
+	if len(removed) > 0 {
+		if err := outbox.EnqueueEvents(ctx, tx, outboxModel.TopicEventCancelled, removed...); err != nil {
+			return nil, err
+		}
+
+		deleteQuery := rp.builder.
+			Delete(eventTable).
+			Where(sq.Eq{"event_id": removed})
+
+		query, args, err := deleteQuery.ToSql()
+		assert.IsNil(err, "Failed to build SQL query")
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			rp.lg.Error("Failed to delete series occurrences", zap.Error(err))
+			return nil, errors.Wrap(err, "Failed to execute SQL query")
+		}
+	}
+
+	if len(matches) > 0 {
+		// occurrences may swap dates with each other, clearing them first keeps the
+		// unique index satisfied while they are moved one by one
+		_, err := tx.ExecContext(ctx, `
+			UPDATE event SET occurrence_date = NULL WHERE event_id = ANY($1::bigint[]);
+		`, updated)
+		if err != nil {
+			rp.lg.Error("Failed to clear occurrence dates", zap.Error(err))
+			return nil, errors.Wrap(err, "Failed to execute SQL query")
+		}
+	}
+	for _, match := range matches {
+		if err := ensurePartition(ctx, tx, match.occurrence.StartDate); err != nil {
+			return nil, err
+		}
+		updateQuery := rp.setEventFields(rp.builder.Update(eventTable), match.occurrence).
+			Set("occurrence_date", match.occurrence.OccurrenceDate).
+			Where(sq.Eq{"event_id": match.eventID})
+		if err := rp.execUpdate(ctx, tx, updateQuery); err != nil {
+			return nil, err
+		}
+	}
+	if err := outbox.EnqueueEvents(ctx, tx, outboxModel.TopicEventUpdated, updated...); err != nil {
+		return nil, err
+	}
+
+	return rp.insertOccurrences(ctx, tx, seriesID, inserts)
+}
+
+// insertOccurrences inserts the occurrences that do not exist yet and returns their ids
+func (rp *repository) insertOccurrences(ctx context.Context, tx *sqlx.Tx, seriesID int64, occurrences []model.CreateEvent) ([]int64, error) {
+	eventIDs := make([]int64, 0, len(occurrences))
+	for _, occurrence := range occurrences {
+		occurrence.SeriesID = &seriesID
+		eventID, err := rp.insertEvent(ctx, tx, occurrence)
+		if errors.Is(err, errOccurrenceExists) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		eventIDs = append(eventIDs, eventID)
+	}
+	return eventIDs, nil
+}
+
+func (rp *repository) setEventFields(query sq.UpdateBuilder, update model.CreateEvent) sq.UpdateBuilder {
+	return query.
+		Set("name", update.Name).
+		Set("description", update.Description).
+		Set("location", sq.Expr("ST_SetSRID(ST_Point(?, ?), 4326)", update.Location_lon, update.Location_lat)).
+		Set("start_date", update.StartDate).
+		Set("end_date", update.EndDate).
+		Set("organizer", update.Organizer).
+		Set("category", update.Category).
+		Set("tags", update.Tags).
+		Set("venue_id", update.VenueID).
+		Set("organizer_id", update.OrganizerID).
+		Set("visibility", update.Visibility)
+}
+
+func (rp *repository) execUpdate(ctx context.Context, tx *sqlx.Tx, updateQuery sq.UpdateBuilder) error {
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get affected rows")
+	}
+	if num == 0 {
+		return errors.New("No rows were updated")
+	}
+	return nil
+}
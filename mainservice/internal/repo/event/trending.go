@@ -0,0 +1,146 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	trendingTable = "event_trending"
+
+	// keep each multi-row upsert well under the bind parameter limit
+	trendingBatchSize = 500
+)
+
+func (rp *repository) GetTrending(ctx context.Context, params model.GetTrendingQueryParams) ([]model.TrendingEvent, error) {
+	selectQuery := rp.builder.
+		Select(eventColumns, "COALESCE(t.score, 0) AS score").
+		From("event e").
+		LeftJoin("event_trending t ON t.event_id = e.event_id").
+		Where(
+			withinEnvelope,       // Bounding box for spatial query
+			params.FirstQuadLon,  // Min Longitude
+			params.FirstQuadLat,  // Min Latitude
+			params.SecondQuadLon, // Max Longitude
+			params.SecondQuadLat, // Max Latitude
+		)
+	selectQuery = applyTimeWindow(selectQuery, params.From, params.To)
+	selectQuery = applyEventFilters(selectQuery, params.EventFilters).
+		Where(notHidden).
+		Where(listedFor(params.Viewer)).
+		OrderBy("score DESC", "e.start_date ASC").
+		Limit(uint64(params.Limit))
+
+	selectquery, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	rows, err := rp.db.QueryxContext(ctx, selectquery, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute GetTrending query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	defer rows.Close()
+
+	var events []model.TrendingEvent
+	for rows.Next() {
+		var event model.TrendingEvent
+		err := rows.StructScan(&event)
+		if err != nil {
+			rp.lg.Error("Failed to scan row", zap.Error(err))
+			return nil, errors.Wrap(err, "Failed to scan row")
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		rp.lg.Error("Row iteration error", zap.Error(err))
+		return nil, errors.Wrap(err, "Row iteration error")
+	}
+
+	return events, nil
+}
+
+// GetTrendingCandidates returns ranking signals for every event still running after endsAfter.
+// Bookings made after bookedAfter count towards booking velocity.
+func (rp *repository) GetTrendingCandidates(ctx context.Context, endsAfter, bookedAfter time.Time) ([]model.TrendingCandidate, error) {
+	selectquery := `
+		SELECT
+			e.event_id,
+			e.upvote,
+			e.downvote,
+			e.interested_count,
+			e.created_at,
+			e.rating_count,
+			e.rating_sum,
+			COALESCE(u.rating_count, 0) AS creator_rating_count,
+			COALESCE(u.rating_sum, 0) AS creator_rating_sum,
+			COUNT(b.booking_id) FILTER (WHERE b.booked_at >= $2) AS recent_bookings
+		FROM event e
+		LEFT JOIN users u ON u.id = e.created_by
+		LEFT JOIN bookings b ON b.event_id = e.event_id AND b.booking_status <> 'rejected'
+		WHERE e.start_date >= $3 AND COALESCE(e.end_date, e.start_date) >= $1
+		GROUP BY e.event_id, e.upvote, e.downvote, e.interested_count, e.created_at, e.rating_count, e.rating_sum, u.rating_count, u.rating_sum;
+	`
+
+	var candidates []model.TrendingCandidate
+	err := rp.db.SelectContext(ctx, &candidates, selectquery, endsAfter, bookedAfter, endsAfter.Add(-model.MaxDuration))
+	if err != nil {
+		rp.lg.Error("Failed to execute GetTrendingCandidates query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	return candidates, nil
+}
+
+// SaveTrendingScores replaces the cached scores with the given ones.
+func (rp *repository) SaveTrendingScores(ctx context.Context, scores []model.TrendingScore) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	refreshedAt := time.Now()
+
+	for start := 0; start < len(scores); start += trendingBatchSize {
+		end := min(start+trendingBatchSize, len(scores))
+
+		upsertQuery := rp.builder.
+			Insert(trendingTable).
+			Columns("event_id", "score", "computed_at")
+		for _, score := range scores[start:end] {
+			upsertQuery = upsertQuery.Values(score.EventID, score.Score, refreshedAt)
+		}
+		upsertQuery = upsertQuery.Suffix("ON CONFLICT (event_id) DO UPDATE SET score = EXCLUDED.score, computed_at = EXCLUDED.computed_at")
+
+		sql, args, err := upsertQuery.ToSql()
+		assert.IsNil(err, "Failed to build SQL query")
+
+		if _, err := tx.ExecContext(ctx, sql, args...); err != nil {
+			rp.lg.Error("Failed to save trending scores", zap.Error(err))
+			return errors.Wrap(err, "Failed to execute SQL query")
+		}
+	}
+
+	// drop scores of events that are no longer candidates
+	deleteQuery := rp.builder.
+		Delete(trendingTable).
+		Where(sq.Lt{"computed_at": refreshedAt})
+
+	sql, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := tx.ExecContext(ctx, sql, args...); err != nil {
+		rp.lg.Error("Failed to prune trending scores", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
@@ -1,14 +1,171 @@
 package event
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
 )
 
+// eventColumns is the select list for model.Event, the event table must be aliased as "e"
+const eventColumns = `
+			e.event_id,
+			e.name,
+			e.description,
+			e.created_by,
+			ST_X(e.location) AS location_lon, -- Ensure longitude is first (PostGIS standard)
+			ST_Y(e.location) AS location_lat, -- Latitude second
+			e.start_date,
+			e.end_date,
+			e.organizer,
+			e.upvote,
+			e.downvote,
+			e.created_at,
+			e.category,
+			to_jsonb(e.tags) AS tags,
+			e.series_id,
+			e.occurrence_date,
+			e.venue_id,
+			e.organizer_id,
+			e.rating_count,
+			ROUND(e.rating_sum::numeric / NULLIF(e.rating_count, 0), 2)::float8 AS rating,
+			e.interested_count,
+			e.visibility,
+			` + hiddenCondition + ` AS hidden`
+
+// hiddenCondition is true for events hidden by moderation and for events of users hidden
+// by moderation, the event table must be aliased as "e"
+const hiddenCondition = `(e.hidden OR EXISTS (SELECT 1 FROM users hu WHERE hu.id = e.created_by AND hu.hidden))`
+
+const partitionBoundLayout = "2006-01-02 15:04:05-07"
+
+// withinEnvelope matches events inside the lon/lat bounding box
+const withinEnvelope = "ST_Within(e.location, ST_SetSRID(ST_MakeEnvelope(?, ?, ?, ?, 4326), 4326))"
+
 // func createPoint(lon, lat float64) string {
 // 	return fmt.Sprintf("ST_SetSRID(ST_Point(%f, %f), 4326)", lon, lat)
 // }
 
+// getPartition returns the name of the daily partition for t, days are UTC days.
+//
+// Partitions created before days were UTC days, by hand or by the initial migration, have
+// bounds in the session timezone. They are kept as they are: rows are always inserted
+// through the parent table, so Postgres routes them to whichever partition covers them,
+// and ensurePartition does not create a UTC day that would overlap one of them.
 func getPartition(t time.Time) string {
+	t = t.UTC()
 	return fmt.Sprintf("%s_%d_%02d_%02d", eventTable, t.Year(), t.Month(), t.Day())
 }
+
+// ensurePartition creates the daily partition for t if it does not exist yet. Rows of that
+// day already in the default partition are moved into the new partition. When the day
+// can not get a partition of its own, e.g. it overlaps a partition with session timezone
+// bounds, its rows stay where Postgres routes them.
+func ensurePartition(ctx context.Context, tx *sqlx.Tx, t time.Time) error {
+	partition := getPartition(t)
+
+	var exists bool
+	err := tx.GetContext(ctx, &exists, `SELECT to_regclass($1) IS NOT NULL;`, partition)
+	if err != nil {
+		return errors.Wrap(err, "Failed to look up partition")
+	}
+	if exists {
+		return nil
+	}
+
+	day := t.UTC()
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC).Format(partitionBoundLayout)
+	to := time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, time.UTC).Format(partitionBoundLayout)
+
+	// a failed attempt must not abort the transaction of the insert
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT ensure_partition;`); err != nil {
+		return errors.Wrap(err, "Failed to create savepoint")
+	}
+
+	// the day is carved out of the default partition: a new table gets the day's rows
+	// and is attached, attaching checks that no row of the day is left in the default
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS);`, partition, eventTable),
+		fmt.Sprintf(`
+			WITH moved AS (
+				DELETE FROM %s_default WHERE start_date >= '%s' AND start_date < '%s' RETURNING *
+			)
+			INSERT INTO %s SELECT * FROM moved;
+		`, eventTable, from, to, partition),
+		fmt.Sprintf(`ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s');`, eventTable, partition, from, to),
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			if _, rollbackErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT ensure_partition;`); rollbackErr != nil {
+				return errors.Wrap(rollbackErr, "Failed to roll back partition "+partition)
+			}
+			// the insert goes through the parent table and still finds a partition
+			return nil
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `RELEASE SAVEPOINT ensure_partition;`)
+	return errors.Wrap(err, "Failed to release savepoint")
+}
+
+// applyTimeWindow matches events overlapping [from, to). The lower start_date bound
+// lets the planner prune partitions older than the longest possible event.
+func applyTimeWindow(query sq.SelectBuilder, from, to time.Time) sq.SelectBuilder {
+	return query.
+		Where(sq.GtOrEq{"e.start_date": from.Add(-model.MaxDuration)}).
+		Where(sq.Lt{"e.start_date": to}).
+		Where(sq.GtOrEq{"COALESCE(e.end_date, e.start_date)": from})
+}
+
+// notHidden leaves out events hidden by moderation and events of hidden users
+var notHidden = sq.Expr("NOT " + hiddenCondition)
+
+// isPublic leaves out unlisted and invite-only events
+var isPublic = sq.Eq{"e.visibility": model.VisibilityPublic}
+
+// accessCondition matches events the user created, manages as an organizer member or was invited to
+const accessCondition = `(
+			e.created_by = ?
+			OR EXISTS (SELECT 1 FROM event_invites i WHERE i.event_id = e.event_id AND i.user_id = ?)
+			OR EXISTS (SELECT 1 FROM organizer_members m WHERE m.organizer_id = e.organizer_id AND m.user_id = ?)
+		)`
+
+func accessibleTo(userID uuid.UUID) sq.Sqlizer {
+	return sq.Expr(accessCondition, userID, userID, userID)
+}
+
+// listedFor keeps unlisted events out of listings, invite-only events are only listed for
+// users with access to them
+func listedFor(viewer *uuid.UUID) sq.Sqlizer {
+	if viewer == nil {
+		return isPublic
+	}
+	return sq.Or{
+		isPublic,
+		sq.And{sq.Eq{"e.visibility": model.VisibilityInviteOnly}, accessibleTo(*viewer)},
+	}
+}
+
+func applyEventFilters(query sq.SelectBuilder, filters model.EventFilters) sq.SelectBuilder {
+	if filters.Category != "" {
+		query = query.Where(sq.Eq{"e.category": filters.Category})
+	}
+	if len(filters.Tags) > 0 {
+		query = query.Where("e.tags @> ?", filters.Tags)
+	}
+	return query
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// containsPattern builds an ILIKE pattern matching s anywhere in the column
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
@@ -0,0 +1,84 @@
+package event
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/change"
+	changeModel "github.com/quietguido/mapnu/mainservice/internal/repo/change/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/outbox"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+// HasAccess reports whether the user created the event, manages it as an organizer member
+// or is on its invite list
+func (rp *repository) HasAccess(ctx context.Context, eventId int64, userID uuid.UUID) (bool, error) {
+	selectQuery := rp.builder.
+		Select("1").
+		From("event e").
+		Where(sq.Eq{"e.event_id": eventId}).
+		Where(accessibleTo(userID)).
+		Prefix("SELECT EXISTS (").
+		Suffix(")")
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var allowed bool
+	if err := rp.db.GetContext(ctx, &allowed, query, args...); err != nil {
+		rp.lg.Error("Failed to execute HasAccess query", zap.Error(err))
+		return false, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return allowed, nil
+}
+
+// SetVisibility changes who can see the event, the live map drops it when it stops being public
+// and saved searches get to match it when it becomes public
+func (rp *repository) SetVisibility(ctx context.Context, eventId int64, visibility string) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	var previous string
+	err = tx.GetContext(ctx, &previous, `SELECT visibility FROM event WHERE event_id = $1 FOR UPDATE;`, eventId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("No event found with the given ID")
+	}
+	if err != nil {
+		rp.lg.Error("Failed to execute SetVisibility query", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	updateQuery := rp.builder.
+		Update(eventTable).
+		Set("visibility", visibility).
+		Where(sq.Eq{"event_id": eventId})
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SetVisibility query", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	if err := change.EmitEvents(ctx, tx, changeModel.EventUpdated, eventId); err != nil {
+		return err
+	}
+	if visibility == model.VisibilityPublic && previous != model.VisibilityPublic {
+		if err := outbox.EnqueueEvents(ctx, tx, outboxModel.TopicEventPublished, eventId); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
@@ -0,0 +1,172 @@
+package follow
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/follow/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	organizerFollowTable = "organizer_follows"
+	venueFollowTable     = "venue_follows"
+)
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// FollowOrganizer follows the organizer and bumps its follower counter in the same
+// transaction. Following twice is a no-op.
+func (rp *repository) FollowOrganizer(ctx context.Context, userID uuid.UUID, organizerID int64) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	insertQuery := rp.builder.
+		Insert(organizerFollowTable).
+		Columns("user_id", "organizer_id").
+		Values(userID, organizerID).
+		Suffix("ON CONFLICT (user_id, organizer_id) DO NOTHING")
+
+	followed, err := rp.execCount(ctx, tx, insertQuery)
+	if err != nil {
+		return err
+	}
+	if followed {
+		if err := rp.adjustFollowers(ctx, tx, organizerID, 1); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+// UnfollowOrganizer is the reverse of FollowOrganizer, unfollowing twice is a no-op.
+func (rp *repository) UnfollowOrganizer(ctx context.Context, userID uuid.UUID, organizerID int64) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	deleteQuery := rp.builder.
+		Delete(organizerFollowTable).
+		Where(sq.Eq{"user_id": userID, "organizer_id": organizerID})
+
+	unfollowed, err := rp.execCount(ctx, tx, deleteQuery)
+	if err != nil {
+		return err
+	}
+	if unfollowed {
+		if err := rp.adjustFollowers(ctx, tx, organizerID, -1); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+func (rp *repository) FollowVenue(ctx context.Context, userID uuid.UUID, venueID int64) error {
+	insertQuery := rp.builder.
+		Insert(venueFollowTable).
+		Columns("user_id", "venue_id").
+		Values(userID, venueID).
+		Suffix("ON CONFLICT (user_id, venue_id) DO NOTHING")
+
+	query, args, err := insertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := rp.db.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to follow venue")
+	}
+	return nil
+}
+
+func (rp *repository) UnfollowVenue(ctx context.Context, userID uuid.UUID, venueID int64) error {
+	deleteQuery := rp.builder.
+		Delete(venueFollowTable).
+		Where(sq.Eq{"user_id": userID, "venue_id": venueID})
+
+	query, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := rp.db.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to unfollow venue")
+	}
+	return nil
+}
+
+func (rp *repository) GetFollowing(ctx context.Context, userID uuid.UUID) (*model.Following, error) {
+	following := &model.Following{
+		Organizers: []int64{},
+		Venues:     []int64{},
+	}
+
+	err := rp.db.SelectContext(ctx, &following.Organizers, `
+		SELECT organizer_id FROM organizer_follows WHERE user_id = $1 ORDER BY followed_at DESC;
+	`, userID)
+	if err != nil {
+		rp.lg.Error("Failed to fetch followed organizers", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	err = rp.db.SelectContext(ctx, &following.Venues, `
+		SELECT venue_id FROM venue_follows WHERE user_id = $1 ORDER BY followed_at DESC;
+	`, userID)
+	if err != nil {
+		rp.lg.Error("Failed to fetch followed venues", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	return following, nil
+}
+
+// execCount runs the statement and reports whether it touched a row
+func (rp *repository) execCount(ctx context.Context, tx *sqlx.Tx, statement sq.Sqlizer) (bool, error) {
+	query, args, err := statement.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return false, errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to get affected rows")
+	}
+	return num > 0, nil
+}
+
+func (rp *repository) adjustFollowers(ctx context.Context, tx *sqlx.Tx, organizerID int64, delta int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE organizers SET follower_count = GREATEST(follower_count + $1, 0) WHERE organizer_id = $2;
+	`, delta, organizerID)
+	if err != nil {
+		rp.lg.Error("Failed to update follower counter", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return nil
+}
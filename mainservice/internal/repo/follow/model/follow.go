@@ -0,0 +1,20 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// followable entities
+const (
+	TargetOrganizer = "organizer"
+	TargetVenue     = "venue"
+)
+
+type Following struct {
+	Organizers []int64 `json:"organizers"`
+	Venues     []int64 `json:"venues"`
+}
+
+type CreateFollow struct {
+	UserID uuid.UUID `json:"user_id"`
+}
@@ -0,0 +1,138 @@
+package image
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/image/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	imageTable = "event_images"
+)
+
+var imageColumns = []string{
+	"image_id",
+	"event_id",
+	"uploaded_by",
+	"storage_key",
+	"format",
+	"width",
+	"height",
+	"size_bytes",
+	"created_at",
+}
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (rp *repository) CreateImage(ctx context.Context, image model.Image) (int64, error) {
+	insertQuery := rp.builder.
+		Insert(imageTable).Columns(
+		"event_id",
+		"uploaded_by",
+		"storage_key",
+		"format",
+		"width",
+		"height",
+		"size_bytes",
+	).Values(
+		image.EventID,
+		image.UploadedBy,
+		image.StorageKey,
+		image.Format,
+		image.Width,
+		image.Height,
+		image.SizeBytes,
+	).Suffix("RETURNING image_id")
+
+	query, args, err := insertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var imageID int64
+	if err := rp.db.QueryRowxContext(ctx, query, args...).Scan(&imageID); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to create image")
+	}
+	return imageID, nil
+}
+
+func (rp *repository) GetImageById(ctx context.Context, imageID int64) (*model.Image, error) {
+	selectQuery := rp.builder.
+		Select(imageColumns...).
+		From(imageTable).
+		Where(sq.Eq{"image_id": imageID})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var image model.Image
+	if err := rp.db.GetContext(ctx, &image, query, args...); err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch image")
+	}
+	return &image, nil
+}
+
+// GetImagesForEvents returns the images of the given events in upload order
+func (rp *repository) GetImagesForEvents(ctx context.Context, eventIDs []int64) ([]model.Image, error) {
+	if len(eventIDs) == 0 {
+		return nil, nil
+	}
+
+	selectQuery := rp.builder.
+		Select(imageColumns...).
+		From(imageTable).
+		Where(sq.Eq{"event_id": eventIDs}).
+		OrderBy("event_id", "image_id")
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var images []model.Image
+	if err := rp.db.SelectContext(ctx, &images, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch images")
+	}
+	return images, nil
+}
+
+func (rp *repository) CountImages(ctx context.Context, eventID int64) (int, error) {
+	var count int
+	err := rp.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM event_images WHERE event_id = $1;`, eventID)
+	if err != nil {
+		rp.lg.Error("Failed to count images", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return count, nil
+}
+
+func (rp *repository) DeleteImage(ctx context.Context, imageID int64) error {
+	deleteQuery := rp.builder.
+		Delete(imageTable).
+		Where(sq.Eq{"image_id": imageID})
+
+	query, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := rp.db.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to delete image")
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// stored formats, uploads in other formats are converted
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+// ThumbnailSizes are the bounding boxes thumbnails are scaled into, keyed by name
+var ThumbnailSizes = map[string]int{
+	"small":  320,
+	"medium": 800,
+}
+
+type Image struct {
+	ImageID    int64      `json:"image_id" db:"image_id"`                 // BIGSERIAL Primary Key
+	EventID    int64      `json:"event_id" db:"event_id"`                 // BIGINT NOT NULL
+	UploadedBy *uuid.UUID `json:"uploaded_by,omitempty" db:"uploaded_by"` // UUID (Nullable, FK to users)
+	StorageKey string     `json:"-" db:"storage_key"`                     // prefix of the stored files
+	Format     string     `json:"format" db:"format"`                     // "jpeg", "png"
+	Width      int        `json:"width" db:"width"`                       // of the original after orientation
+	Height     int        `json:"height" db:"height"`
+	SizeBytes  int        `json:"size_bytes" db:"size_bytes"` // of the stored original
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"` // TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+}
+
+// Ext is the file extension of the stored files
+func (i Image) Ext() string {
+	if i.Format == FormatPNG {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// OriginalKey is the storage key of the full size image
+func (i Image) OriginalKey() string {
+	return i.StorageKey + "/original" + i.Ext()
+}
+
+// ThumbnailKey is the storage key of the named thumbnail
+func (i Image) ThumbnailKey(name string) string {
+	return i.StorageKey + "/" + name + i.Ext()
+}
+
+// URLs resolves the stored files to their public addresses
+func (i Image) URLs(urlFor func(key string) string) ImageURLs {
+	thumbnails := make(map[string]string, len(ThumbnailSizes))
+	for name := range ThumbnailSizes {
+		thumbnails[name] = urlFor(i.ThumbnailKey(name))
+	}
+	return ImageURLs{
+		ImageID:    i.ImageID,
+		URL:        urlFor(i.OriginalKey()),
+		Width:      i.Width,
+		Height:     i.Height,
+		Thumbnails: thumbnails,
+	}
+}
+
+// ImageURLs is how images appear in event responses
+type ImageURLs struct {
+	ImageID    int64             `json:"image_id"`
+	URL        string            `json:"url"`
+	Width      int               `json:"width"`
+	Height     int               `json:"height"`
+	Thumbnails map[string]string `json:"thumbnails"`
+}
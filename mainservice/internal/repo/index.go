@@ -2,28 +2,88 @@ package repo
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/quietguido/mapnu/mainservice/internal/repo/booking"
 	bookingModel "github.com/quietguido/mapnu/mainservice/internal/repo/booking/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/bookmark"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/calendar"
+	calendarModel "github.com/quietguido/mapnu/mainservice/internal/repo/calendar/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/comment"
+	commentModel "github.com/quietguido/mapnu/mainservice/internal/repo/comment/model"
 	"github.com/quietguido/mapnu/mainservice/internal/repo/event"
 	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/follow"
+	followModel "github.com/quietguido/mapnu/mainservice/internal/repo/follow/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/image"
+	imageModel "github.com/quietguido/mapnu/mainservice/internal/repo/image/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/invite"
+	inviteModel "github.com/quietguido/mapnu/mainservice/internal/repo/invite/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/lock"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/notification"
+	notificationModel "github.com/quietguido/mapnu/mainservice/internal/repo/notification/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/notify"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/organizer"
+	organizerModel "github.com/quietguido/mapnu/mainservice/internal/repo/organizer/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/outbox"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/reminder"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/report"
+	reportModel "github.com/quietguido/mapnu/mainservice/internal/repo/report/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/review"
+	reviewModel "github.com/quietguido/mapnu/mainservice/internal/repo/review/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/savedsearch"
+	savedSearchModel "github.com/quietguido/mapnu/mainservice/internal/repo/savedsearch/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/stream"
+	streamModel "github.com/quietguido/mapnu/mainservice/internal/repo/stream/model"
 	"github.com/quietguido/mapnu/mainservice/internal/repo/user"
 	userModel "github.com/quietguido/mapnu/mainservice/internal/repo/user/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/venue"
+	venueModel "github.com/quietguido/mapnu/mainservice/internal/repo/venue/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/vote"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/webhook"
+	webhookModel "github.com/quietguido/mapnu/mainservice/internal/repo/webhook/model"
 
 	"go.uber.org/zap"
 )
 
 type EventRepository interface {
 	CreateEvent(ctx context.Context, createEvent eventModel.CreateEvent) (int, error)
+	CreateEvents(ctx context.Context, events []eventModel.CreateEvent) ([]int64, error)
+	FindDuplicates(ctx context.Context, events []eventModel.CreateEvent) ([]bool, error)
 	GetEventById(ctx context.Context, eventId int) (*eventModel.Event, error)
+	GetEventsByIds(ctx context.Context, eventIds []int64) ([]eventModel.Event, error)
 	GetMapForQuadrant(ctx context.Context, mapQuery eventModel.GetMapQueryParams) ([]eventModel.Event, error)
+	GetUpcomingForVenues(ctx context.Context, venueIds []int64, from time.Time, perVenue int) ([]eventModel.Event, error)
+	GetUpcomingForOrganizer(ctx context.Context, organizerId int64, from time.Time, limit int) ([]eventModel.Event, error)
+	GetFeed(ctx context.Context, params eventModel.GetFeedQueryParams) ([]eventModel.FeedItem, error)
+	GetBookmarkedEvents(ctx context.Context, params eventModel.GetBookmarksQueryParams) ([]eventModel.BookmarkedEvent, error)
+	SearchEvents(ctx context.Context, params eventModel.SearchQueryParams) ([]eventModel.Event, error)
+	GetTrending(ctx context.Context, params eventModel.GetTrendingQueryParams) ([]eventModel.TrendingEvent, error)
+	GetTrendingCandidates(ctx context.Context, endsAfter, bookedAfter time.Time) ([]eventModel.TrendingCandidate, error)
+	SaveTrendingScores(ctx context.Context, scores []eventModel.TrendingScore) error
+	CreateSeries(ctx context.Context, createSeries eventModel.CreateSeries, occurrences []eventModel.CreateEvent, materializedUntil time.Time) (int64, error)
+	GetSeriesById(ctx context.Context, seriesID int64) (*eventModel.Series, error)
+	GetSeriesToMaterialize(ctx context.Context, horizon time.Time) ([]eventModel.Series, error)
+	AddOccurrences(ctx context.Context, seriesID int64, occurrences []eventModel.CreateEvent, materializedFrom, materializedUntil time.Time) error
+	UpdateOccurrence(ctx context.Context, seriesID, eventID int64, update eventModel.CreateEvent) error
+	UpdateSeriesFrom(ctx context.Context, seriesID int64, update eventModel.CreateSeries, from time.Time, occurrences []eventModel.CreateEvent, materializedUntil time.Time) error
+	CancelOccurrence(ctx context.Context, seriesID, eventID int64) error
+	SetEventHidden(ctx context.Context, eventId int64, hidden bool) error
+	SetVisibility(ctx context.Context, eventId int64, visibility string) error
+	HasAccess(ctx context.Context, eventId int64, userID uuid.UUID) (bool, error)
+	CountSimilar(ctx context.Context, createdBy *uuid.UUID, createEvent eventModel.CreateEvent) (int, error)
+	CountCreatedSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
 }
 
 type UserRepository interface {
 	CreateUser(ctx context.Context, newUser userModel.CreateUser) error
 	GetUserById(ctx context.Context, userId string) (*userModel.User, error)
+	GetUsersByUsernames(ctx context.Context, usernames []string) ([]userModel.User, error)
+	SetUserHidden(ctx context.Context, userId string, hidden bool) error
+	UpdateSettings(ctx context.Context, settings userModel.UpdateSettings) error
 }
 
 type BookingReposity interface {
@@ -31,18 +91,222 @@ type BookingReposity interface {
 	GetBookingById(ctx context.Context, bookingId int) (*bookingModel.Booking, error)
 	GetBookingsForUser(ctx context.Context, userID uuid.UUID) ([]bookingModel.Booking, error)
 	ChangeBookingStatus(ctx context.Context, bookingId int, status string) error
+	GetAttendeeIds(ctx context.Context, eventId int64) ([]uuid.UUID, error)
+}
+
+type VoteRepository interface {
+	SetVote(ctx context.Context, userID uuid.UUID, eventID int64, value int) error
+	DeleteVote(ctx context.Context, userID uuid.UUID, eventID int64) error
+	GetUserVotes(ctx context.Context, userID uuid.UUID, eventIDs []int64) (map[int64]int, error)
+}
+
+type BookmarkRepository interface {
+	AddBookmark(ctx context.Context, userID uuid.UUID, eventID int64) error
+	RemoveBookmark(ctx context.Context, userID uuid.UUID, eventID int64) error
+}
+
+type CalendarRepository interface {
+	SetToken(ctx context.Context, userID uuid.UUID, tokenHash string) error
+	DeleteToken(ctx context.Context, userID uuid.UUID) error
+	GetTokenByHash(ctx context.Context, tokenHash string) (*calendarModel.CalendarToken, error)
+}
+
+type VenueRepository interface {
+	CreateVenue(ctx context.Context, createVenue venueModel.CreateVenue) (int64, error)
+	GetVenueById(ctx context.Context, venueID int64) (*venueModel.Venue, error)
+	GetVenuesForQuadrant(ctx context.Context, params venueModel.GetVenuesMapQueryParams) ([]venueModel.Venue, error)
+}
+
+type OrganizerRepository interface {
+	CreateOrganizer(ctx context.Context, createOrganizer organizerModel.CreateOrganizer) (int64, error)
+	GetOrganizerById(ctx context.Context, organizerID int64) (*organizerModel.Organizer, error)
+	UpdateOrganizer(ctx context.Context, organizerID int64, update organizerModel.UpdateOrganizer) error
+	GetMembers(ctx context.Context, organizerID int64) ([]organizerModel.Member, error)
+	GetMemberRole(ctx context.Context, organizerID int64, userID uuid.UUID) (string, error)
+	SetMember(ctx context.Context, organizerID int64, userID uuid.UUID, role string) error
+	DeleteMember(ctx context.Context, organizerID int64, userID uuid.UUID) error
+}
+
+type FollowRepository interface {
+	FollowOrganizer(ctx context.Context, userID uuid.UUID, organizerID int64) error
+	UnfollowOrganizer(ctx context.Context, userID uuid.UUID, organizerID int64) error
+	FollowVenue(ctx context.Context, userID uuid.UUID, venueID int64) error
+	UnfollowVenue(ctx context.Context, userID uuid.UUID, venueID int64) error
+	GetFollowing(ctx context.Context, userID uuid.UUID) (*followModel.Following, error)
+}
+
+type CommentRepository interface {
+	CreateComment(ctx context.Context, createComment commentModel.CreateComment, rootID *int64) (int64, error)
+	GetCommentById(ctx context.Context, commentID int64) (*commentModel.Comment, error)
+	GetThreads(ctx context.Context, params commentModel.GetCommentsQueryParams) ([]commentModel.Comment, error)
+	GetReplies(ctx context.Context, params commentModel.GetCommentsQueryParams) ([]commentModel.Comment, error)
+	GetPinnedReplies(ctx context.Context, rootIDs []int64) ([]commentModel.Comment, error)
+	UpdateComment(ctx context.Context, commentID int64, update commentModel.UpdateComment) error
+	DeleteComment(ctx context.Context, commentID int64) error
+	SetPinned(ctx context.Context, commentID int64, pinned bool) error
+	SetStatus(ctx context.Context, commentID int64, status string) error
+}
+
+type ReviewRepository interface {
+	CreateReview(ctx context.Context, createReview reviewModel.CreateReview) (int64, error)
+	GetReviewById(ctx context.Context, reviewID int64) (*reviewModel.Review, error)
+	GetReviewsForEvent(ctx context.Context, params reviewModel.GetReviewsQueryParams) ([]reviewModel.Review, error)
+	UpdateReview(ctx context.Context, reviewID int64, update reviewModel.UpdateReview) error
+	DeleteReview(ctx context.Context, reviewID int64) error
+}
+
+type ReportRepository interface {
+	CreateReport(ctx context.Context, createReport reportModel.CreateReport) (int64, error)
+	GetReportById(ctx context.Context, reportID int64) (*reportModel.Report, error)
+	GetQueue(ctx context.Context, params reportModel.GetQueueQueryParams) ([]reportModel.Report, error)
+	AssignReport(ctx context.Context, reportID int64, assignee *uuid.UUID) error
+	ResolveTarget(ctx context.Context, targetType, targetID string, hide bool, status string, moderatorID uuid.UUID, note string) (int64, error)
+}
+
+type ImageRepository interface {
+	CreateImage(ctx context.Context, image imageModel.Image) (int64, error)
+	GetImageById(ctx context.Context, imageID int64) (*imageModel.Image, error)
+	GetImagesForEvents(ctx context.Context, eventIDs []int64) ([]imageModel.Image, error)
+	CountImages(ctx context.Context, eventID int64) (int, error)
+	DeleteImage(ctx context.Context, imageID int64) error
+}
+
+type NotifyRepository interface {
+	Notify(ctx context.Context, channel string, payload any) error
+	Listen(ctx context.Context, channels []string, handle func(channel string, payload []byte)) error
+}
+
+type StreamRepository interface {
+	AppendEvents(ctx context.Context, userIDs []uuid.UUID, eventType string, payload any, messageID int64) error
+	GetEventsAfter(ctx context.Context, userID uuid.UUID, afterSeq int64, limit int) ([]streamModel.StreamEvent, error)
+	GetLatestSeq(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type OutboxRepository interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]outboxModel.Message, error)
+	RenewLease(ctx context.Context, messageID int64, attempt int, lease time.Duration) (bool, error)
+	MarkDelivered(ctx context.Context, messageID int64, attempt int) error
+	MarkHandled(ctx context.Context, messageID int64, attempt int, consumer string) error
+	Retry(ctx context.Context, messageID int64, attempt int, lastError string, at time.Time) error
+	DeadLetter(ctx context.Context, messageID int64, attempt int, lastError string) error
+	GetMessages(ctx context.Context, params outboxModel.GetMessagesQueryParams) ([]outboxModel.Message, error)
+	GetMessageById(ctx context.Context, messageID int64) (*outboxModel.Message, error)
+	Replay(ctx context.Context, messageID int64) error
+	ReplayDead(ctx context.Context, topic string) (int64, error)
+	DeleteDeliveredBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type NotificationRepository interface {
+	GetPreferences(ctx context.Context, userID uuid.UUID) ([]notificationModel.Preference, error)
+	SetPreference(ctx context.Context, preference notificationModel.Preference) error
+	AddPushDevice(ctx context.Context, device notificationModel.RegisterPushDevice) (int64, error)
+	DeletePushDevice(ctx context.Context, userID uuid.UUID, token string) error
+	GetPushDevices(ctx context.Context, userID uuid.UUID) ([]notificationModel.PushDevice, error)
+	WasSent(ctx context.Context, dedupeKey, channel, recipient string) (bool, error)
+	LogDelivery(ctx context.Context, delivery notificationModel.Delivery) error
+	GetDeliveries(ctx context.Context, userID uuid.UUID, params notificationModel.GetDeliveriesQueryParams) ([]notificationModel.Delivery, error)
+}
+
+type ReminderRepository interface {
+	EnqueueDue(ctx context.Context, remindBefore, notBefore time.Duration) (int, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type LockRepository interface {
+	TryLock(ctx context.Context, key int64) (release func(), ok bool, err error)
+	Lock(ctx context.Context, namespace int32, key string) (release func(), err error)
+}
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, organizerID int64, createWebhook webhookModel.CreateWebhook, secret string) (int64, error)
+	GetWebhookById(ctx context.Context, webhookID int64) (*webhookModel.Webhook, error)
+	GetWebhooks(ctx context.Context, organizerID int64) ([]webhookModel.Webhook, error)
+	CountWebhooks(ctx context.Context, organizerID int64) (int, error)
+	UpdateWebhook(ctx context.Context, webhookID int64, update webhookModel.UpdateWebhook) error
+	DeleteWebhook(ctx context.Context, webhookID int64) error
+	EnqueueDeliveries(ctx context.Context, organizerID int64, eventType string, payload any, sourceID int64) (int64, error)
+	CreateClaimedDelivery(ctx context.Context, webhookID int64, eventType string, payload any, lease time.Duration) (*webhookModel.Delivery, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]webhookModel.Attempt, error)
+	RecordAttempt(ctx context.Context, result webhookModel.AttemptResult, disableAfter int) (bool, error)
+	GetDeliveries(ctx context.Context, webhookID int64, params webhookModel.GetDeliveriesQueryParams) ([]webhookModel.Delivery, error)
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type SavedSearchRepository interface {
+	CreateSearch(ctx context.Context, save savedSearchModel.SaveSearch) (int64, error)
+	GetSearchById(ctx context.Context, searchID int64) (*savedSearchModel.SavedSearch, error)
+	GetSearches(ctx context.Context, userID uuid.UUID) ([]savedSearchModel.SavedSearch, error)
+	CountSearches(ctx context.Context, userID uuid.UUID) (int, error)
+	UpdateSearch(ctx context.Context, searchID int64, save savedSearchModel.SaveSearch) error
+	DeleteSearch(ctx context.Context, searchID int64) error
+	MatchEvent(ctx context.Context, eventID int64) (int64, error)
+	GetDueDigestUsers(ctx context.Context, digestHour, limit int) ([]uuid.UUID, error)
+	EnqueueDigest(ctx context.Context, userID uuid.UUID, maxEvents int) (bool, error)
+	DeleteDigestedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type InviteRepository interface {
+	AddInvites(ctx context.Context, eventID int64, invitedBy uuid.UUID, userIDs []uuid.UUID) (int64, error)
+	RemoveInvite(ctx context.Context, eventID int64, userID uuid.UUID) error
+	GetInvites(ctx context.Context, eventID int64) ([]inviteModel.Invite, error)
+	CreateLink(ctx context.Context, createLink inviteModel.CreateLink) (*inviteModel.Link, error)
+	GetLinkById(ctx context.Context, linkID int64) (*inviteModel.Link, error)
+	GetLinkByCode(ctx context.Context, code string) (*inviteModel.Link, error)
+	GetLinks(ctx context.Context, eventID int64) ([]inviteModel.Link, error)
+	CountActiveLinks(ctx context.Context, eventID int64) (int, error)
+	RevokeLink(ctx context.Context, eventID, linkID int64) error
+	RedeemLink(ctx context.Context, linkID int64, userID uuid.UUID, now time.Time) error
 }
 
 type Repositories struct {
-	Event   EventRepository
-	User    UserRepository
-	Booking BookingReposity
+	Event        EventRepository
+	User         UserRepository
+	Booking      BookingReposity
+	Vote         VoteRepository
+	Bookmark     BookmarkRepository
+	Calendar     CalendarRepository
+	Venue        VenueRepository
+	Organizer    OrganizerRepository
+	Follow       FollowRepository
+	Comment      CommentRepository
+	Review       ReviewRepository
+	Report       ReportRepository
+	Image        ImageRepository
+	Notify       NotifyRepository
+	Stream       StreamRepository
+	Outbox       OutboxRepository
+	Notification NotificationRepository
+	Reminder     ReminderRepository
+	Lock         LockRepository
+	Webhook      WebhookRepository
+	SavedSearch  SavedSearchRepository
+	Invite       InviteRepository
 }
 
 func InitRepositories(lg *zap.Logger, db *sqlx.DB) *Repositories {
 	return &Repositories{
-		Event:   event.NewRepository(lg, db),
-		User:    user.NewRepository(lg, db),
-		Booking: booking.NewRepository(lg, db),
+		Event:        event.NewRepository(lg, db),
+		User:         user.NewRepository(lg, db),
+		Booking:      booking.NewRepository(lg, db),
+		Vote:         vote.NewRepository(lg, db),
+		Bookmark:     bookmark.NewRepository(lg, db),
+		Calendar:     calendar.NewRepository(lg, db),
+		Venue:        venue.NewRepository(lg, db),
+		Organizer:    organizer.NewRepository(lg, db),
+		Follow:       follow.NewRepository(lg, db),
+		Comment:      comment.NewRepository(lg, db),
+		Review:       review.NewRepository(lg, db),
+		Report:       report.NewRepository(lg, db),
+		Image:        image.NewRepository(lg, db),
+		Notify:       notify.NewRepository(lg, db),
+		Stream:       stream.NewRepository(lg, db),
+		Outbox:       outbox.NewRepository(lg, db),
+		Notification: notification.NewRepository(lg, db),
+		Reminder:     reminder.NewRepository(lg, db),
+		Lock:         lock.NewRepository(lg, db),
+		Webhook:      webhook.NewRepository(lg, db),
+		SavedSearch:  savedsearch.NewRepository(lg, db),
+		Invite:       invite.NewRepository(lg, db),
 	}
 }
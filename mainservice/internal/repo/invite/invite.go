@@ -0,0 +1,303 @@
+package invite
+
+import (
+	"context"
+	dbsql "database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/invite/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	inviteTable = "event_invites"
+	linkTable   = "event_invite_links"
+)
+
+var ErrLinkInactive = errors.New("Invite link is expired, used up or revoked")
+
+const linkColumns = `
+			link_id,
+			event_id,
+			code,
+			created_by,
+			max_uses,
+			uses,
+			expires_at,
+			revoked_at,
+			created_at`
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// AddInvites puts the users on the event's invite list and returns how many were not on it yet
+func (rp *repository) AddInvites(ctx context.Context, eventID int64, invitedBy uuid.UUID, userIDs []uuid.UUID) (int64, error) {
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+
+	insertQuery := rp.builder.
+		Insert(inviteTable).
+		Columns("event_id", "user_id", "invited_by")
+	for _, userID := range userIDs {
+		insertQuery = insertQuery.Values(eventID, userID, invitedBy)
+	}
+	insertQuery = insertQuery.Suffix("ON CONFLICT (event_id, user_id) DO NOTHING")
+
+	query, args, err := insertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to add invites")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to get affected rows")
+	}
+	return num, nil
+}
+
+func (rp *repository) RemoveInvite(ctx context.Context, eventID int64, userID uuid.UUID) error {
+	deleteQuery := rp.builder.
+		Delete(inviteTable).
+		Where(sq.Eq{"event_id": eventID, "user_id": userID})
+
+	query, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to remove invite")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get affected rows")
+	}
+	if num == 0 {
+		return errors.New("No invite found for the given user")
+	}
+	return nil
+}
+
+// GetInvites returns the event's invite list, newest first
+func (rp *repository) GetInvites(ctx context.Context, eventID int64) ([]model.Invite, error) {
+	selectQuery := rp.builder.
+		Select(
+			"i.event_id",
+			"i.user_id",
+			"u.username",
+			"i.invited_by",
+			"i.link_id",
+			"i.created_at",
+		).
+		From(inviteTable+" i").
+		Join("users u ON u.id = i.user_id").
+		Where(sq.Eq{"i.event_id": eventID}).
+		OrderBy("i.created_at DESC", "u.username ASC")
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	invites := []model.Invite{}
+	if err := rp.db.SelectContext(ctx, &invites, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch invites")
+	}
+	return invites, nil
+}
+
+func (rp *repository) CreateLink(ctx context.Context, createLink model.CreateLink) (*model.Link, error) {
+	insertQuery := rp.builder.
+		Insert(linkTable).
+		Columns(
+			"event_id",
+			"code",
+			"created_by",
+			"max_uses",
+			"expires_at",
+		).
+		Values(
+			createLink.EventID,
+			createLink.Code,
+			createLink.UserID,
+			createLink.MaxUses,
+			createLink.ExpiresAt,
+		).
+		Suffix("RETURNING " + linkColumns)
+
+	query, args, err := insertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var link model.Link
+	if err := rp.db.GetContext(ctx, &link, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to create invite link")
+	}
+	return &link, nil
+}
+
+func (rp *repository) GetLinkById(ctx context.Context, linkID int64) (*model.Link, error) {
+	return rp.getLink(ctx, sq.Eq{"link_id": linkID})
+}
+
+func (rp *repository) GetLinkByCode(ctx context.Context, code string) (*model.Link, error) {
+	return rp.getLink(ctx, sq.Eq{"code": code})
+}
+
+func (rp *repository) getLink(ctx context.Context, where sq.Eq) (*model.Link, error) {
+	selectQuery := rp.builder.
+		Select(linkColumns).
+		From(linkTable).
+		Where(where)
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var link model.Link
+	err = rp.db.GetContext(ctx, &link, query, args...)
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return nil, errors.New("No invite link found")
+	}
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch invite link")
+	}
+	return &link, nil
+}
+
+// GetLinks returns the event's invite links, newest first
+func (rp *repository) GetLinks(ctx context.Context, eventID int64) ([]model.Link, error) {
+	selectQuery := rp.builder.
+		Select(linkColumns).
+		From(linkTable).
+		Where(sq.Eq{"event_id": eventID}).
+		OrderBy("created_at DESC", "link_id DESC")
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	links := []model.Link{}
+	if err := rp.db.SelectContext(ctx, &links, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch invite links")
+	}
+	return links, nil
+}
+
+// CountActiveLinks counts the links of the event that can still be redeemed
+func (rp *repository) CountActiveLinks(ctx context.Context, eventID int64) (int, error) {
+	var count int
+	err := rp.db.GetContext(ctx, &count, `
+		SELECT COUNT(*) FROM event_invite_links
+		WHERE event_id = $1
+			AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > NOW())
+			AND (max_uses IS NULL OR uses < max_uses);
+	`, eventID)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to count invite links")
+	}
+	return count, nil
+}
+
+// RevokeLink stops the link from being redeemed, users already invited through it stay invited
+func (rp *repository) RevokeLink(ctx context.Context, eventID, linkID int64) error {
+	updateQuery := rp.builder.
+		Update(linkTable).
+		Set("revoked_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"link_id": linkID, "event_id": eventID}).
+		Where(sq.Eq{"revoked_at": nil})
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to revoke invite link")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get affected rows")
+	}
+	if num == 0 {
+		return errors.New("No active invite link found with the given ID")
+	}
+	return nil
+}
+
+// RedeemLink puts the user on the invite list of the link's event. A use is only counted
+// for users who were not invited yet, so opening a link twice costs nothing.
+func (rp *repository) RedeemLink(ctx context.Context, linkID int64, userID uuid.UUID, now time.Time) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	// the row lock keeps concurrent redemptions from going over max_uses
+	var link model.Link
+	err = tx.GetContext(ctx, &link, `SELECT `+linkColumns+` FROM event_invite_links WHERE link_id = $1 FOR UPDATE;`, linkID)
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return errors.New("No invite link found")
+	}
+	if err != nil {
+		rp.lg.Error("Failed to lock invite link", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	var invited bool
+	err = tx.GetContext(ctx, &invited, `
+		SELECT EXISTS (SELECT 1 FROM event_invites WHERE event_id = $1 AND user_id = $2);
+	`, link.EventID, userID)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+	if invited {
+		return tx.Commit()
+	}
+	if !link.Active(now) {
+		return ErrLinkInactive
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO event_invites (event_id, user_id, link_id) VALUES ($1, $2, $3);
+	`, link.EventID, userID, link.LinkID)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to add invite")
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE event_invite_links SET uses = uses + 1 WHERE link_id = $1;`, link.LinkID)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to count invite link use")
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invite puts a user on an event's invite list
+type Invite struct {
+	EventID   int64      `json:"event_id" db:"event_id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	Username  string     `json:"username" db:"username"`
+	InvitedBy *uuid.UUID `json:"invited_by,omitempty" db:"invited_by"` // nil when the user redeemed a link
+	LinkID    *int64     `json:"link_id,omitempty" db:"link_id"`       // the redeemed link
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// InviteUsers adds users to the invite list by id or username
+type InviteUsers struct {
+	UserID     uuid.UUID   `json:"user_id"` // the acting organizer
+	InviteeIDs []uuid.UUID `json:"invitee_ids"`
+	Usernames  []string    `json:"usernames"`
+}
+
+// Link is an invite link, its token and its code both put whoever redeems them on the invite list
+type Link struct {
+	LinkID    int64      `json:"link_id" db:"link_id"`
+	EventID   int64      `json:"event_id" db:"event_id"`
+	Code      string     `json:"code" db:"code"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	MaxUses   *int       `json:"max_uses,omitempty" db:"max_uses"` // unlimited when nil
+	Uses      int        `json:"uses" db:"uses"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	Token     string     `json:"token,omitempty" db:"-"`
+	URL       string     `json:"url,omitempty" db:"-"`
+}
+
+// Active reports whether the link can still be redeemed at now
+func (l *Link) Active(now time.Time) bool {
+	if l.RevokedAt != nil {
+		return false
+	}
+	if l.ExpiresAt != nil && !now.Before(*l.ExpiresAt) {
+		return false
+	}
+	return l.MaxUses == nil || l.Uses < *l.MaxUses
+}
+
+type CreateLink struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	MaxUses   *int       `json:"max_uses,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	EventID   int64      `json:"-"`
+	Code      string     `json:"-"`
+}
+
+type RedeemInvite struct {
+	UserID uuid.UUID `json:"user_id"`
+	Invite string    `json:"invite"` // token of an invite link or its code
+}
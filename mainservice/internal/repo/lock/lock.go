@@ -0,0 +1,82 @@
+// Package lock coordinates jobs across instances with Postgres advisory locks
+package lock
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type repository struct {
+	lg *zap.Logger
+	db *sqlx.DB
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg: lg,
+		db: db,
+	}
+}
+
+// TryLock takes the session-level advisory lock on a connection of its own
+// without waiting. ok is false when another session holds it; otherwise
+// release must be called once the job is done. A crashed holder's lock goes
+// away with its connection.
+func (rp *repository) TryLock(ctx context.Context, key int64) (release func(), ok bool, err error) {
+	conn, err := rp.db.Conn(ctx)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "Failed to get connection")
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		conn.Close()
+		rp.lg.Error("Failed to take advisory lock", zap.Int64("key", key), zap.Error(err))
+		return nil, false, errors.Wrap(err, "Failed to take advisory lock")
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release = func() {
+		// ctx may be done by now, the lock has to be released regardless
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			rp.lg.Error("Failed to release advisory lock", zap.Int64("key", key), zap.Error(err))
+			// never hand a connection that may still hold the lock back to the pool
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return release, true, nil
+}
+
+// Lock waits for the session-level advisory lock of key within namespace, e.g.
+// one user's event quota, on a connection of its own. Keys are hashed into the
+// two-key space, which never collides with the job locks of TryLock. release
+// must be called once done.
+func (rp *repository) Lock(ctx context.Context, namespace int32, key string) (release func(), err error) {
+	conn, err := rp.db.Conn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get connection")
+	}
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1, hashtext($2))", namespace, key); err != nil {
+		conn.Close()
+		rp.lg.Error("Failed to take advisory lock", zap.Int32("namespace", namespace), zap.String("key", key), zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to take advisory lock")
+	}
+
+	release = func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1, hashtext($2))", namespace, key); err != nil {
+			rp.lg.Error("Failed to release advisory lock", zap.Int32("namespace", namespace), zap.String("key", key), zap.Error(err))
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return release, nil
+}
@@ -0,0 +1,14 @@
+package model
+
+// advisory lock keys, one per job that must only run on one instance at a time
+const (
+	KeySeriesMaterializer int64 = 30001
+	KeyReminderScheduler  int64 = 46001
+	KeySearchDigest       int64 = 48001
+)
+
+// advisory lock namespaces, locked per key with Lock
+const (
+	// one user's event quota, held from counting their events until the new ones are inserted
+	NamespaceEventQuota int32 = 39001
+)
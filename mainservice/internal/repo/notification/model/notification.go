@@ -0,0 +1,105 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// notification types, each needs a template per locale in services/notification
+const (
+	TypeBookingConfirmed = "booking.confirmed"
+	TypeBookingRejected  = "booking.rejected"
+	TypeEventUpdated     = "event.updated"
+	TypeEventCancelled   = "event.cancelled"
+	TypeEventReminder    = "event.reminder"
+	TypeSearchDigest     = "search.digest"
+)
+
+var Types = []string{
+	TypeBookingConfirmed,
+	TypeBookingRejected,
+	TypeEventUpdated,
+	TypeEventCancelled,
+	TypeEventReminder,
+	TypeSearchDigest,
+}
+
+// channels, must stay in sync with the CHECK constraint on notification_deliveries.channel
+const (
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+)
+
+// delivery statuses, must stay in sync with the CHECK constraint on notification_deliveries.status
+const (
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped" // the user turned the channel off
+)
+
+// push platforms, must stay in sync with the CHECK constraint on push_devices.platform
+var Platforms = []string{"ios", "android", "web"}
+
+// Preference tells which channels a notification type reaches the user on
+type Preference struct {
+	UserID uuid.UUID `json:"-" db:"user_id"`
+	Type   string    `json:"type" db:"type"`
+	Email  bool      `json:"email" db:"email"`
+	Push   bool      `json:"push" db:"push"`
+}
+
+// DefaultPreference applies while the user hasn't set one for the type
+func DefaultPreference(userID uuid.UUID, notificationType string) Preference {
+	return Preference{
+		UserID: userID,
+		Type:   notificationType,
+		Email:  true,
+		Push:   true,
+	}
+}
+
+type SetPreference struct {
+	UserID uuid.UUID `json:"user_id"` // change for token
+	Type   string    `json:"type"`
+	Email  bool      `json:"email"`
+	Push   bool      `json:"push"`
+}
+
+type PushDevice struct {
+	DeviceID  int64     `json:"device_id" db:"device_id"` // BIGSERIAL Primary Key
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Token     string    `json:"token" db:"token"`       // issued by the push provider, UNIQUE
+	Platform  string    `json:"platform" db:"platform"` // one of Platforms
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type RegisterPushDevice struct {
+	UserID   uuid.UUID `json:"user_id"` // change for token
+	Token    string    `json:"token"`
+	Platform string    `json:"platform"`
+}
+
+type Delivery struct {
+	DeliveryID int64     `json:"delivery_id" db:"delivery_id"` // BIGSERIAL Primary Key
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`
+	Type       string    `json:"type" db:"type"`
+	Channel    string    `json:"channel" db:"channel"`       // "email", "push"
+	Recipient  string    `json:"recipient" db:"recipient"`   // email address or device token, empty when skipped
+	DedupeKey  string    `json:"dedupe_key" db:"dedupe_key"` // identifies the notification across retries
+	Status     string    `json:"status" db:"status"`         // "sent", "failed", "skipped"
+	Subject    string    `json:"subject" db:"subject"`
+	Error      *string   `json:"error,omitempty" db:"error"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+type GetDeliveriesQueryParams struct {
+	BeforeCreated time.Time
+	BeforeID      int64
+	Limit         int
+}
+
+type DeliveryPage struct {
+	Deliveries []Delivery `json:"deliveries"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,198 @@
+package notification
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/notification/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	preferenceTable = "notification_preferences"
+	pushDeviceTable = "push_devices"
+	deliveryTable   = "notification_deliveries"
+)
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// GetPreferences returns the preferences the user has set, types without one use the default
+func (rp *repository) GetPreferences(ctx context.Context, userID uuid.UUID) ([]model.Preference, error) {
+	selectQuery := rp.builder.
+		Select("user_id", "type", "email", "push").
+		From(preferenceTable).
+		Where(sq.Eq{"user_id": userID})
+
+	sql, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var preferences []model.Preference
+	if err := rp.db.SelectContext(ctx, &preferences, sql, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch notification preferences")
+	}
+	return preferences, nil
+}
+
+func (rp *repository) SetPreference(ctx context.Context, preference model.Preference) error {
+	upsertQuery := rp.builder.
+		Insert(preferenceTable).
+		Columns("user_id", "type", "email", "push").
+		Values(preference.UserID, preference.Type, preference.Email, preference.Push).
+		Suffix("ON CONFLICT (user_id, type) DO UPDATE SET email = EXCLUDED.email, push = EXCLUDED.push, updated_at = CURRENT_TIMESTAMP")
+
+	sql, args, err := upsertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := rp.db.ExecContext(ctx, sql, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to store notification preference")
+	}
+	return nil
+}
+
+// AddPushDevice registers the token for the user, a token seen before moves to the user
+func (rp *repository) AddPushDevice(ctx context.Context, device model.RegisterPushDevice) (int64, error) {
+	upsertQuery := rp.builder.
+		Insert(pushDeviceTable).
+		Columns("user_id", "token", "platform").
+		Values(device.UserID, device.Token, device.Platform).
+		Suffix(`ON CONFLICT (token) DO UPDATE SET user_id = EXCLUDED.user_id, platform = EXCLUDED.platform,
+			created_at = CURRENT_TIMESTAMP RETURNING device_id`)
+
+	sql, args, err := upsertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var deviceID int64
+	if err := rp.db.QueryRowContext(ctx, sql, args...).Scan(&deviceID); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to store push device")
+	}
+	return deviceID, nil
+}
+
+func (rp *repository) DeletePushDevice(ctx context.Context, userID uuid.UUID, token string) error {
+	deleteQuery := rp.builder.
+		Delete(pushDeviceTable).
+		Where(sq.Eq{"user_id": userID, "token": token})
+
+	sql, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to delete push device")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get affected rows")
+	}
+	if num == 0 {
+		return errors.New("No push device found with the given token")
+	}
+	return nil
+}
+
+func (rp *repository) GetPushDevices(ctx context.Context, userID uuid.UUID) ([]model.PushDevice, error) {
+	selectQuery := rp.builder.
+		Select("device_id", "user_id", "token", "platform", "created_at").
+		From(pushDeviceTable).
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("device_id")
+
+	sql, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var devices []model.PushDevice
+	if err := rp.db.SelectContext(ctx, &devices, sql, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch push devices")
+	}
+	return devices, nil
+}
+
+// WasSent reports whether the notification already reached the recipient on the channel
+func (rp *repository) WasSent(ctx context.Context, dedupeKey, channel, recipient string) (bool, error) {
+	var sent bool
+	err := rp.db.GetContext(ctx, &sent, `
+		SELECT EXISTS (
+			SELECT 1 FROM notification_deliveries
+			WHERE dedupe_key = $1 AND channel = $2 AND recipient = $3 AND status = 'sent'
+		);
+	`, dedupeKey, channel, recipient)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return false, errors.Wrap(err, "Failed to check notification deliveries")
+	}
+	return sent, nil
+}
+
+// LogDelivery records a delivery attempt. A second successful or skipped
+// delivery of the same notification, e.g. from a retry, is dropped.
+func (rp *repository) LogDelivery(ctx context.Context, delivery model.Delivery) error {
+	_, err := rp.db.ExecContext(ctx, `
+		INSERT INTO notification_deliveries (user_id, type, channel, recipient, dedupe_key, status, subject, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (dedupe_key, channel, recipient) WHERE status IN ('sent', 'skipped') DO NOTHING;
+	`, delivery.UserID, delivery.Type, delivery.Channel, delivery.Recipient,
+		delivery.DedupeKey, delivery.Status, delivery.Subject, delivery.Error)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to log notification delivery")
+	}
+	return nil
+}
+
+// GetDeliveries returns the user's deliveries newest first, before the keyset cursor when set
+func (rp *repository) GetDeliveries(ctx context.Context, userID uuid.UUID, params model.GetDeliveriesQueryParams) ([]model.Delivery, error) {
+	selectQuery := rp.builder.
+		Select(
+			"delivery_id",
+			"user_id",
+			"type",
+			"channel",
+			"recipient",
+			"dedupe_key",
+			"status",
+			"subject",
+			"error",
+			"created_at",
+		).
+		From(deliveryTable).
+		Where(sq.Eq{"user_id": userID})
+	if params.BeforeID != 0 {
+		selectQuery = selectQuery.Where("(created_at, delivery_id) < (?, ?)", params.BeforeCreated, params.BeforeID)
+	}
+	selectQuery = selectQuery.
+		OrderBy("created_at DESC", "delivery_id DESC").
+		Limit(uint64(params.Limit))
+
+	sql, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var deliveries []model.Delivery
+	if err := rp.db.SelectContext(ctx, &deliveries, sql, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch notification deliveries")
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// Postgres rejects NOTIFY payloads of 8000 bytes and more
+	MaxPayloadSize = 7900
+
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+type repository struct {
+	lg *zap.Logger
+	db *sqlx.DB
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg: lg,
+		db: db,
+	}
+}
+
+// Notify sends the payload as JSON to the listeners of the channel on every instance
+func (rp *repository) Notify(ctx context.Context, channel string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode notification")
+	}
+	if len(data) > MaxPayloadSize {
+		return errors.Errorf("Notification of %d bytes is too large", len(data))
+	}
+
+	if _, err := rp.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, string(data)); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to send notification")
+	}
+	return nil
+}
+
+// Listen passes the notifications of the channels to handle until ctx is done.
+// A lost connection is reestablished, notifications sent in between are missed.
+func (rp *repository) Listen(ctx context.Context, channels []string, handle func(channel string, payload []byte)) error {
+	delay := minReconnectDelay
+	for {
+		started := time.Now()
+		err := rp.listen(ctx, channels, handle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rp.lg.Error("Lost notification listener", zap.Strings("channels", channels), zap.Error(err))
+
+		// a listener that ran for a while starts over with the shortest delay
+		if time.Since(started) > maxReconnectDelay {
+			delay = minReconnectDelay
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+func (rp *repository) listen(ctx context.Context, channels []string, handle func(channel string, payload []byte)) error {
+	conn, err := rp.db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Failed to acquire connection")
+	}
+	defer conn.Close()
+
+	var listenErr error
+	conn.Raw(func(driverConn any) error {
+		pgConn := driverConn.(*stdlib.Conn).Conn()
+		listenErr = wait(ctx, pgConn, channels, handle)
+
+		// the connection keeps its LISTEN state, so it must not go back to the pool
+		return driver.ErrBadConn
+	})
+	return listenErr
+}
+
+func wait(ctx context.Context, conn *pgx.Conn, channels []string, handle func(channel string, payload []byte)) error {
+	for _, channel := range channels {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return errors.Wrap(err, "Failed to listen")
+		}
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return errors.Wrap(err, "Failed to wait for notification")
+		}
+		handle(notification.Channel, []byte(notification.Payload))
+	}
+}
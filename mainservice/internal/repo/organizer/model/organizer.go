@@ -0,0 +1,90 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+)
+
+// member roles, must stay in sync with the CHECK constraint on organizer_members.role
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+)
+
+// permissions granted by the roles
+const (
+	PermissionEditProfile    = "edit_profile"
+	PermissionManageMembers  = "manage_members"
+	PermissionManageEvents   = "manage_events"
+	PermissionManageBookings = "manage_bookings"
+	PermissionManageWebhooks = "manage_webhooks"
+)
+
+var rolePermissions = map[string][]string{
+	RoleOwner:  {PermissionEditProfile, PermissionManageMembers, PermissionManageEvents, PermissionManageBookings, PermissionManageWebhooks},
+	RoleAdmin:  {PermissionEditProfile, PermissionManageMembers, PermissionManageEvents, PermissionManageBookings, PermissionManageWebhooks},
+	RoleEditor: {PermissionManageEvents, PermissionManageBookings},
+}
+
+// RoleCan reports whether the role grants the permission, an empty role (not a member) grants nothing
+func RoleCan(role, permission string) bool {
+	return slices.Contains(rolePermissions[role], permission)
+}
+
+func CheckRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+type Organizer struct {
+	OrganizerID int64     `json:"organizer_id" db:"organizer_id"` // BIGSERIAL Primary Key
+	Name        string    `json:"name" db:"name"`                 // VARCHAR(255) NOT NULL
+	Description string    `json:"description" db:"description"`   // TEXT NOT NULL DEFAULT ''
+	LogoURL     string    `json:"logo_url" db:"logo_url"`         // TEXT NOT NULL DEFAULT ''
+	Website     string    `json:"website" db:"website"`           // TEXT NOT NULL DEFAULT ''
+	Verified    bool      `json:"verified" db:"verified"`         // BOOLEAN NOT NULL DEFAULT FALSE
+	Followers   int       `json:"followers" db:"follower_count"`  // INTEGER NOT NULL DEFAULT 0
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type Member struct {
+	OrganizerID int64     `json:"organizer_id" db:"organizer_id"`
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	Role        string    `json:"role" db:"role"` // "owner", "admin", "editor"
+	AddedAt     time.Time `json:"added_at" db:"added_at"`
+}
+
+// OrganizerProfile is the public organizer page
+type OrganizerProfile struct {
+	Organizer
+	Members        []Member           `json:"members"`
+	UpcomingEvents []eventModel.Event `json:"upcoming_events"`
+}
+
+type CreateOrganizer struct {
+	UserID      uuid.UUID `json:"user_id"` // becomes the first owner
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	LogoURL     string    `json:"logo_url"`
+	Website     string    `json:"website"`
+}
+
+type UpdateOrganizer struct {
+	UserID      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	LogoURL     string    `json:"logo_url"`
+	Website     string    `json:"website"`
+}
+
+type SetMember struct {
+	UserID   uuid.UUID `json:"user_id"`   // member making the change
+	MemberID uuid.UUID `json:"member_id"` // user being added or changed
+	Role     string    `json:"role"`
+}
@@ -0,0 +1,194 @@
+package organizer
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/organizer/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	organizerTable = "organizers"
+	memberTable    = "organizer_members"
+)
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// CreateOrganizer stores the organizer with its creator as the first owner
+func (rp *repository) CreateOrganizer(ctx context.Context, createOrganizer model.CreateOrganizer) (int64, error) {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	insertQuery := rp.builder.
+		Insert(organizerTable).
+		Columns("name", "description", "logo_url", "website").
+		Values(createOrganizer.Name, createOrganizer.Description, createOrganizer.LogoURL, createOrganizer.Website).
+		Suffix("RETURNING organizer_id")
+
+	query, args, err := insertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var organizerID int64
+	if err := tx.QueryRowxContext(ctx, query, args...).Scan(&organizerID); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to create organizer")
+	}
+
+	memberQuery := rp.builder.
+		Insert(memberTable).
+		Columns("organizer_id", "user_id", "role").
+		Values(organizerID, createOrganizer.UserID, model.RoleOwner)
+
+	query, args, err = memberQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to add organizer owner")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit transaction")
+	}
+	return organizerID, nil
+}
+
+func (rp *repository) GetOrganizerById(ctx context.Context, organizerID int64) (*model.Organizer, error) {
+	selectQuery := rp.builder.
+		Select("organizer_id", "name", "description", "logo_url", "website", "verified", "follower_count", "created_at", "updated_at").
+		From(organizerTable).
+		Where(sq.Eq{"organizer_id": organizerID})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var organizer model.Organizer
+	if err := rp.db.GetContext(ctx, &organizer, query, args...); err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch organizer")
+	}
+	return &organizer, nil
+}
+
+func (rp *repository) UpdateOrganizer(ctx context.Context, organizerID int64, update model.UpdateOrganizer) error {
+	updateQuery := rp.builder.
+		Update(organizerTable).
+		Set("name", update.Name).
+		Set("description", update.Description).
+		Set("logo_url", update.LogoURL).
+		Set("website", update.Website).
+		Set("updated_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"organizer_id": organizerID})
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to update organizer")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get affected rows")
+	}
+	if num == 0 {
+		return errors.New("No rows were updated")
+	}
+	return nil
+}
+
+func (rp *repository) GetMembers(ctx context.Context, organizerID int64) ([]model.Member, error) {
+	selectQuery := rp.builder.
+		Select("organizer_id", "user_id", "role", "added_at").
+		From(memberTable).
+		Where(sq.Eq{"organizer_id": organizerID}).
+		OrderBy("added_at ASC")
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var members []model.Member
+	if err := rp.db.SelectContext(ctx, &members, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch organizer members")
+	}
+	return members, nil
+}
+
+// GetMemberRole returns the user's role in the organizer, "" when the user is not a member
+func (rp *repository) GetMemberRole(ctx context.Context, organizerID int64, userID uuid.UUID) (string, error) {
+	selectQuery := rp.builder.
+		Select("role").
+		From(memberTable).
+		Where(sq.Eq{"organizer_id": organizerID, "user_id": userID})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var role string
+	err = rp.db.GetContext(ctx, &role, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return "", errors.Wrap(err, "Failed to fetch member role")
+	}
+	return role, nil
+}
+
+// SetMember adds the user to the organizer or changes their role
+func (rp *repository) SetMember(ctx context.Context, organizerID int64, userID uuid.UUID, role string) error {
+	upsertQuery := rp.builder.
+		Insert(memberTable).
+		Columns("organizer_id", "user_id", "role").
+		Values(organizerID, userID, role).
+		Suffix("ON CONFLICT (organizer_id, user_id) DO UPDATE SET role = EXCLUDED.role")
+
+	query, args, err := upsertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := rp.db.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to set organizer member")
+	}
+	return nil
+}
+
+func (rp *repository) DeleteMember(ctx context.Context, organizerID int64, userID uuid.UUID) error {
+	deleteQuery := rp.builder.
+		Delete(memberTable).
+		Where(sq.Eq{"organizer_id": organizerID, "user_id": userID})
+
+	query, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := rp.db.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to remove organizer member")
+	}
+	return nil
+}
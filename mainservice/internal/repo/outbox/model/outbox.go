@@ -0,0 +1,100 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Channel is notified whenever messages are enqueued, waking the dispatchers
+const Channel = "outbox_messages"
+
+// message statuses, must stay in sync with the CHECK constraint on outbox_messages.status
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead" // gave up after too many attempts, waits for a replay
+)
+
+// Consumers scans a TEXT[] column selected as JSON (to_jsonb(handled))
+type Consumers []string
+
+func (c *Consumers) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*c = Consumers{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for consumers: %T", src)
+	}
+
+	var consumers []string
+	if err := json.Unmarshal(data, &consumers); err != nil {
+		return err
+	}
+	*c = consumers
+	return nil
+}
+
+// topics, the payload of the booking topics is a change model BookingChange,
+// the one of the event topics an EventPayload, the reminder's a reminder model
+// Reminder and the digest's a saved search model Digest
+const (
+	TopicBookingCreated       = "booking.created"
+	TopicBookingStatusChanged = "booking.status_changed"
+	TopicEventCreated         = "event.created"
+	TopicEventUpdated         = "event.updated"
+	TopicEventCancelled       = "event.cancelled"
+	TopicEventPublished       = "event.published" // an existing event became public, restored by moderation or made public by its owner
+	TopicEventReminder        = "event.reminder"
+	TopicSearchDigest         = "search.digest"
+)
+
+type Message struct {
+	MessageID     int64           `json:"message_id" db:"message_id"` // BIGSERIAL Primary Key, doubles as the idempotency key
+	Topic         string          `json:"topic" db:"topic"`           // VARCHAR(64) NOT NULL
+	Payload       json.RawMessage `json:"payload" db:"payload"`       // JSONB NOT NULL
+	Status        string          `json:"status" db:"status"`         // "pending", "delivered", "dead"
+	Attempts      int             `json:"attempts" db:"attempts"`     // deliveries started so far
+	Handled       Consumers       `json:"handled" db:"handled"`       // consumers done with the message
+	LastError     *string         `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"` // when a pending message is due
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// EventPayload is the event as of the change, it may be gone by the time the message is handled
+type EventPayload struct {
+	EventID     int64     `json:"event_id"`
+	SeriesID    *int64    `json:"series_id,omitempty"`
+	OrganizerID *int64    `json:"organizer_id,omitempty"`
+	Name        string    `json:"name"`
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"`
+}
+
+type GetMessagesQueryParams struct {
+	Status        string    `form:"status"` // any status when empty
+	Topic         string    `form:"topic"`
+	BeforeCreated time.Time `form:"-"`
+	BeforeID      int64     `form:"-"`
+	Limit         int       `form:"limit"`
+}
+
+type MessagePage struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// ReplayMessages sends messages back to the dispatcher as if they were new
+type ReplayMessages struct {
+	AdminID uuid.UUID `json:"admin_id"`
+	Topic   string    `json:"topic"` // bulk replays dead messages of every topic when empty
+}
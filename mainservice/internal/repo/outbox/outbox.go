@@ -0,0 +1,339 @@
+// Package outbox stores side effects in the same transaction as the domain
+// change that causes them. The dispatcher delivers them after the commit and
+// keeps retrying until they succeed, so a crash in between loses nothing;
+// the price is that a message may be delivered more than once.
+package outbox
+
+import (
+	"context"
+	dbsql "database/sql"
+	"encoding/json"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	outboxTable = "outbox_messages"
+)
+
+const messageColumns = `message_id, topic, payload, status, attempts, to_jsonb(handled) AS handled,
+	last_error, next_attempt_at, created_at, delivered_at`
+
+// Enqueue writes the message on the transaction and wakes the dispatchers once it commits
+func Enqueue(ctx context.Context, tx sqlx.ExecerContext, topic string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode outbox message")
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		WITH message AS (
+			INSERT INTO outbox_messages (topic, payload)
+			VALUES ($1, $2::jsonb)
+			RETURNING message_id
+		)
+		SELECT pg_notify($3, message_id::text) FROM message;
+	`, topic, string(data), model.Channel)
+	if err != nil {
+		return errors.Wrap(err, "Failed to enqueue outbox message")
+	}
+	return nil
+}
+
+// EnqueueEvents writes an EventPayload message for each of the events, built
+// from their current rows. Deletions have to enqueue before deleting.
+func EnqueueEvents(ctx context.Context, tx sqlx.ExecerContext, topic string, eventIDs ...int64) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		WITH message AS (
+			INSERT INTO outbox_messages (topic, payload)
+			SELECT $1, jsonb_build_object(
+				'event_id', event_id,
+				'series_id', series_id,
+				'organizer_id', organizer_id,
+				'name', name,
+				'start_date', start_date,
+				'end_date', end_date
+			)
+			FROM event
+			WHERE event_id = ANY($2::bigint[])
+			RETURNING message_id
+		)
+		SELECT pg_notify($3, message_id::text) FROM message;
+	`, topic, eventIDs, model.Channel)
+	if err != nil {
+		return errors.Wrap(err, "Failed to enqueue event messages")
+	}
+	return nil
+}
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// ClaimDue takes up to limit due messages for delivery. Claiming counts an
+// attempt and leases the message by pushing next_attempt_at past the lease, so
+// other dispatchers skip it and a dispatcher that dies mid-delivery only delays it.
+func (rp *repository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.Message, error) {
+	query := `
+		UPDATE outbox_messages
+		SET
+			attempts = attempts + 1,
+			next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE message_id IN (
+			SELECT message_id
+			FROM outbox_messages
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at, message_id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + messageColumns + `;
+	`
+
+	var messages []model.Message
+	if err := rp.db.SelectContext(ctx, &messages, query, limit, lease.Seconds()); err != nil {
+		rp.lg.Error("Failed to claim outbox messages", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return messages, nil
+}
+
+// RenewLease extends the lease of a claimed message before a consumer runs. It
+// reports false when the lease already ran out, another dispatcher may have
+// claimed the message by then and the caller has to leave it alone.
+func (rp *repository) RenewLease(ctx context.Context, messageID int64, attempt int, lease time.Duration) (bool, error) {
+	updateQuery := rp.builder.
+		Update(outboxTable).
+		Set("next_attempt_at", sq.Expr("NOW() + make_interval(secs => ?)", lease.Seconds())).
+		Where(sq.Eq{"message_id": messageID, "attempts": attempt, "status": model.StatusPending}).
+		Where("next_attempt_at > NOW()")
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return false, errors.Wrap(err, "Failed to renew outbox lease")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to get affected rows")
+	}
+	return num > 0, nil
+}
+
+// MarkDelivered finishes the message. The attempt has to match the claim, so
+// a dispatcher whose lease ran out can't overwrite a newer attempt.
+func (rp *repository) MarkDelivered(ctx context.Context, messageID int64, attempt int) error {
+	updateQuery := rp.builder.
+		Update(outboxTable).
+		Set("status", model.StatusDelivered).
+		Set("last_error", nil).
+		Set("delivered_at", sq.Expr("NOW()")).
+		Where(sq.Eq{"message_id": messageID, "attempts": attempt, "status": model.StatusPending})
+
+	return rp.finishAttempt(ctx, updateQuery)
+}
+
+// MarkHandled records that the consumer is done with the message, so retries skip it
+func (rp *repository) MarkHandled(ctx context.Context, messageID int64, attempt int, consumer string) error {
+	updateQuery := rp.builder.
+		Update(outboxTable).
+		Set("handled", sq.Expr("array_append(handled, ?)", consumer)).
+		Where(sq.Eq{"message_id": messageID, "attempts": attempt, "status": model.StatusPending})
+
+	return rp.finishAttempt(ctx, updateQuery)
+}
+
+// Retry records the failed attempt and schedules the next one
+func (rp *repository) Retry(ctx context.Context, messageID int64, attempt int, lastError string, at time.Time) error {
+	updateQuery := rp.builder.
+		Update(outboxTable).
+		Set("last_error", lastError).
+		Set("next_attempt_at", at).
+		Where(sq.Eq{"message_id": messageID, "attempts": attempt, "status": model.StatusPending})
+
+	return rp.finishAttempt(ctx, updateQuery)
+}
+
+// DeadLetter records the failed attempt and stops retrying the message
+func (rp *repository) DeadLetter(ctx context.Context, messageID int64, attempt int, lastError string) error {
+	updateQuery := rp.builder.
+		Update(outboxTable).
+		Set("status", model.StatusDead).
+		Set("last_error", lastError).
+		Where(sq.Eq{"message_id": messageID, "attempts": attempt, "status": model.StatusPending})
+
+	return rp.finishAttempt(ctx, updateQuery)
+}
+
+func (rp *repository) finishAttempt(ctx context.Context, updateQuery sq.UpdateBuilder) error {
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to update outbox message")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get affected rows")
+	}
+	if num == 0 {
+		return errors.New("Outbox message was claimed again or replayed")
+	}
+	return nil
+}
+
+// GetMessages returns the messages newest first, before the keyset cursor when set
+func (rp *repository) GetMessages(ctx context.Context, params model.GetMessagesQueryParams) ([]model.Message, error) {
+	selectQuery := rp.builder.
+		Select(messageColumns).
+		From(outboxTable)
+	if params.Status != "" {
+		selectQuery = selectQuery.Where(sq.Eq{"status": params.Status})
+	}
+	if params.Topic != "" {
+		selectQuery = selectQuery.Where(sq.Eq{"topic": params.Topic})
+	}
+	if params.BeforeID != 0 {
+		selectQuery = selectQuery.Where("(created_at, message_id) < (?, ?)", params.BeforeCreated, params.BeforeID)
+	}
+	selectQuery = selectQuery.
+		OrderBy("created_at DESC", "message_id DESC").
+		Limit(uint64(params.Limit))
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var messages []model.Message
+	if err := rp.db.SelectContext(ctx, &messages, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch outbox messages")
+	}
+	return messages, nil
+}
+
+func (rp *repository) GetMessageById(ctx context.Context, messageID int64) (*model.Message, error) {
+	selectQuery := rp.builder.
+		Select(messageColumns).
+		From(outboxTable).
+		Where(sq.Eq{"message_id": messageID})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var message model.Message
+	err = rp.db.GetContext(ctx, &message, query, args...)
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return nil, errors.New("No outbox message found with the given ID")
+	}
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch outbox message")
+	}
+	return &message, nil
+}
+
+// Replay makes an undelivered message due now with a fresh attempt budget,
+// consumers that already handled it are still skipped
+func (rp *repository) Replay(ctx context.Context, messageID int64) error {
+	num, err := rp.replay(ctx, sq.Eq{"message_id": messageID})
+	if err != nil {
+		return err
+	}
+	if num == 0 {
+		return errors.New("No undelivered outbox message found with the given ID")
+	}
+	return nil
+}
+
+// ReplayDead replays every dead message, of the topic when given
+func (rp *repository) ReplayDead(ctx context.Context, topic string) (int64, error) {
+	where := sq.Eq{"status": model.StatusDead}
+	if topic != "" {
+		where["topic"] = topic
+	}
+	return rp.replay(ctx, where)
+}
+
+func (rp *repository) replay(ctx context.Context, where sq.Eq) (int64, error) {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	updateQuery := rp.builder.
+		Update(outboxTable).
+		Set("status", model.StatusPending).
+		Set("attempts", 0).
+		Set("next_attempt_at", sq.Expr("NOW()")).
+		Where(where).
+		Where(sq.NotEq{"status": model.StatusDelivered})
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to replay outbox messages")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to get affected rows")
+	}
+	if num == 0 {
+		return 0, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_notify($1, '')", model.Channel); err != nil {
+		return 0, errors.Wrap(err, "Failed to wake dispatchers")
+	}
+	return num, errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+// DeleteDeliveredBefore prunes delivered messages, dead ones are kept until replayed
+func (rp *repository) DeleteDeliveredBefore(ctx context.Context, before time.Time) (int64, error) {
+	deleteQuery := rp.builder.
+		Delete(outboxTable).
+		Where(sq.Eq{"status": model.StatusDelivered}).
+		Where(sq.Lt{"delivered_at": before})
+
+	query, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to delete outbox messages")
+	}
+	return result.RowsAffected()
+}
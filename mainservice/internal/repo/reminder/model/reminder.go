@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reminder is due for a confirmed booking, it is the payload of the outbox reminder topic
+type Reminder struct {
+	BookingID    int64     `json:"booking_id" db:"booking_id"`
+	UserID       uuid.UUID `json:"user_id" db:"user_id"`
+	EventID      int64     `json:"event_id" db:"event_id"`
+	Name         string    `json:"name" db:"name"`
+	StartDate    time.Time `json:"start_date" db:"start_date"`
+	RemindBefore int       `json:"remind_before" db:"remind_before"` // seconds before start_date
+}
@@ -0,0 +1,104 @@
+package reminder
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/outbox"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/reminder/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	reminderTable = "booking_reminders"
+)
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// EnqueueDue records a reminder for every confirmed booking of a visible event
+// starting in (notBefore, remindBefore] from now and hands it to the outbox in
+// the same transaction. A reminder is recorded once per booking, offset and
+// start date, so reruns and concurrent runs never enqueue it twice.
+func (rp *repository) EnqueueDue(ctx context.Context, remindBefore, notBefore time.Duration) (int, error) {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	var reminders []model.Reminder
+	err = tx.SelectContext(ctx, &reminders, `
+		WITH due AS (
+			INSERT INTO booking_reminders (booking_id, remind_before, start_date)
+			SELECT b.booking_id, $1::int, e.start_date
+			FROM bookings b
+			JOIN event e ON e.event_id = b.event_id
+			WHERE b.booking_status = 'confirmed'
+				AND NOT e.hidden
+				AND e.start_date > NOW() + make_interval(secs => $2::int)
+				AND e.start_date <= NOW() + make_interval(secs => $1::int)
+			ON CONFLICT DO NOTHING
+			RETURNING booking_id, remind_before, start_date
+		)
+		SELECT
+			b.booking_id,
+			b.user_id,
+			e.event_id,
+			e.name,
+			due.start_date,
+			due.remind_before
+		FROM due
+		JOIN bookings b ON b.booking_id = due.booking_id
+		JOIN event e ON e.event_id = b.event_id AND e.start_date = due.start_date;
+	`, int(remindBefore.Seconds()), int(notBefore.Seconds()))
+	if err != nil {
+		rp.lg.Error("Failed to record due reminders", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	for _, reminder := range reminders {
+		if err := outbox.Enqueue(ctx, tx, outboxModel.TopicEventReminder, reminder); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit transaction")
+	}
+	return len(reminders), nil
+}
+
+// DeleteBefore prunes reminders of events that started before the given time
+func (rp *repository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	deleteQuery := rp.builder.
+		Delete(reminderTable).
+		Where(sq.Lt{"start_date": before})
+
+	query, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to delete reminders")
+	}
+	return result.RowsAffected()
+}
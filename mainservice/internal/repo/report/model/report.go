@@ -0,0 +1,82 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// report targets, must stay in sync with the CHECK constraint on reports.target_type
+const (
+	TargetEvent   = "event"
+	TargetComment = "comment"
+	TargetUser    = "user"
+)
+
+// report statuses
+const (
+	StatusOpen      = "open"
+	StatusResolved  = "resolved"  // the content was hidden
+	StatusDismissed = "dismissed" // no action was needed
+)
+
+var Reasons = []string{"spam", "inappropriate", "misleading", "harassment", "other"}
+
+type Report struct {
+	ReportID       int64      `json:"report_id" db:"report_id"`               // BIGSERIAL Primary Key
+	TargetType     string     `json:"target_type" db:"target_type"`           // "event", "comment", "user"
+	TargetID       string     `json:"target_id" db:"target_id"`               // id of the target as text
+	ReporterID     *uuid.UUID `json:"reporter_id,omitempty" db:"reporter_id"` // UUID (Nullable, FK to users)
+	Reason         string     `json:"reason" db:"reason"`                     // one of Reasons
+	Details        string     `json:"details" db:"details"`                   // TEXT NOT NULL DEFAULT ''
+	Status         string     `json:"status" db:"status"`                     // "open", "resolved", "dismissed"
+	AssignedTo     *uuid.UUID `json:"assigned_to,omitempty" db:"assigned_to"` // moderator working the report
+	ResolvedBy     *uuid.UUID `json:"resolved_by,omitempty" db:"resolved_by"` // moderator who closed the report
+	ResolutionNote string     `json:"resolution_note,omitempty" db:"resolution_note"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`                   // TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`       // TIMESTAMP WITH TIME ZONE (Nullable)
+	TargetReports  int        `json:"target_reports,omitempty" db:"target_reports"` // open reports on the same target, queue only
+}
+
+type CreateReport struct {
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	ReporterID uuid.UUID `json:"user_id"`
+	Reason     string    `json:"reason"`
+	Details    string    `json:"details"`
+}
+
+type AssignReport struct {
+	ModeratorID uuid.UUID  `json:"moderator_id"`
+	AssigneeID  *uuid.UUID `json:"assignee_id"` // nil unassigns the report
+}
+
+// ResolveReport closes every open report on the target of the report
+type ResolveReport struct {
+	ModeratorID uuid.UUID `json:"moderator_id"`
+	Hide        bool      `json:"hide"` // hide the content, otherwise the reports are dismissed
+	Note        string    `json:"note"`
+}
+
+// SetContentHidden hides or restores content directly, without a report
+type SetContentHidden struct {
+	ModeratorID uuid.UUID `json:"moderator_id"`
+	Hidden      bool      `json:"hidden"`
+}
+
+// GetQueueQueryParams pages through reports oldest first,
+// the page starts after (AfterCreated, AfterID)
+type GetQueueQueryParams struct {
+	Status       string     `form:"status"` // defaults to "open"
+	TargetType   string     `form:"target_type"`
+	AssignedTo   *uuid.UUID `form:"-"`
+	Unassigned   bool       `form:"unassigned"`
+	AfterCreated time.Time  `form:"-"`
+	AfterID      int64      `form:"-"`
+	Limit        int        `form:"limit"`
+}
+
+type QueuePage struct {
+	Reports    []Report `json:"reports"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
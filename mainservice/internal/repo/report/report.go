@@ -0,0 +1,264 @@
+package report
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/change"
+	changeModel "github.com/quietguido/mapnu/mainservice/internal/repo/change/model"
+	commentModel "github.com/quietguido/mapnu/mainservice/internal/repo/comment/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/report/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	reportTable = "reports"
+)
+
+// reportColumns is the select list for model.Report, the table must be aliased as "r"
+const reportColumns = `
+			r.report_id,
+			r.target_type,
+			r.target_id,
+			r.reporter_id,
+			r.reason,
+			r.details,
+			r.status,
+			r.assigned_to,
+			r.resolved_by,
+			r.resolution_note,
+			r.created_at,
+			r.resolved_at`
+
+var ErrAlreadyReported = errors.New("Target was already reported by the user")
+
+// FileScreeningReport puts an event held by screening into the moderation queue,
+// run in the transaction creating the event so a held event always has a report
+func FileScreeningReport(ctx context.Context, tx sqlx.ExecerContext, eventID int64, details string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO reports (target_type, target_id, reason, details)
+		VALUES ($1, $2::bigint::text, 'spam', $3);
+	`, model.TargetEvent, eventID, details)
+	if err != nil {
+		return errors.Wrap(err, "Failed to file screening report")
+	}
+	return nil
+}
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// CreateReport files the report, a user can have only one open report per target
+func (rp *repository) CreateReport(ctx context.Context, createReport model.CreateReport) (int64, error) {
+	// reports filed by automated screening have no reporter
+	var reporterID any = createReport.ReporterID
+	if createReport.ReporterID == uuid.Nil {
+		reporterID = nil
+	}
+
+	insertQuery := rp.builder.
+		Insert(reportTable).Columns(
+		"target_type",
+		"target_id",
+		"reporter_id",
+		"reason",
+		"details",
+	).Values(
+		createReport.TargetType,
+		createReport.TargetID,
+		reporterID,
+		createReport.Reason,
+		createReport.Details,
+	).Suffix("ON CONFLICT (reporter_id, target_type, target_id) WHERE status = 'open' DO NOTHING RETURNING report_id")
+
+	query, args, err := insertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var reportID int64
+	err = rp.db.QueryRowxContext(ctx, query, args...).Scan(&reportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrAlreadyReported
+	}
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to create report")
+	}
+	return reportID, nil
+}
+
+func (rp *repository) GetReportById(ctx context.Context, reportID int64) (*model.Report, error) {
+	selectQuery := rp.builder.
+		Select(reportColumns).
+		From(reportTable + " r").
+		Where(sq.Eq{"r.report_id": reportID})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var report model.Report
+	if err := rp.db.GetContext(ctx, &report, query, args...); err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch report")
+	}
+	return &report, nil
+}
+
+// GetQueue returns reports oldest first, each with the number of open reports on its target
+func (rp *repository) GetQueue(ctx context.Context, params model.GetQueueQueryParams) ([]model.Report, error) {
+	selectQuery := rp.builder.
+		Select(
+			reportColumns,
+			`(SELECT COUNT(*) FROM reports o
+				WHERE o.target_type = r.target_type AND o.target_id = r.target_id AND o.status = 'open'
+			) AS target_reports`,
+		).
+		From(reportTable+" r").
+		Where(sq.Eq{"r.status": params.Status}).
+		Where("(r.created_at, r.report_id) > (?, ?)", params.AfterCreated, params.AfterID)
+	if params.TargetType != "" {
+		selectQuery = selectQuery.Where(sq.Eq{"r.target_type": params.TargetType})
+	}
+	if params.AssignedTo != nil {
+		selectQuery = selectQuery.Where(sq.Eq{"r.assigned_to": *params.AssignedTo})
+	} else if params.Unassigned {
+		selectQuery = selectQuery.Where(sq.Eq{"r.assigned_to": nil})
+	}
+	selectQuery = selectQuery.
+		OrderBy("r.created_at ASC", "r.report_id ASC").
+		Limit(uint64(params.Limit))
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var reports []model.Report
+	if err := rp.db.SelectContext(ctx, &reports, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch reports")
+	}
+	return reports, nil
+}
+
+// AssignReport hands an open report to a moderator, a nil assignee unassigns it
+func (rp *repository) AssignReport(ctx context.Context, reportID int64, assignee *uuid.UUID) error {
+	updateQuery := rp.builder.
+		Update(reportTable).
+		Set("assigned_to", assignee).
+		Where(sq.Eq{"report_id": reportID, "status": model.StatusOpen})
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to assign report")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get affected rows")
+	}
+	if num == 0 {
+		return errors.New("No open report found with the given ID")
+	}
+	return nil
+}
+
+// ResolveTarget closes all open reports on the target with status, hiding the target first
+// when hide is set. Both happen in one transaction, so hidden content never keeps open
+// reports and closed reports never leave the content visible.
+func (rp *repository) ResolveTarget(ctx context.Context, targetType, targetID string, hide bool, status string, moderatorID uuid.UUID, note string) (int64, error) {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	if hide {
+		if err := hideTarget(ctx, tx, targetType, targetID); err != nil {
+			return 0, err
+		}
+	}
+
+	updateQuery := rp.builder.
+		Update(reportTable).
+		Set("status", status).
+		Set("resolved_by", moderatorID).
+		Set("resolution_note", note).
+		Set("resolved_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"target_type": targetType, "target_id": targetID, "status": model.StatusOpen})
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to close reports")
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to get affected rows")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit transaction")
+	}
+	return num, nil
+}
+
+// hideTarget hides reported content like the event, comment and user repositories do
+func hideTarget(ctx context.Context, tx *sqlx.Tx, targetType, targetID string) error {
+	var query string
+	switch targetType {
+	case model.TargetEvent:
+		query = `UPDATE event SET hidden = TRUE WHERE event_id = $1::bigint;`
+	case model.TargetComment:
+		query = `UPDATE event_comments SET status = '` + commentModel.StatusHidden + `'
+			WHERE comment_id = $1::bigint AND status <> '` + commentModel.StatusDeleted + `';`
+	case model.TargetUser:
+		query = `UPDATE users SET hidden = TRUE WHERE id = $1::uuid;`
+	default:
+		return errors.New("Unknown report target")
+	}
+
+	result, err := tx.ExecContext(ctx, query, targetID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to hide reported content")
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get affected rows")
+	}
+	if num == 0 {
+		return errors.New("Reported content no longer exists")
+	}
+
+	if targetType == model.TargetEvent {
+		eventID, err := strconv.ParseInt(targetID, 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "Invalid event ID")
+		}
+		// the live map drops the event like on any other hide
+		return change.EmitEvents(ctx, tx, changeModel.EventHidden, eventID)
+	}
+	return nil
+}
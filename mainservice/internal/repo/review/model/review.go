@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+type Review struct {
+	ReviewID  int64      `json:"review_id" db:"review_id"`             // BIGSERIAL Primary Key
+	BookingID int64      `json:"booking_id" db:"booking_id"`           // INTEGER NOT NULL UNIQUE (FK to bookings)
+	EventID   int64      `json:"event_id" db:"event_id"`               // BIGINT NOT NULL
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`                 // UUID (FK to users)
+	Rating    int        `json:"rating" db:"rating"`                   // SMALLINT 1-5
+	Body      string     `json:"body" db:"body"`                       // TEXT NOT NULL DEFAULT ''
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`           // TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"` // TIMESTAMP WITH TIME ZONE (Nullable)
+}
+
+type CreateReview struct {
+	BookingID int64     `json:"booking_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Rating    int       `json:"rating"`
+	Body      string    `json:"body"`
+	EventID   int64     `json:"-"` // taken from the booking
+}
+
+type UpdateReview struct {
+	UserID uuid.UUID `json:"user_id"`
+	Rating int       `json:"rating"`
+	Body   string    `json:"body"`
+}
+
+// GetReviewsQueryParams pages through reviews of an event newest first,
+// the page starts after (BeforeCreated, BeforeID)
+type GetReviewsQueryParams struct {
+	EventID       int64
+	BeforeCreated time.Time
+	BeforeID      int64
+	Limit         int
+}
+
+type ReviewPage struct {
+	Reviews    []Review `json:"reviews"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
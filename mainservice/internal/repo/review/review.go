@@ -0,0 +1,207 @@
+package review
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/review/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	reviewTable = "event_reviews"
+)
+
+var ErrAlreadyReviewed = errors.New("Booking was already reviewed")
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// CreateReview stores the review and adds it to the event's and its creator's rating
+// in the same transaction. A booking can only be reviewed once.
+func (rp *repository) CreateReview(ctx context.Context, createReview model.CreateReview) (int64, error) {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	insertQuery := rp.builder.
+		Insert(reviewTable).Columns(
+		"booking_id",
+		"event_id",
+		"user_id",
+		"rating",
+		"body",
+	).Values(
+		createReview.BookingID,
+		createReview.EventID,
+		createReview.UserID,
+		createReview.Rating,
+		createReview.Body,
+	).Suffix("ON CONFLICT (booking_id) DO NOTHING RETURNING review_id")
+
+	query, args, err := insertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var reviewID int64
+	err = tx.QueryRowxContext(ctx, query, args...).Scan(&reviewID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrAlreadyReviewed
+	}
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to create review")
+	}
+
+	if err := rp.applyRating(ctx, tx, createReview.EventID, 1, createReview.Rating); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit transaction")
+	}
+	return reviewID, nil
+}
+
+func (rp *repository) GetReviewById(ctx context.Context, reviewID int64) (*model.Review, error) {
+	selectQuery := rp.builder.
+		Select("review_id", "booking_id", "event_id", "user_id", "rating", "body", "created_at", "updated_at").
+		From(reviewTable).
+		Where(sq.Eq{"review_id": reviewID})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var review model.Review
+	if err := rp.db.GetContext(ctx, &review, query, args...); err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch review")
+	}
+	return &review, nil
+}
+
+// GetReviewsForEvent returns reviews of the event newest first
+func (rp *repository) GetReviewsForEvent(ctx context.Context, params model.GetReviewsQueryParams) ([]model.Review, error) {
+	selectQuery := rp.builder.
+		Select("review_id", "booking_id", "event_id", "user_id", "rating", "body", "created_at", "updated_at").
+		From(reviewTable).
+		Where(sq.Eq{"event_id": params.EventID})
+	if params.BeforeID != 0 {
+		selectQuery = selectQuery.Where("(created_at, review_id) < (?, ?)", params.BeforeCreated, params.BeforeID)
+	}
+	selectQuery = selectQuery.
+		OrderBy("created_at DESC", "review_id DESC").
+		Limit(uint64(params.Limit))
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var reviews []model.Review
+	if err := rp.db.SelectContext(ctx, &reviews, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch reviews")
+	}
+	return reviews, nil
+}
+
+// UpdateReview changes the review and moves the rating aggregates by the difference
+func (rp *repository) UpdateReview(ctx context.Context, reviewID int64, update model.UpdateReview) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	var prev model.Review
+	err = tx.GetContext(ctx, &prev, `
+		SELECT event_id, rating FROM event_reviews WHERE review_id = $1 FOR UPDATE;
+	`, reviewID)
+	if err != nil {
+		rp.lg.Error("Failed to fetch previous review", zap.Error(err))
+		return errors.Wrap(err, "Failed to fetch review")
+	}
+
+	updateQuery := rp.builder.
+		Update(reviewTable).
+		Set("rating", update.Rating).
+		Set("body", update.Body).
+		Set("updated_at", sq.Expr("CURRENT_TIMESTAMP")).
+		Where(sq.Eq{"review_id": reviewID})
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to update review")
+	}
+
+	if err := rp.applyRating(ctx, tx, prev.EventID, 0, update.Rating-prev.Rating); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+// DeleteReview removes the review and takes it out of the rating aggregates
+func (rp *repository) DeleteReview(ctx context.Context, reviewID int64) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	var prev model.Review
+	err = tx.GetContext(ctx, &prev, `
+		DELETE FROM event_reviews WHERE review_id = $1 RETURNING event_id, rating;
+	`, reviewID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return tx.Commit()
+	}
+	if err != nil {
+		rp.lg.Error("Failed to delete review", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	if err := rp.applyRating(ctx, tx, prev.EventID, -1, -prev.Rating); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+// applyRating moves the rating aggregates of the event and of the user who created it
+func (rp *repository) applyRating(ctx context.Context, tx *sqlx.Tx, eventID int64, count, sum int) error {
+	_, err := tx.ExecContext(ctx, `
+		WITH e AS (
+			UPDATE event
+			SET rating_count = rating_count + $1, rating_sum = rating_sum + $2
+			WHERE event_id = $3
+			RETURNING created_by
+		)
+		UPDATE users
+		SET rating_count = rating_count + $1, rating_sum = rating_sum + $2
+		WHERE id IN (SELECT created_by FROM e);
+	`, count, sum, eventID)
+	if err != nil {
+		rp.lg.Error("Failed to update rating aggregates", zap.Error(err))
+		return errors.Wrap(err, "Failed to update rating aggregates")
+	}
+	return nil
+}
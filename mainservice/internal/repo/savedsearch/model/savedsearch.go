@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SavedSearch alerts its user about new events matching all of the criteria
+// that are set. The area is a circle around the location, the date window is
+// either fixed (DateFrom, DateTo) or rolling (WithinDays), or both.
+type SavedSearch struct {
+	SearchID     int64      `json:"search_id" db:"search_id"` // BIGSERIAL Primary Key
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	Name         string     `json:"name" db:"name"`                           // VARCHAR(255) NOT NULL
+	Query        string     `json:"query" db:"query"`                         // matched against name and description, any when empty
+	Category     *string    `json:"category,omitempty" db:"category"`         // any when NULL
+	Location_lat *float64   `json:"location_lat,omitempty" db:"location_lat"` // center of the area, anywhere when NULL
+	Location_lon *float64   `json:"location_lon,omitempty" db:"location_lon"`
+	RadiusM      *int       `json:"radius_m,omitempty" db:"radius_m"`       // set together with the location
+	DateFrom     *time.Time `json:"date_from,omitempty" db:"date_from"`     // events still running at
+	DateTo       *time.Time `json:"date_to,omitempty" db:"date_to"`         // events starting before
+	WithinDays   *int       `json:"within_days,omitempty" db:"within_days"` // events starting within this many days of being matched
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// SaveSearch creates a saved search or replaces its criteria
+type SaveSearch struct {
+	UserID       uuid.UUID  `json:"user_id"` // change for token
+	Name         string     `json:"name"`
+	Query        string     `json:"query"`
+	Category     *string    `json:"category,omitempty"`
+	Location_lat *float64   `json:"location_lat,omitempty"`
+	Location_lon *float64   `json:"location_lon,omitempty"`
+	RadiusM      *int       `json:"radius_m,omitempty"`
+	DateFrom     *time.Time `json:"date_from,omitempty"`
+	DateTo       *time.Time `json:"date_to,omitempty"`
+	WithinDays   *int       `json:"within_days,omitempty"`
+}
+
+// Digest batches a user's new matches, it is the payload of the outbox digest topic
+type Digest struct {
+	UserID uuid.UUID     `json:"user_id"`
+	Events []DigestEvent `json:"events"` // soonest first, one per series
+	More   int           `json:"more"`   // matches left out of Events
+}
+
+type DigestEvent struct {
+	EventID    int64     `json:"event_id" db:"event_id"`
+	Name       string    `json:"name" db:"name"`
+	StartDate  time.Time `json:"start_date" db:"start_date"`
+	SearchName string    `json:"search_name" db:"search_name"` // the saved search it matched first
+}
@@ -0,0 +1,308 @@
+package savedsearch
+
+import (
+	"context"
+	dbsql "database/sql"
+	"slices"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/outbox"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	"github.com/quietguido/mapnu/mainservice/internal/repo/savedsearch/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	searchTable = "saved_searches"
+	matchTable  = "saved_search_matches"
+)
+
+const searchColumns = `search_id, user_id, name, query, category, ST_Y(location) AS location_lat,
+	ST_X(location) AS location_lon, radius_m, date_from, date_to, within_days, created_at, updated_at`
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (rp *repository) CreateSearch(ctx context.Context, save model.SaveSearch) (int64, error) {
+	insertQuery := rp.builder.
+		Insert(searchTable).
+		Columns("user_id", "name", "query", "category", "location", "radius_m", "date_from", "date_to", "within_days").
+		Values(save.UserID, save.Name, save.Query, save.Category, location(save), save.RadiusM, save.DateFrom, save.DateTo, save.WithinDays).
+		Suffix("RETURNING search_id")
+
+	query, args, err := insertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var searchID int64
+	if err := rp.db.QueryRowContext(ctx, query, args...).Scan(&searchID); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to create saved search")
+	}
+	return searchID, nil
+}
+
+func (rp *repository) GetSearchById(ctx context.Context, searchID int64) (*model.SavedSearch, error) {
+	selectQuery := rp.builder.
+		Select(searchColumns).
+		From(searchTable).
+		Where(sq.Eq{"search_id": searchID})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var search model.SavedSearch
+	err = rp.db.GetContext(ctx, &search, query, args...)
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return nil, errors.New("No saved search found with the given ID")
+	}
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch saved search")
+	}
+	return &search, nil
+}
+
+func (rp *repository) GetSearches(ctx context.Context, userID uuid.UUID) ([]model.SavedSearch, error) {
+	selectQuery := rp.builder.
+		Select(searchColumns).
+		From(searchTable).
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("search_id")
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var searches []model.SavedSearch
+	if err := rp.db.SelectContext(ctx, &searches, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch saved searches")
+	}
+	return searches, nil
+}
+
+func (rp *repository) CountSearches(ctx context.Context, userID uuid.UUID) (int, error) {
+	selectQuery := rp.builder.
+		Select("COUNT(*)").
+		From(searchTable).
+		Where(sq.Eq{"user_id": userID})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var count int
+	if err := rp.db.GetContext(ctx, &count, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to count saved searches")
+	}
+	return count, nil
+}
+
+// UpdateSearch replaces the criteria, matches found so far are kept
+func (rp *repository) UpdateSearch(ctx context.Context, searchID int64, save model.SaveSearch) error {
+	updateQuery := rp.builder.
+		Update(searchTable).
+		Set("name", save.Name).
+		Set("query", save.Query).
+		Set("category", save.Category).
+		Set("location", location(save)).
+		Set("radius_m", save.RadiusM).
+		Set("date_from", save.DateFrom).
+		Set("date_to", save.DateTo).
+		Set("within_days", save.WithinDays).
+		Set("updated_at", sq.Expr("NOW()")).
+		Where(sq.Eq{"search_id": searchID})
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := rp.db.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to update saved search")
+	}
+	return nil
+}
+
+// DeleteSearch removes the search, its pending matches are dropped from the next digest
+func (rp *repository) DeleteSearch(ctx context.Context, searchID int64) error {
+	deleteQuery := rp.builder.
+		Delete(searchTable).
+		Where(sq.Eq{"search_id": searchID})
+
+	query, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := rp.db.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to delete saved search")
+	}
+	return nil
+}
+
+// MatchEvent records the event as a match of every saved search it satisfies.
+// Events held by moderation, already over or created by the searching user
+// don't match. Matching an event again adds nothing.
+func (rp *repository) MatchEvent(ctx context.Context, eventID int64) (int64, error) {
+	result, err := rp.db.ExecContext(ctx, `
+		INSERT INTO saved_search_matches (search_id, event_id, user_id)
+		SELECT s.search_id, e.event_id, s.user_id
+		FROM event e
+		JOIN saved_searches s ON
+			(s.category IS NULL OR s.category = e.category)
+			AND (s.location IS NULL OR ST_DWithin(s.location::geography, e.location::geography, s.radius_m))
+			AND (s.date_from IS NULL OR COALESCE(e.end_date, e.start_date) >= s.date_from)
+			AND (s.date_to IS NULL OR e.start_date < s.date_to)
+			AND (s.within_days IS NULL OR e.start_date < NOW() + make_interval(days => s.within_days))
+		CROSS JOIN LATERAL (
+			SELECT '%' || replace(replace(replace(s.query, '\', '\\'), '%', '\%'), '_', '\_') || '%' AS pattern
+		) p
+		WHERE e.event_id = $1
+			AND NOT e.hidden
+			AND NOT EXISTS (SELECT 1 FROM users hu WHERE hu.id = e.created_by AND hu.hidden)
+			AND e.visibility = 'public'
+			AND COALESCE(e.end_date, e.start_date) >= NOW()
+			AND e.created_by IS DISTINCT FROM s.user_id
+			AND (s.query = '' OR e.name ILIKE p.pattern OR e.description ILIKE p.pattern)
+		ON CONFLICT DO NOTHING;
+	`, eventID)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to match saved searches")
+	}
+	return result.RowsAffected()
+}
+
+// GetDueDigestUsers returns users with pending matches whose local clock passed
+// digestHour today, unless they already got today's digest
+func (rp *repository) GetDueDigestUsers(ctx context.Context, digestHour, limit int) ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	err := rp.db.SelectContext(ctx, &userIDs, `
+		SELECT u.id
+		FROM users u
+		CROSS JOIN LATERAL (
+			SELECT (date_trunc('day', NOW() AT TIME ZONE u.timezone) + make_interval(hours => $1))
+				AT TIME ZONE u.timezone AS digest_at
+		) today
+		LEFT JOIN saved_search_digests d ON d.user_id = u.id
+		WHERE EXISTS (
+				SELECT 1 FROM saved_search_matches m
+				WHERE m.user_id = u.id AND m.digested_at IS NULL
+			)
+			AND NOW() >= today.digest_at
+			AND (d.last_sent_at IS NULL OR d.last_sent_at < today.digest_at)
+		LIMIT $2;
+	`, digestHour, limit)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch due digests")
+	}
+	return userIDs, nil
+}
+
+// EnqueueDigest takes the user's pending matches and hands them to the outbox
+// as one digest of at most maxEvents events, in the same transaction. Events
+// that are gone, hidden or over by now are dropped, and a series counts once.
+// It reports whether a digest was enqueued.
+func (rp *repository) EnqueueDigest(ctx context.Context, userID uuid.UUID, maxEvents int) (bool, error) {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	var events []model.DigestEvent
+	err = tx.SelectContext(ctx, &events, `
+		WITH taken AS (
+			UPDATE saved_search_matches
+			SET digested_at = NOW()
+			WHERE user_id = $1 AND digested_at IS NULL
+			RETURNING search_id, event_id
+		)
+		SELECT DISTINCT ON (COALESCE(e.series_id, -e.event_id))
+			e.event_id,
+			e.name,
+			e.start_date,
+			s.name AS search_name
+		FROM taken
+		JOIN event e ON e.event_id = taken.event_id
+		JOIN saved_searches s ON s.search_id = taken.search_id
+		WHERE NOT e.hidden AND e.visibility = 'public' AND COALESCE(e.end_date, e.start_date) >= NOW()
+			AND NOT EXISTS (SELECT 1 FROM users hu WHERE hu.id = e.created_by AND hu.hidden)
+		ORDER BY COALESCE(e.series_id, -e.event_id), e.start_date, s.search_id;
+	`, userID)
+	if err != nil {
+		rp.lg.Error("Failed to take saved search matches", zap.Error(err))
+		return false, errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	if len(events) > 0 {
+		slices.SortFunc(events, func(a, b model.DigestEvent) int {
+			return a.StartDate.Compare(b.StartDate)
+		})
+		digest := model.Digest{UserID: userID, Events: events}
+		if len(events) > maxEvents {
+			digest.Events = events[:maxEvents]
+			digest.More = len(events) - maxEvents
+		}
+
+		if err := outbox.Enqueue(ctx, tx, outboxModel.TopicSearchDigest, digest); err != nil {
+			return false, err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO saved_search_digests (user_id, last_sent_at)
+			VALUES ($1, NOW())
+			ON CONFLICT (user_id) DO UPDATE SET last_sent_at = EXCLUDED.last_sent_at;
+		`, userID)
+		if err != nil {
+			rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+			return false, errors.Wrap(err, "Failed to record digest")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, errors.Wrap(err, "Failed to commit transaction")
+	}
+	return len(events) > 0, nil
+}
+
+// DeleteDigestedBefore prunes matches sent in digests before the given time
+func (rp *repository) DeleteDigestedBefore(ctx context.Context, before time.Time) (int64, error) {
+	deleteQuery := rp.builder.
+		Delete(matchTable).
+		Where(sq.Lt{"digested_at": before})
+
+	query, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to delete saved search matches")
+	}
+	return result.RowsAffected()
+}
+
+// location is the center of the search's area, NULL without one
+func location(save model.SaveSearch) any {
+	if save.Location_lat == nil || save.Location_lon == nil {
+		return nil
+	}
+	return sq.Expr("ST_SetSRID(ST_Point(?, ?), 4326)", *save.Location_lon, *save.Location_lat)
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Channel is notified with the user id whenever events are appended for the user
+const Channel = "stream_events"
+
+// stream event types, sent as the SSE event name
+const (
+	TypeBookingStatus      = "booking.status_changed" // to the attendee
+	TypeBookingApplication = "booking.application"    // to the organizers of the event
+	TypeEventCancelled     = "event.cancelled"        // to the attendees of the event
+)
+
+type StreamEvent struct {
+	StreamEventID int64           `json:"stream_event_id" db:"stream_event_id"` // BIGSERIAL Primary Key
+	UserID        uuid.UUID       `json:"user_id" db:"user_id"`                 // UUID NOT NULL, the recipient
+	Seq           int64           `json:"seq" db:"seq"`                         // BIGINT NOT NULL, per user in commit order, the SSE id
+	Type          string          `json:"type" db:"type"`                       // VARCHAR(64) NOT NULL
+	Payload       json.RawMessage `json:"payload" db:"payload"`                 // JSONB NOT NULL
+	MessageID     *int64          `json:"-" db:"message_id"`                    // BIGINT, the outbox message it was appended for
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+}
+
+// BookingPayload is sent with the booking event types
+type BookingPayload struct {
+	BookingID int64     `json:"booking_id"`
+	EventID   int64     `json:"event_id"`
+	EventName string    `json:"event_name"`
+	UserID    uuid.UUID `json:"user_id"` // the attendee
+	Status    string    `json:"booking_status"`
+}
+
+// EventPayload is sent with TypeEventCancelled
+type EventPayload struct {
+	EventID   int64     `json:"event_id"`
+	Name      string    `json:"name"`
+	StartDate time.Time `json:"start_date"`
+}
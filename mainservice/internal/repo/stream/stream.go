@@ -0,0 +1,132 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/stream/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	streamEventTable = "stream_events"
+)
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// AppendEvents logs the event for every user and notifies their streams. The
+// notifications go out on commit, so a woken stream always finds its events.
+// messageID is the outbox message the event is appended for, appending it again
+// for the same user is a no-op so redelivered messages aren't streamed twice.
+//
+// Every event gets the next sequence number of its user. The user's counter row
+// stays locked until commit, so a user's events become visible in seq order and
+// a stream resuming after a seq never skips one committed later.
+func (rp *repository) AppendEvents(ctx context.Context, userIDs []uuid.UUID, eventType string, payload any, messageID int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode stream event")
+	}
+
+	ids := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		ids = append(ids, id.String())
+	}
+
+	_, err = rp.db.ExecContext(ctx, `
+		WITH sequenced AS (
+			INSERT INTO stream_sequences (user_id, last_seq)
+			SELECT DISTINCT u, 1
+			FROM unnest($1::uuid[]) AS u
+			ORDER BY u
+			ON CONFLICT (user_id) DO UPDATE SET last_seq = stream_sequences.last_seq + 1
+			RETURNING user_id, last_seq
+		), appended AS (
+			INSERT INTO stream_events (user_id, seq, type, payload, message_id)
+			SELECT user_id, last_seq, $2, $3::jsonb, $4::bigint
+			FROM sequenced
+			ON CONFLICT (user_id, message_id) DO NOTHING
+			RETURNING user_id
+		)
+		SELECT pg_notify($5, user_id::text) FROM appended;
+	`, ids, eventType, string(data), messageID, model.Channel)
+	if err != nil {
+		rp.lg.Error("Failed to append stream events", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return nil
+}
+
+// GetEventsAfter returns the user's events following afterSeq, oldest first
+func (rp *repository) GetEventsAfter(ctx context.Context, userID uuid.UUID, afterSeq int64, limit int) ([]model.StreamEvent, error) {
+	selectQuery := rp.builder.
+		Select("stream_event_id", "user_id", "seq", "type", "payload", "created_at").
+		From(streamEventTable).
+		Where(sq.Eq{"user_id": userID}).
+		Where(sq.Gt{"seq": afterSeq}).
+		OrderBy("seq").
+		Limit(uint64(limit))
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var events []model.StreamEvent
+	if err := rp.db.SelectContext(ctx, &events, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch stream events")
+	}
+	return events, nil
+}
+
+// GetLatestSeq returns the seq of the user's newest event, 0 without events
+func (rp *repository) GetLatestSeq(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var latest int64
+	err := rp.db.GetContext(ctx, &latest, `
+		SELECT COALESCE(MAX(seq), 0) FROM stream_events WHERE user_id = $1;
+	`, userID)
+	if err != nil {
+		rp.lg.Error("Failed to fetch latest stream event", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return latest, nil
+}
+
+// DeleteEventsBefore prunes the log, streams can't resume past the pruned events
+func (rp *repository) DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	deleteQuery := rp.builder.
+		Delete(streamEventTable).
+		Where(sq.Lt{"created_at": before})
+
+	query, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to prune stream events")
+	}
+	return result.RowsAffected()
+}
@@ -6,6 +6,16 @@ import (
 	"github.com/google/uuid"
 )
 
+// user roles, must stay in sync with the CHECK constraint on users.role
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Locales notifications can be rendered in, each needs its templates in services/notification
+var Locales = []string{"en", "ru"}
+
 type CreateUser struct {
 	Username string `json:"username" db:"username"`
 	Email    string `json:"email" db:"email"`
@@ -18,6 +28,29 @@ type User struct {
 	Email    string    `json:"email" db:"email"`
 	// Password  string    `json:"password" db:"password"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	Role      string    `json:"role" db:"role"`
+	Hidden    bool      `json:"-" db:"hidden"`          // hidden by moderation
+	Locale    string    `json:"locale" db:"locale"`     // one of Locales, defaults to "en"
+	Timezone  string    `json:"timezone" db:"timezone"` // IANA name, defaults to "UTC"
+	// reviews of the events the user created
+	RatingCount int      `json:"rating_count" db:"rating_count"`
+	Rating      *float64 `json:"rating,omitempty" db:"rating"`
+}
+
+type UpdateSettings struct {
+	UserID   uuid.UUID `json:"user_id"` // change for token
+	Locale   string    `json:"locale"`
+	Timezone string    `json:"timezone"`
+}
+
+// IsModerator reports whether the user may work the moderation queue
+func (u *User) IsModerator() bool {
+	return u.Role == RoleModerator || u.Role == RoleAdmin
+}
+
+// IsAdmin reports whether the user may operate the service, e.g. replay the outbox
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
 }
 
 // -- Create users table
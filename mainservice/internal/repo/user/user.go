@@ -64,6 +64,12 @@ func (rp *repository) GetUserById(ctx context.Context, userId string) (*model.Us
 			"username",
 			"email",
 			"created_at",
+			"rating_count",
+			"ROUND(rating_sum::numeric / NULLIF(rating_count, 0), 2)::float8 AS rating",
+			"role",
+			"hidden",
+			"locale",
+			"timezone",
 		).
 		From("users").
 		Where(sq.Eq{"id": userId})
@@ -81,3 +87,86 @@ func (rp *repository) GetUserById(ctx context.Context, userId string) (*model.Us
 
 	return &userModel, nil
 }
+
+// GetUsersByUsernames fetches the users with the given usernames, unknown names are skipped
+func (rp *repository) GetUsersByUsernames(ctx context.Context, usernames []string) ([]model.User, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	getQuery := rp.builder.
+		Select(
+			"id",
+			"username",
+			"email",
+			"created_at",
+		).
+		From("users").
+		Where(sq.Eq{"username": usernames})
+
+	sql, args, err := getQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var users []model.User
+	err = rp.db.SelectContext(ctx, &users, sql, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch users")
+	}
+
+	return users, nil
+}
+
+// SetUserHidden hides the user's profile or restores it, used by moderation. Event and
+// comment queries check the flag of the author, so the user's content goes with the profile.
+func (rp *repository) SetUserHidden(ctx context.Context, userId string, hidden bool) error {
+	updateQuery := rp.builder.
+		Update(userTable).
+		Set("hidden", hidden).
+		Where(sq.Eq{"id": userId})
+
+	sql, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to update user")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get affected rows")
+	}
+	if rowsAffected == 0 {
+		return errors.New("No user found with the given ID")
+	}
+	return nil
+}
+
+// UpdateSettings sets the locale and timezone the user is notified in
+func (rp *repository) UpdateSettings(ctx context.Context, settings model.UpdateSettings) error {
+	updateQuery := rp.builder.
+		Update(userTable).
+		Set("locale", settings.Locale).
+		Set("timezone", settings.Timezone).
+		Where(sq.Eq{"id": settings.UserID})
+
+	sql, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to update user")
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get affected rows")
+	}
+	if rowsAffected == 0 {
+		return errors.New("No user found with the given ID")
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+)
+
+type Venue struct {
+	VenueID       int64         `json:"venue_id" db:"venue_id"`               // BIGSERIAL Primary Key
+	Name          string        `json:"name" db:"name"`                       // VARCHAR(255) NOT NULL
+	Address       string        `json:"address" db:"address"`                 // TEXT NOT NULL DEFAULT ''
+	CreatedBy     *uuid.UUID    `json:"created_by,omitempty" db:"created_by"` // UUID (Nullable, FK to users)
+	Location_lat  float64       `json:"location_lat" db:"location_lat"`       // For PostGIS geometry data
+	Location_lon  float64       `json:"location_lon" db:"location_lon"`       // For PostGIS geometry data
+	Area          Geometry      `json:"area,omitempty" db:"area"`             // GeoJSON Polygon (Nullable)
+	Capacity      *int          `json:"capacity,omitempty" db:"capacity"`     // INTEGER (Nullable)
+	Accessibility Accessibility `json:"accessibility" db:"accessibility"`     // JSONB NOT NULL DEFAULT '{}'
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`           // TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+}
+
+// VenueWithEvents is a venue together with its next events
+type VenueWithEvents struct {
+	Venue
+	UpcomingEvents []eventModel.Event `json:"upcoming_events"`
+}
+
+type CreateVenue struct {
+	Name          string        `json:"name"`
+	Address       string        `json:"address"`
+	CreatedBy     *uuid.UUID    `json:"created_by,omitempty"`
+	Location_lat  float64       `json:"location_lat"` // optional when area is set, defaults to a point inside it
+	Location_lon  float64       `json:"location_lon"`
+	Area          Geometry      `json:"area,omitempty"` // GeoJSON Polygon
+	Capacity      *int          `json:"capacity,omitempty"`
+	Accessibility Accessibility `json:"accessibility"`
+}
+
+type GetVenuesMapQueryParams struct {
+	FirstQuadLon  float64
+	FirstQuadLat  float64
+	SecondQuadLon float64
+	SecondQuadLat float64
+	From          time.Time // upcoming events are the ones still running at From
+	EventsLimit   int       // upcoming events per venue
+}
+
+type Accessibility struct {
+	Wheelchair        bool   `json:"wheelchair"`
+	StepFreeEntrance  bool   `json:"step_free_entrance"`
+	AccessibleToilets bool   `json:"accessible_toilets"`
+	HearingLoop       bool   `json:"hearing_loop"`
+	Notes             string `json:"notes,omitempty"`
+}
+
+func (a *Accessibility) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*a = Accessibility{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for accessibility: %T", src)
+	}
+	return json.Unmarshal(data, a)
+}
+
+func (a Accessibility) Value() (driver.Value, error) {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Geometry is a GeoJSON geometry, scanned from ST_AsGeoJSON
+type Geometry json.RawMessage
+
+func (g *Geometry) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*g = nil
+	case []byte:
+		*g = append(Geometry(nil), v...)
+	case string:
+		*g = Geometry(v)
+	default:
+		return fmt.Errorf("unsupported type for geometry: %T", src)
+	}
+	return nil
+}
+
+func (g Geometry) MarshalJSON() ([]byte, error) {
+	if len(g) == 0 {
+		return []byte("null"), nil
+	}
+	return g, nil
+}
+
+func (g *Geometry) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*g = nil
+		return nil
+	}
+	*g = append(Geometry(nil), data...)
+	return nil
+}
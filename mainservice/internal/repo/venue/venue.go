@@ -0,0 +1,125 @@
+package venue
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/venue/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	venueTable = "venues"
+)
+
+// venueColumns is the select list for model.Venue, the venues table must be aliased as "v"
+const venueColumns = `
+			v.venue_id,
+			v.name,
+			v.address,
+			v.created_by,
+			ST_X(v.location) AS location_lon,
+			ST_Y(v.location) AS location_lat,
+			ST_AsGeoJSON(v.area) AS area,
+			v.capacity,
+			v.accessibility,
+			v.created_at`
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (rp *repository) CreateVenue(ctx context.Context, createVenue model.CreateVenue) (int64, error) {
+	var area, location any
+	if len(createVenue.Area) > 0 {
+		area = sq.Expr("ST_SetSRID(ST_GeomFromGeoJSON(?), 4326)", string(createVenue.Area))
+	}
+	if createVenue.Location_lat == 0 && createVenue.Location_lon == 0 && area != nil {
+		location = sq.Expr("ST_PointOnSurface(ST_SetSRID(ST_GeomFromGeoJSON(?), 4326))", string(createVenue.Area))
+	} else {
+		location = sq.Expr("ST_SetSRID(ST_Point(?, ?), 4326)", createVenue.Location_lon, createVenue.Location_lat)
+	}
+
+	insertQuery := rp.builder.
+		Insert(venueTable).Columns(
+		"name",
+		"address",
+		"created_by",
+		"location",
+		"area",
+		"capacity",
+		"accessibility",
+	).Values(
+		createVenue.Name,
+		createVenue.Address,
+		createVenue.CreatedBy,
+		location,
+		area,
+		createVenue.Capacity,
+		createVenue.Accessibility,
+	).Suffix("RETURNING venue_id")
+
+	sql, args, err := insertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var venueID int64
+	if err := rp.db.QueryRowxContext(ctx, sql, args...).Scan(&venueID); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to create venue")
+	}
+	return venueID, nil
+}
+
+func (rp *repository) GetVenueById(ctx context.Context, venueID int64) (*model.Venue, error) {
+	selectQuery := rp.builder.
+		Select(venueColumns).
+		From(venueTable + " v").
+		Where(sq.Eq{"v.venue_id": venueID})
+
+	sql, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var venue model.Venue
+	if err := rp.db.GetContext(ctx, &venue, sql, args...); err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch venue")
+	}
+	return &venue, nil
+}
+
+func (rp *repository) GetVenuesForQuadrant(ctx context.Context, params model.GetVenuesMapQueryParams) ([]model.Venue, error) {
+	selectQuery := rp.builder.
+		Select(venueColumns).
+		From(venueTable+" v").
+		Where(
+			"ST_Within(v.location, ST_SetSRID(ST_MakeEnvelope(?, ?, ?, ?, 4326), 4326))",
+			params.FirstQuadLon,
+			params.FirstQuadLat,
+			params.SecondQuadLon,
+			params.SecondQuadLat,
+		).
+		OrderBy("v.venue_id")
+
+	sql, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var venues []model.Venue
+	if err := rp.db.SelectContext(ctx, &venues, sql, args...); err != nil {
+		rp.lg.Error("Failed to execute GetVenuesForQuadrant query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return venues, nil
+}
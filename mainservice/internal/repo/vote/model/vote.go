@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Vote struct {
+	UserID  uuid.UUID `json:"user_id" db:"user_id"`
+	EventID int64     `json:"event_id" db:"event_id"`
+	Value   int       `json:"value" db:"value"` // 1 for upvote, -1 for downvote
+	VotedAt time.Time `json:"voted_at" db:"voted_at"`
+}
+
+type SetVote struct {
+	UserID  uuid.UUID `json:"user_id" db:"user_id"`
+	EventID int64     `json:"event_id" db:"event_id"`
+	Vote    string    `json:"vote" db:"vote"` // "up", "down"
+}
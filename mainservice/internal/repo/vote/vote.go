@@ -0,0 +1,215 @@
+package vote
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/change"
+	changeModel "github.com/quietguido/mapnu/mainservice/internal/repo/change/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	voteTable  = "event_votes"
+	eventTable = "event"
+)
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// SetVote creates or replaces the user's vote and keeps event.upvote/downvote in sync
+// within a single transaction.
+func (rp *repository) SetVote(ctx context.Context, userID uuid.UUID, eventID int64, value int) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	if err := rp.lockEvent(ctx, tx, eventID); err != nil {
+		return err
+	}
+
+	var prev int
+	err = tx.GetContext(ctx, &prev, `
+		SELECT value FROM event_votes WHERE user_id = $1 AND event_id = $2;
+	`, userID, eventID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		rp.lg.Error("Failed to fetch previous vote", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+	if prev == value {
+		return tx.Commit()
+	}
+
+	upsertQuery := rp.builder.
+		Insert(voteTable).Columns(
+		"user_id",
+		"event_id",
+		"value",
+	).Values(
+		userID,
+		eventID,
+		value,
+	).Suffix("ON CONFLICT (user_id, event_id) DO UPDATE SET value = EXCLUDED.value, voted_at = CURRENT_TIMESTAMP")
+
+	query, args, err := upsertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Warn(query)
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	if err := rp.applyCounters(ctx, tx, eventID, prev, value); err != nil {
+		return err
+	}
+	if err := change.EmitEvents(ctx, tx, changeModel.EventVotes, eventID); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+// DeleteVote removes the user's vote if there is one. Deleting a missing vote is a no-op.
+func (rp *repository) DeleteVote(ctx context.Context, userID uuid.UUID, eventID int64) error {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	if err := rp.lockEvent(ctx, tx, eventID); err != nil {
+		return err
+	}
+
+	var prev int
+	err = tx.GetContext(ctx, &prev, `
+		DELETE FROM event_votes WHERE user_id = $1 AND event_id = $2 RETURNING value;
+	`, userID, eventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return tx.Commit()
+	}
+	if err != nil {
+		rp.lg.Error("Failed to delete vote", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+
+	if err := rp.applyCounters(ctx, tx, eventID, prev, 0); err != nil {
+		return err
+	}
+	if err := change.EmitEvents(ctx, tx, changeModel.EventVotes, eventID); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+// GetUserVotes returns the user's votes for the given events keyed by event_id.
+func (rp *repository) GetUserVotes(ctx context.Context, userID uuid.UUID, eventIDs []int64) (map[int64]int, error) {
+	votes := make(map[int64]int, len(eventIDs))
+	if len(eventIDs) == 0 {
+		return votes, nil
+	}
+
+	selectQuery := rp.builder.
+		Select("event_id", "value").
+		From(voteTable).
+		Where(sq.Eq{"user_id": userID, "event_id": eventIDs})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	rows, err := rp.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute GetUserVotes query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			eventID int64
+			value   int
+		)
+		if err := rows.Scan(&eventID, &value); err != nil {
+			rp.lg.Error("Failed to scan row", zap.Error(err))
+			return nil, errors.Wrap(err, "Failed to scan row")
+		}
+		votes[eventID] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		rp.lg.Error("Row iteration error", zap.Error(err))
+		return nil, errors.Wrap(err, "Row iteration error")
+	}
+
+	return votes, nil
+}
+
+// lockEvent takes a row lock on the event so concurrent votes on it are serialized.
+func (rp *repository) lockEvent(ctx context.Context, tx *sqlx.Tx, eventID int64) error {
+	var id int64
+	err := tx.GetContext(ctx, &id, `SELECT event_id FROM event WHERE event_id = $1 FOR UPDATE;`, eventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("No event found with the given ID")
+	}
+	if err != nil {
+		rp.lg.Error("Failed to lock event", zap.Error(err))
+		return errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return nil
+}
+
+func (rp *repository) applyCounters(ctx context.Context, tx *sqlx.Tx, eventID int64, prev, next int) error {
+	up, down := counterDelta(prev, next)
+
+	updateQuery := rp.builder.
+		Update(eventTable).
+		Set("upvote", sq.Expr("upvote + ?", up)).
+		Set("downvote", sq.Expr("downvote + ?", down)).
+		Where(sq.Eq{"event_id": eventID})
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to update vote counters", zap.Error(err))
+		return errors.Wrap(err, "Failed to update vote counters")
+	}
+	return nil
+}
+
+// counterDelta converts a vote transition (values in -1, 0, 1) into upvote/downvote increments.
+func counterDelta(prev, next int) (up, down int) {
+	switch prev {
+	case 1:
+		up--
+	case -1:
+		down--
+	}
+	switch next {
+	case 1:
+		up++
+	case -1:
+		down++
+	}
+	return up, down
+}
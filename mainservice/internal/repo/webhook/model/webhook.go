@@ -0,0 +1,142 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+)
+
+// event types organizers can subscribe to, named after the outbox topics they
+// are sent for and carrying the same payload
+var SubscribableEvents = []string{
+	outboxModel.TopicBookingCreated,
+	outboxModel.TopicBookingStatusChanged,
+	outboxModel.TopicEventUpdated,
+	outboxModel.TopicEventCancelled,
+}
+
+// EventTest is only sent by the test endpoint, to every webhook regardless of its event types
+const EventTest = "webhook.test"
+
+// delivery statuses, must stay in sync with the CHECK constraint on webhook_deliveries.status
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed" // gave up after too many attempts
+)
+
+// EventTypes scans a TEXT[] column selected as JSON (to_jsonb(event_types))
+type EventTypes []string
+
+func (e *EventTypes) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*e = EventTypes{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for event types: %T", src)
+	}
+
+	var eventTypes []string
+	if err := json.Unmarshal(data, &eventTypes); err != nil {
+		return err
+	}
+	*e = eventTypes
+	return nil
+}
+
+type Webhook struct {
+	WebhookID           int64      `json:"webhook_id" db:"webhook_id"`     // BIGSERIAL Primary Key
+	OrganizerID         int64      `json:"organizer_id" db:"organizer_id"` // BIGINT NOT NULL, FK to organizers
+	URL                 string     `json:"url" db:"url"`
+	EventTypes          EventTypes `json:"event_types" db:"event_types"` // subset of SubscribableEvents
+	Secret              string     `json:"-" db:"secret"`                // only shown on creation
+	Active              bool       `json:"active" db:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures" db:"consecutive_failures"` // failed attempts since the last success
+	DisabledAt          *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	DisabledReason      *string    `json:"disabled_reason,omitempty" db:"disabled_reason"`
+	CreatedBy           *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CreatedWebhook is the creation response, the one time the secret is handed out
+type CreatedWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+type CreateWebhook struct {
+	UserID     uuid.UUID `json:"user_id"` // change for token
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+}
+
+// UpdateWebhook replaces the settings, activating a disabled webhook resets its failures
+type UpdateWebhook struct {
+	UserID     uuid.UUID `json:"user_id"` // change for token
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+}
+
+type TestWebhook struct {
+	UserID uuid.UUID `json:"user_id"` // change for token
+}
+
+type Delivery struct {
+	DeliveryID     int64           `json:"delivery_id" db:"delivery_id"` // BIGSERIAL Primary Key, sent as DeliveryHeader
+	WebhookID      int64           `json:"webhook_id" db:"webhook_id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`   // the data of the request body
+	Status         string          `json:"status" db:"status"`     // "pending", "succeeded", "failed"
+	Attempts       int             `json:"attempts" db:"attempts"` // requests started so far
+	ResponseStatus *int            `json:"response_status,omitempty" db:"response_status"`
+	ResponseBody   *string         `json:"response_body,omitempty" db:"response_body"` // truncated
+	Error          *string         `json:"error,omitempty" db:"error"`
+	DurationMs     *int            `json:"duration_ms,omitempty" db:"duration_ms"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" db:"next_attempt_at"` // when a pending delivery is due
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty" db:"last_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+}
+
+// Attempt is a claimed delivery with the endpoint to send it to
+type Attempt struct {
+	Delivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+// AttemptResult is the outcome of one request. Status stays pending when the
+// delivery is retried at NextAttemptAt.
+type AttemptResult struct {
+	DeliveryID     int64
+	WebhookID      int64
+	Attempt        int
+	Status         string
+	ResponseStatus *int
+	ResponseBody   *string
+	Error          *string
+	Duration       time.Duration
+	NextAttemptAt  time.Time
+}
+
+type GetDeliveriesQueryParams struct {
+	BeforeCreated time.Time
+	BeforeID      int64
+	Limit         int
+}
+
+type DeliveryPage struct {
+	Deliveries []Delivery `json:"deliveries"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
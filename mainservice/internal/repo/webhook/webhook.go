@@ -0,0 +1,385 @@
+package webhook
+
+import (
+	"context"
+	dbsql "database/sql"
+	"encoding/json"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo/webhook/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+const (
+	webhookTable  = "webhooks"
+	deliveryTable = "webhook_deliveries"
+)
+
+const webhookColumns = `webhook_id, organizer_id, url, to_jsonb(event_types) AS event_types, secret, active,
+	consecutive_failures, disabled_at, disabled_reason, created_by, created_at, updated_at`
+
+const deliveryColumns = `delivery_id, webhook_id, event_type, payload, status, attempts, response_status,
+	response_body, error, duration_ms, next_attempt_at, last_attempt_at, created_at`
+
+type repository struct {
+	lg      *zap.Logger
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRepository(lg *zap.Logger, db *sqlx.DB) *repository {
+	return &repository{
+		lg:      lg,
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (rp *repository) CreateWebhook(ctx context.Context, organizerID int64, createWebhook model.CreateWebhook, secret string) (int64, error) {
+	insertQuery := rp.builder.
+		Insert(webhookTable).
+		Columns("organizer_id", "url", "event_types", "secret", "created_by").
+		Values(organizerID, createWebhook.URL, createWebhook.EventTypes, secret, createWebhook.UserID).
+		Suffix("RETURNING webhook_id")
+
+	query, args, err := insertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var webhookID int64
+	if err := rp.db.QueryRowContext(ctx, query, args...).Scan(&webhookID); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to create webhook")
+	}
+	return webhookID, nil
+}
+
+func (rp *repository) GetWebhookById(ctx context.Context, webhookID int64) (*model.Webhook, error) {
+	selectQuery := rp.builder.
+		Select(webhookColumns).
+		From(webhookTable).
+		Where(sq.Eq{"webhook_id": webhookID})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var webhook model.Webhook
+	err = rp.db.GetContext(ctx, &webhook, query, args...)
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return nil, errors.New("No webhook found with the given ID")
+	}
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch webhook")
+	}
+	return &webhook, nil
+}
+
+func (rp *repository) GetWebhooks(ctx context.Context, organizerID int64) ([]model.Webhook, error) {
+	selectQuery := rp.builder.
+		Select(webhookColumns).
+		From(webhookTable).
+		Where(sq.Eq{"organizer_id": organizerID}).
+		OrderBy("webhook_id")
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var webhooks []model.Webhook
+	if err := rp.db.SelectContext(ctx, &webhooks, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch webhooks")
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook replaces the settings. Activating clears the failure streak and
+// the reason it was disabled, deactivating records why.
+func (rp *repository) UpdateWebhook(ctx context.Context, webhookID int64, update model.UpdateWebhook) error {
+	updateQuery := rp.builder.
+		Update(webhookTable).
+		Set("url", update.URL).
+		Set("event_types", update.EventTypes).
+		Set("updated_at", sq.Expr("NOW()"))
+	if update.Active {
+		updateQuery = updateQuery.
+			Set("active", true).
+			Set("consecutive_failures", 0).
+			Set("disabled_at", nil).
+			Set("disabled_reason", nil)
+	} else {
+		updateQuery = updateQuery.
+			Set("disabled_at", sq.Expr("CASE WHEN active THEN NOW() ELSE disabled_at END")).
+			Set("disabled_reason", sq.Expr("CASE WHEN active THEN ? ELSE disabled_reason END", "Disabled by a member")).
+			Set("active", false)
+	}
+	updateQuery = updateQuery.Where(sq.Eq{"webhook_id": webhookID})
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := rp.db.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to update webhook")
+	}
+	return nil
+}
+
+// DeleteWebhook removes the webhook together with its delivery history
+func (rp *repository) DeleteWebhook(ctx context.Context, webhookID int64) error {
+	deleteQuery := rp.builder.
+		Delete(webhookTable).
+		Where(sq.Eq{"webhook_id": webhookID})
+
+	query, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := rp.db.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return errors.Wrap(err, "Failed to delete webhook")
+	}
+	return nil
+}
+
+// EnqueueDeliveries creates a delivery for every active webhook of the organizer
+// subscribed to the event type. Deliveries are keyed by the source message, so
+// enqueueing it again adds nothing.
+func (rp *repository) EnqueueDeliveries(ctx context.Context, organizerID int64, eventType string, payload any, sourceID int64) (int64, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to encode webhook payload")
+	}
+
+	result, err := rp.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload, source_id)
+		SELECT webhook_id, $2, $3::jsonb, $4
+		FROM webhooks
+		WHERE organizer_id = $1 AND active AND $2 = ANY(event_types)
+		ON CONFLICT (webhook_id, source_id) DO NOTHING;
+	`, organizerID, eventType, string(data), sourceID)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to enqueue webhook deliveries")
+	}
+	return result.RowsAffected()
+}
+
+// CreateClaimedDelivery stores a delivery that is sent right away by the caller,
+// claimed with its first attempt and leased so the dispatchers leave it alone
+func (rp *repository) CreateClaimedDelivery(ctx context.Context, webhookID int64, eventType string, payload any, lease time.Duration) (*model.Delivery, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode webhook payload")
+	}
+
+	insertQuery := rp.builder.
+		Insert(deliveryTable).
+		Columns("webhook_id", "event_type", "payload", "attempts", "next_attempt_at").
+		Values(webhookID, eventType, sq.Expr("?::jsonb", string(data)), 1, sq.Expr("NOW() + make_interval(secs => ?)", lease.Seconds())).
+		Suffix("RETURNING " + deliveryColumns)
+
+	query, args, err := insertQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var delivery model.Delivery
+	if err := rp.db.GetContext(ctx, &delivery, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to create webhook delivery")
+	}
+	return &delivery, nil
+}
+
+// ClaimDue takes up to limit due deliveries of active webhooks. Like the outbox,
+// claiming counts an attempt and leases the delivery past the lease, a
+// dispatcher that dies mid-request only delays it.
+func (rp *repository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.Attempt, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE webhook_deliveries
+			SET
+				attempts = attempts + 1,
+				next_attempt_at = NOW() + make_interval(secs => $2)
+			WHERE delivery_id IN (
+				SELECT d.delivery_id
+				FROM webhook_deliveries d
+				JOIN webhooks w ON w.webhook_id = d.webhook_id
+				WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND w.active
+				ORDER BY d.next_attempt_at, d.delivery_id
+				LIMIT $1
+				FOR UPDATE OF d SKIP LOCKED
+			)
+			RETURNING ` + deliveryColumns + `
+		)
+		SELECT claimed.*, w.url, w.secret
+		FROM claimed
+		JOIN webhooks w ON w.webhook_id = claimed.webhook_id;
+	`
+
+	var attempts []model.Attempt
+	if err := rp.db.SelectContext(ctx, &attempts, query, limit, lease.Seconds()); err != nil {
+		rp.lg.Error("Failed to claim webhook deliveries", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to execute SQL query")
+	}
+	return attempts, nil
+}
+
+// RecordAttempt stores the outcome of the attempt, which has to match the claim.
+// With disableAfter set it also tracks the webhook's failure streak and disables
+// it once the streak reaches disableAfter, reporting whether it just did.
+func (rp *repository) RecordAttempt(ctx context.Context, result model.AttemptResult, disableAfter int) (bool, error) {
+	tx, err := rp.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	updateQuery := rp.builder.
+		Update(deliveryTable).
+		Set("status", result.Status).
+		Set("response_status", result.ResponseStatus).
+		Set("response_body", result.ResponseBody).
+		Set("error", result.Error).
+		Set("duration_ms", result.Duration.Milliseconds()).
+		Set("last_attempt_at", sq.Expr("NOW()")).
+		Set("next_attempt_at", result.NextAttemptAt).
+		Where(sq.Eq{"delivery_id": result.DeliveryID, "attempts": result.Attempt, "status": model.StatusPending})
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	updated, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return false, errors.Wrap(err, "Failed to update webhook delivery")
+	}
+	num, err := updated.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to get affected rows")
+	}
+	if num == 0 {
+		return false, errors.New("Webhook delivery was claimed again")
+	}
+
+	disabled := false
+	if disableAfter > 0 {
+		disabled, err = rp.trackFailures(ctx, tx, result, disableAfter)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return disabled, errors.Wrap(tx.Commit(), "Failed to commit transaction")
+}
+
+func (rp *repository) trackFailures(ctx context.Context, tx *sqlx.Tx, result model.AttemptResult, disableAfter int) (bool, error) {
+	var webhook struct {
+		Active              bool `db:"active"`
+		ConsecutiveFailures int  `db:"consecutive_failures"`
+	}
+	err := tx.GetContext(ctx, &webhook, `
+		SELECT active, consecutive_failures FROM webhooks WHERE webhook_id = $1 FOR UPDATE;
+	`, result.WebhookID)
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return false, nil // deleted meanwhile
+	}
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return false, errors.Wrap(err, "Failed to fetch webhook")
+	}
+
+	updateQuery := rp.builder.
+		Update(webhookTable).
+		Where(sq.Eq{"webhook_id": result.WebhookID})
+
+	disable := false
+	if result.Status == model.StatusSucceeded {
+		if webhook.ConsecutiveFailures == 0 {
+			return false, nil
+		}
+		updateQuery = updateQuery.Set("consecutive_failures", 0)
+	} else {
+		failures := webhook.ConsecutiveFailures + 1
+		updateQuery = updateQuery.Set("consecutive_failures", failures)
+
+		disable = webhook.Active && failures >= disableAfter
+		if disable {
+			updateQuery = updateQuery.
+				Set("active", false).
+				Set("disabled_at", sq.Expr("NOW()")).
+				Set("disabled_reason", "Disabled after repeated delivery failures")
+		}
+	}
+
+	query, args, err := updateQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return false, errors.Wrap(err, "Failed to update webhook failures")
+	}
+	return disable, nil
+}
+
+// GetDeliveries returns the webhook's deliveries newest first, before the keyset cursor when set
+func (rp *repository) GetDeliveries(ctx context.Context, webhookID int64, params model.GetDeliveriesQueryParams) ([]model.Delivery, error) {
+	selectQuery := rp.builder.
+		Select(deliveryColumns).
+		From(deliveryTable).
+		Where(sq.Eq{"webhook_id": webhookID})
+	if params.BeforeID != 0 {
+		selectQuery = selectQuery.Where("(created_at, delivery_id) < (?, ?)", params.BeforeCreated, params.BeforeID)
+	}
+	selectQuery = selectQuery.
+		OrderBy("created_at DESC", "delivery_id DESC").
+		Limit(uint64(params.Limit))
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var deliveries []model.Delivery
+	if err := rp.db.SelectContext(ctx, &deliveries, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return nil, errors.Wrap(err, "Failed to fetch webhook deliveries")
+	}
+	return deliveries, nil
+}
+
+// CountWebhooks counts the organizer's webhooks, disabled ones included
+func (rp *repository) CountWebhooks(ctx context.Context, organizerID int64) (int, error) {
+	selectQuery := rp.builder.
+		Select("COUNT(*)").
+		From(webhookTable).
+		Where(sq.Eq{"organizer_id": organizerID})
+
+	query, args, err := selectQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	var count int
+	if err := rp.db.GetContext(ctx, &count, query, args...); err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to count webhooks")
+	}
+	return count, nil
+}
+
+// DeleteFinishedBefore prunes the history, pending deliveries are kept
+func (rp *repository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	deleteQuery := rp.builder.
+		Delete(deliveryTable).
+		Where(sq.NotEq{"status": model.StatusPending}).
+		Where(sq.Lt{"created_at": before})
+
+	query, args, err := deleteQuery.ToSql()
+	assert.IsNil(err, "Failed to build SQL query")
+
+	result, err := rp.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		rp.lg.Error("Failed to execute SQL query", zap.Error(err))
+		return 0, errors.Wrap(err, "Failed to delete webhook deliveries")
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,81 @@
+package booking
+
+import (
+	"context"
+	dbsql "database/sql"
+	"encoding/json"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	changeModel "github.com/quietguido/mapnu/mainservice/internal/repo/change/model"
+	organizerModel "github.com/quietguido/mapnu/mainservice/internal/repo/organizer/model"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	streamModel "github.com/quietguido/mapnu/mainservice/internal/repo/stream/model"
+)
+
+// HandleBookingCreated streams the application to everyone who manages bookings of the event
+func (s *service) HandleBookingCreated(ctx context.Context, message outboxModel.Message) error {
+	var booking changeModel.BookingChange
+	if err := json.Unmarshal(message.Payload, &booking); err != nil {
+		return errors.Wrap(err, "Failed to decode booking")
+	}
+
+	event, err := s.eventRepo.GetEventById(ctx, int(booking.EventID))
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return nil // the event is gone, nobody is left to tell
+	}
+	if err != nil {
+		return err
+	}
+
+	var managers []uuid.UUID
+	if event.CreatedBy != nil {
+		managers = append(managers, *event.CreatedBy)
+	}
+	if event.OrganizerID != nil {
+		members, err := s.organizerRepo.GetMembers(ctx, *event.OrganizerID)
+		if err != nil {
+			return err
+		}
+		for _, member := range members {
+			if organizerModel.RoleCan(member.Role, organizerModel.PermissionManageBookings) {
+				managers = append(managers, member.UserID)
+			}
+		}
+	}
+	managers = slices.DeleteFunc(managers, func(id uuid.UUID) bool { return id == booking.UserID })
+
+	payload := streamModel.BookingPayload{
+		BookingID: booking.BookingID,
+		EventID:   event.EventID,
+		EventName: event.Name,
+		UserID:    booking.UserID,
+		Status:    booking.Status,
+	}
+	return s.streamRepo.AppendEvents(ctx, managers, streamModel.TypeBookingApplication, payload, message.MessageID)
+}
+
+// HandleBookingStatusChanged streams the new status to the attendee
+func (s *service) HandleBookingStatusChanged(ctx context.Context, message outboxModel.Message) error {
+	var booking changeModel.BookingChange
+	if err := json.Unmarshal(message.Payload, &booking); err != nil {
+		return errors.Wrap(err, "Failed to decode booking")
+	}
+
+	payload := streamModel.BookingPayload{
+		BookingID: booking.BookingID,
+		EventID:   booking.EventID,
+		UserID:    booking.UserID,
+		Status:    booking.Status,
+	}
+	event, err := s.eventRepo.GetEventById(ctx, int(booking.EventID))
+	switch {
+	case err == nil:
+		payload.EventName = event.Name
+	case !errors.Is(err, dbsql.ErrNoRows):
+		return err
+	}
+	return s.streamRepo.AppendEvents(ctx, []uuid.UUID{booking.UserID}, streamModel.TypeBookingStatus, payload, message.MessageID)
+}
@@ -9,6 +9,9 @@ import (
 	"go.uber.org/zap"
 
 	bookingModel "github.com/quietguido/mapnu/mainservice/internal/repo/booking/model"
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	organizerModel "github.com/quietguido/mapnu/mainservice/internal/repo/organizer/model"
+	eventService "github.com/quietguido/mapnu/mainservice/internal/services/event"
 )
 
 const (
@@ -18,24 +21,35 @@ const (
 )
 
 type service struct {
-	lg          *zap.Logger
-	bookingRepo repo.BookingReposity
-	eventRepo   repo.EventRepository
+	lg            *zap.Logger
+	bookingRepo   repo.BookingReposity
+	eventRepo     repo.EventRepository
+	organizerRepo repo.OrganizerRepository
+	streamRepo    repo.StreamRepository
 }
 
 func InitService(
 	lg *zap.Logger,
 	bookingRepo repo.BookingReposity,
 	eventRepo repo.EventRepository,
+	organizerRepo repo.OrganizerRepository,
+	streamRepo repo.StreamRepository,
 ) *service {
 	return &service{
-		lg:          lg,
-		bookingRepo: bookingRepo,
-		eventRepo:   eventRepo,
+		lg:            lg,
+		bookingRepo:   bookingRepo,
+		eventRepo:     eventRepo,
+		organizerRepo: organizerRepo,
+		streamRepo:    streamRepo,
 	}
 }
 
 func (s *service) Create(ctx context.Context, createBooking bookingModel.CreateBooking) (int, error) {
+	// invite links are redeemed before booking, see invite.Redeem
+	if _, err := eventService.GetVisibleEvent(ctx, s.eventRepo, createBooking.EventID, &createBooking.UserID); err != nil {
+		return 0, err
+	}
+
 	return s.bookingRepo.CreateBooking(ctx, createBooking)
 }
 
@@ -62,12 +76,15 @@ func (s *service) ChangeBookingStatus(ctx context.Context, changeBookingStatus b
 		return err
 	}
 
-	if event.CreatedBy == nil || *event.CreatedBy != changeBookingStatus.EventHolderUserId {
+	allowed, err := s.canManageBookings(ctx, event, changeBookingStatus.EventHolderUserId)
+	if err != nil {
+		return err
+	}
+	if !allowed {
 		return errors.New("Booking does not belong to user")
 	}
 
-	err = s.bookingRepo.ChangeBookingStatus(ctx, changeBookingStatus.BookingID, changeBookingStatus.BookingStatus)
-	return err
+	return s.bookingRepo.ChangeBookingStatus(ctx, changeBookingStatus.BookingID, changeBookingStatus.BookingStatus)
 }
 
 func (s *service) GetBookingApplicationsForOrganizer(ctx context.Context, userId uuid.UUID) ([]bookingModel.Booking, error) {
@@ -77,6 +94,22 @@ func (s *service) GetBookingApplicationsForOrganizer(ctx context.Context, userId
 	return nil, nil
 }
 
+// canManageBookings allows the event creator and, for organizer events, members whose role grants it
+func (s *service) canManageBookings(ctx context.Context, event *eventModel.Event, userId uuid.UUID) (bool, error) {
+	if event.CreatedBy != nil && *event.CreatedBy == userId {
+		return true, nil
+	}
+	if event.OrganizerID == nil {
+		return false, nil
+	}
+
+	role, err := s.organizerRepo.GetMemberRole(ctx, *event.OrganizerID, userId)
+	if err != nil {
+		return false, err
+	}
+	return organizerModel.RoleCan(role, organizerModel.PermissionManageBookings), nil
+}
+
 func checkBookingStatus(bookingStatus string) bool {
 	switch bookingStatus {
 	case ConfimedBookingStatus, PendingBookingStatus, RejectedBookingStatus:
@@ -0,0 +1,87 @@
+package bookmark
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	eventService "github.com/quietguido/mapnu/mainservice/internal/services/event"
+	"github.com/quietguido/mapnu/mainservice/pkg/cursor"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+type service struct {
+	lg        *zap.Logger
+	repo      repo.BookmarkRepository
+	eventRepo repo.EventRepository
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.BookmarkRepository,
+	eventRepo repo.EventRepository,
+) *service {
+	return &service{
+		lg:        lg,
+		repo:      repo,
+		eventRepo: eventRepo,
+	}
+}
+
+func (s *service) Add(ctx context.Context, userId uuid.UUID, eventId int64) error {
+	if _, err := eventService.GetVisibleEvent(ctx, s.eventRepo, eventId, &userId); err != nil {
+		return err
+	}
+
+	return s.repo.AddBookmark(ctx, userId, eventId)
+}
+
+// Remove works for hidden events too, so users can clean up their list
+func (s *service) Remove(ctx context.Context, userId uuid.UUID, eventId int64) error {
+	return s.repo.RemoveBookmark(ctx, userId, eventId)
+}
+
+// GetBookmarks returns a page of the user's bookmarks by start date, cursorStr is the
+// next_cursor of the previous page
+func (s *service) GetBookmarks(ctx context.Context, userId uuid.UUID, includePast bool, cursorStr string, limit int) (*eventModel.BookmarkPage, error) {
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	limit = min(limit, MaxPageLimit)
+
+	params := eventModel.GetBookmarksQueryParams{
+		UserID:      userId,
+		Now:         time.Now(),
+		IncludePast: includePast,
+		Limit:       limit + 1, // one extra row tells whether there is a next page
+	}
+	if cursorStr != "" {
+		var err error
+		params.AfterStart, params.AfterID, err = cursor.Decode(cursorStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	events, err := s.eventRepo.GetBookmarkedEvents(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &eventModel.BookmarkPage{Items: []eventModel.BookmarkedEvent{}}
+	if len(events) > limit {
+		events = events[:limit]
+		last := events[limit-1]
+		page.NextCursor = cursor.Encode(last.StartDate, last.EventID)
+	}
+	page.Items = append(page.Items, events...)
+	return page, nil
+}
@@ -0,0 +1,176 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	"go.uber.org/zap"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	eventService "github.com/quietguido/mapnu/mainservice/internal/services/event"
+	"github.com/quietguido/mapnu/mainservice/pkg/ical"
+)
+
+const (
+	ConfirmedBookingStatus = "confirmed"
+
+	prodID = "-//MapNu//Events//EN"
+
+	defaultPublicURL = "http://localhost:8080"
+	tokenBytes       = 32
+)
+
+type service struct {
+	lg           *zap.Logger
+	calendarRepo repo.CalendarRepository
+	eventRepo    repo.EventRepository
+	bookingRepo  repo.BookingReposity
+	userRepo     repo.UserRepository
+	publicURL    string
+}
+
+func InitService(
+	lg *zap.Logger,
+	calendarRepo repo.CalendarRepository,
+	eventRepo repo.EventRepository,
+	bookingRepo repo.BookingReposity,
+	userRepo repo.UserRepository,
+) *service {
+	publicURL, exists := os.LookupEnv("PUBLIC_URL")
+	if !exists {
+		publicURL = defaultPublicURL
+	}
+
+	return &service{
+		lg:           lg,
+		calendarRepo: calendarRepo,
+		eventRepo:    eventRepo,
+		bookingRepo:  bookingRepo,
+		userRepo:     userRepo,
+		publicURL:    strings.TrimSuffix(publicURL, "/"),
+	}
+}
+
+// EventICS renders a single event as an iCalendar file
+func (s *service) EventICS(ctx context.Context, eventId int, viewer *uuid.UUID) ([]byte, error) {
+	event, err := s.eventRepo.GetEventById(ctx, eventId)
+	if err != nil {
+		return nil, err
+	}
+	if err := eventService.CheckHidden(event, viewer); err != nil {
+		return nil, err
+	}
+	if err := eventService.CheckAccess(ctx, s.eventRepo, event, viewer); err != nil {
+		return nil, err
+	}
+
+	calendar := ical.Calendar{
+		ProdID: prodID,
+		Events: []ical.Event{s.toICal(*event)},
+	}
+	return calendar.Encode(time.Now()), nil
+}
+
+// CreateFeedToken issues a new feed token for the user, revoking the previous one.
+// Only the hash is stored so the token can not be recovered later.
+func (s *service) CreateFeedToken(ctx context.Context, userId uuid.UUID) (string, string, error) {
+	if _, err := s.userRepo.GetUserById(ctx, userId.String()); err != nil {
+		return "", "", err
+	}
+
+	raw := make([]byte, tokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(raw)
+
+	if err := s.calendarRepo.SetToken(ctx, userId, hashToken(token)); err != nil {
+		return "", "", err
+	}
+	return token, s.FeedURL(token), nil
+}
+
+func (s *service) RevokeFeedToken(ctx context.Context, userId uuid.UUID) error {
+	return s.calendarRepo.DeleteToken(ctx, userId)
+}
+
+func (s *service) FeedURL(token string) string {
+	return fmt.Sprintf("%s/calendar/%s.ics", s.publicURL, token)
+}
+
+// UserFeed renders the confirmed bookings of the token's owner as an iCalendar feed
+func (s *service) UserFeed(ctx context.Context, token string) ([]byte, error) {
+	calendarToken, err := s.calendarRepo.GetTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	bookings, err := s.bookingRepo.GetBookingsForUser(ctx, calendarToken.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	eventIds := make([]int64, 0, len(bookings))
+	for _, booking := range bookings {
+		if booking.BookingStatus == ConfirmedBookingStatus {
+			eventIds = append(eventIds, booking.EventID)
+		}
+	}
+
+	events, err := s.eventRepo.GetEventsByIds(ctx, eventIds)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := ical.Calendar{
+		ProdID: prodID,
+		Name:   "MapNu",
+		Events: make([]ical.Event, 0, len(events)),
+	}
+	for _, event := range events {
+		if eventService.CheckHidden(&event, &calendarToken.UserID) != nil {
+			continue
+		}
+		calendar.Events = append(calendar.Events, s.toICal(event))
+	}
+	return calendar.Encode(time.Now()), nil
+}
+
+// toICal converts the event, the organizer is named but never given an email since the
+// feeds are public
+func (s *service) toICal(event eventModel.Event) ical.Event {
+	url := fmt.Sprintf("%s/event/%d", s.publicURL, event.EventID)
+
+	organizerURL := url
+	if event.OrganizerID != nil {
+		organizerURL = fmt.Sprintf("%s/organizer/%d", s.publicURL, *event.OrganizerID)
+	}
+
+	return ical.Event{
+		UID:           fmt.Sprintf("event-%d@mapnu", event.EventID),
+		Summary:       event.Name,
+		Description:   event.Description,
+		Start:         event.StartDate,
+		End:           event.EndDate,
+		Lat:           event.Location_lat,
+		Lon:           event.Location_lon,
+		OrganizerName: event.Organizer,
+		OrganizerURL:  organizerURL,
+		URL:           url,
+		Categories:    append([]string{event.Category}, event.Tags...),
+		Created:       event.CreatedAt,
+	}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
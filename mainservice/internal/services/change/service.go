@@ -0,0 +1,89 @@
+package change
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	changeModel "github.com/quietguido/mapnu/mainservice/internal/repo/change/model"
+)
+
+// Handlers run one after another on the listener, they must return quickly.
+// Every instance runs its handlers for every change, side effects that must
+// happen once do not belong here.
+type (
+	EventHandler   func(ctx context.Context, change changeModel.EventChange)
+	SeriesHandler  func(ctx context.Context, change changeModel.SeriesChange)
+	BookingHandler func(ctx context.Context, change changeModel.BookingChange)
+)
+
+type service struct {
+	lg              *zap.Logger
+	notifyRepo      repo.NotifyRepository
+	mu              sync.RWMutex
+	eventHandlers   []EventHandler
+	seriesHandlers  []SeriesHandler
+	bookingHandlers []BookingHandler
+}
+
+func InitService(lg *zap.Logger, notifyRepo repo.NotifyRepository) *service {
+	return &service{
+		lg:         lg,
+		notifyRepo: notifyRepo,
+	}
+}
+
+func (s *service) OnEventChange(handler EventHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventHandlers = append(s.eventHandlers, handler)
+}
+
+func (s *service) OnSeriesChange(handler SeriesHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seriesHandlers = append(s.seriesHandlers, handler)
+}
+
+func (s *service) OnBookingChange(handler BookingHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bookingHandlers = append(s.bookingHandlers, handler)
+}
+
+// Run delivers the committed changes of all instances to the handlers until ctx is done
+func (s *service) Run(ctx context.Context) {
+	err := s.notifyRepo.Listen(ctx, []string{changeModel.Channel}, func(_ string, payload []byte) {
+		s.dispatch(ctx, payload)
+	})
+	s.lg.Info("change bus stopped", zap.Error(err))
+}
+
+func (s *service) dispatch(ctx context.Context, payload []byte) {
+	var change changeModel.Change
+	if err := json.Unmarshal(payload, &change); err != nil {
+		s.lg.Error("Invalid change", zap.ByteString("payload", payload), zap.Error(err))
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	switch {
+	case change.Event != nil:
+		for _, handler := range s.eventHandlers {
+			handler(ctx, *change.Event)
+		}
+	case change.Series != nil:
+		for _, handler := range s.seriesHandlers {
+			handler(ctx, *change.Series)
+		}
+	case change.Booking != nil:
+		for _, handler := range s.bookingHandlers {
+			handler(ctx, *change.Booking)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package comment
+
+import (
+	"context"
+	"strings"
+
+	commentModel "github.com/quietguido/mapnu/mainservice/internal/repo/comment/model"
+)
+
+type Verdict int
+
+const (
+	VerdictAllow  Verdict = iota
+	VerdictHold           // stored hidden until a moderator restores it
+	VerdictReject         // not stored at all
+)
+
+// MaxLinks is how many links a comment may carry before it is held for review
+const MaxLinks = 3
+
+// ModerationHook inspects a comment before it is stored or edited. The strictest
+// verdict of all hooks wins.
+type ModerationHook func(ctx context.Context, comment commentModel.CreateComment) (Verdict, error)
+
+// AddModerationHook registers a hook run on every new or edited comment
+func (s *service) AddModerationHook(hook ModerationHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+func (s *service) moderate(ctx context.Context, comment commentModel.CreateComment) (Verdict, error) {
+	verdict := VerdictAllow
+	for _, hook := range s.hooks {
+		v, err := hook(ctx, comment)
+		if err != nil {
+			return VerdictAllow, err
+		}
+		verdict = max(verdict, v)
+	}
+	return verdict, nil
+}
+
+// holdLinkHeavy holds comments that are mostly there to post links
+func holdLinkHeavy(_ context.Context, comment commentModel.CreateComment) (Verdict, error) {
+	body := strings.ToLower(comment.Body)
+	links := strings.Count(body, "http://") + strings.Count(body, "https://")
+	if links > MaxLinks {
+		return VerdictHold, nil
+	}
+	return VerdictAllow, nil
+}
@@ -0,0 +1,356 @@
+package comment
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	commentModel "github.com/quietguido/mapnu/mainservice/internal/repo/comment/model"
+	organizerModel "github.com/quietguido/mapnu/mainservice/internal/repo/organizer/model"
+	eventService "github.com/quietguido/mapnu/mainservice/internal/services/event"
+	"github.com/quietguido/mapnu/mainservice/pkg/cursor"
+)
+
+const (
+	MaxBodyLength = 2000
+	MaxMentions   = 10
+
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_.\-]+)`)
+
+type service struct {
+	lg            *zap.Logger
+	repo          repo.CommentRepository
+	eventRepo     repo.EventRepository
+	organizerRepo repo.OrganizerRepository
+	userRepo      repo.UserRepository
+	hooks         []ModerationHook
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.CommentRepository,
+	eventRepo repo.EventRepository,
+	organizerRepo repo.OrganizerRepository,
+	userRepo repo.UserRepository,
+) *service {
+	return &service{
+		lg:            lg,
+		repo:          repo,
+		eventRepo:     eventRepo,
+		organizerRepo: organizerRepo,
+		userRepo:      userRepo,
+		hooks:         []ModerationHook{holdLinkHeavy},
+	}
+}
+
+// Create adds a comment to the event, a reply when ParentID is set
+func (s *service) Create(ctx context.Context, createComment commentModel.CreateComment) (*commentModel.Comment, error) {
+	body, err := checkBody(createComment.Body)
+	if err != nil {
+		return nil, err
+	}
+	createComment.Body = body
+
+	if _, err := eventService.GetVisibleEvent(ctx, s.eventRepo, createComment.EventID, &createComment.UserID); err != nil {
+		return nil, err
+	}
+
+	var rootId *int64
+	if createComment.ParentID != nil {
+		parent, err := s.repo.GetCommentById(ctx, *createComment.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.EventID != createComment.EventID || parent.Status != commentModel.StatusVisible {
+			return nil, errors.New("Can not reply to this comment")
+		}
+
+		// replies to replies stay in the thread of the top level comment
+		rootId = parent.RootID
+		if rootId == nil {
+			rootId = &parent.CommentID
+		}
+	}
+
+	createComment.Mentions, err = s.resolveMentions(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	verdict, err := s.moderate(ctx, createComment)
+	if err != nil {
+		return nil, err
+	}
+	switch verdict {
+	case VerdictReject:
+		return nil, errors.New("Comment was rejected")
+	case VerdictHold:
+		createComment.Status = commentModel.StatusHidden
+	default:
+		createComment.Status = commentModel.StatusVisible
+	}
+
+	commentId, err := s.repo.CreateComment(ctx, createComment, rootId)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetCommentById(ctx, commentId)
+}
+
+// Update edits the comment body, only the author may edit
+func (s *service) Update(ctx context.Context, commentId int64, update commentModel.UpdateComment) error {
+	comment, err := s.repo.GetCommentById(ctx, commentId)
+	if err != nil {
+		return err
+	}
+	if comment.UserID == nil || *comment.UserID != update.UserID {
+		return errors.New("Comment does not belong to user")
+	}
+
+	body, err := checkBody(update.Body)
+	if err != nil {
+		return err
+	}
+	update.Body = body
+
+	update.Mentions, err = s.resolveMentions(ctx, body)
+	if err != nil {
+		return err
+	}
+
+	verdict, err := s.moderate(ctx, commentModel.CreateComment{
+		EventID:  comment.EventID,
+		ParentID: comment.ParentID,
+		UserID:   update.UserID,
+		Body:     body,
+		Mentions: update.Mentions,
+	})
+	if err != nil {
+		return err
+	}
+	if verdict == VerdictReject {
+		return errors.New("Comment was rejected")
+	}
+
+	if err := s.repo.UpdateComment(ctx, commentId, update); err != nil {
+		return err
+	}
+	if verdict == VerdictHold {
+		return s.repo.SetStatus(ctx, commentId, commentModel.StatusHidden)
+	}
+	return nil
+}
+
+// Delete removes the comment, allowed for the author and the event's organizers
+func (s *service) Delete(ctx context.Context, commentId int64, userId uuid.UUID) error {
+	comment, err := s.repo.GetCommentById(ctx, commentId)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID == nil || *comment.UserID != userId {
+		allowed, err := s.canModerate(ctx, comment.EventID, userId)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return errors.New("Comment does not belong to user")
+		}
+	}
+
+	return s.repo.DeleteComment(ctx, commentId)
+}
+
+// Pin pins or unpins a reply, e.g. the organizer's answer to a question
+func (s *service) Pin(ctx context.Context, commentId int64, pin commentModel.PinComment) error {
+	comment, err := s.repo.GetCommentById(ctx, commentId)
+	if err != nil {
+		return err
+	}
+	if comment.RootID == nil {
+		return errors.New("Only replies can be pinned")
+	}
+
+	allowed, err := s.canModerate(ctx, comment.EventID, pin.UserID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("Only organizers can pin replies")
+	}
+
+	return s.repo.SetPinned(ctx, commentId, pin.Pinned)
+}
+
+// GetThreads returns a page of top level comments with their pinned replies
+func (s *service) GetThreads(ctx context.Context, eventId int64, viewer *uuid.UUID, cursorStr string, limit int) (*commentModel.ThreadPage, error) {
+	params, err := pageParams(cursorStr, limit)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := eventService.GetVisibleEvent(ctx, s.eventRepo, eventId, viewer); err != nil {
+		return nil, err
+	}
+	params.EventID = eventId
+
+	comments, err := s.repo.GetThreads(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &commentModel.ThreadPage{Threads: []commentModel.Thread{}}
+	comments, page.NextCursor = trimPage(comments, params.Limit-1)
+
+	rootIds := make([]int64, 0, len(comments))
+	for _, comment := range comments {
+		rootIds = append(rootIds, comment.CommentID)
+	}
+
+	pinned, err := s.repo.GetPinnedReplies(ctx, rootIds)
+	if err != nil {
+		return nil, err
+	}
+	byRoot := make(map[int64][]commentModel.Comment)
+	for _, reply := range pinned {
+		byRoot[*reply.RootID] = append(byRoot[*reply.RootID], reply)
+	}
+
+	for _, comment := range comments {
+		replies := byRoot[comment.CommentID]
+		if replies == nil {
+			replies = []commentModel.Comment{}
+		}
+		page.Threads = append(page.Threads, commentModel.Thread{
+			Comment:       comment,
+			PinnedReplies: replies,
+		})
+	}
+	return page, nil
+}
+
+// GetReplies returns a page of replies of the thread started by commentId
+func (s *service) GetReplies(ctx context.Context, commentId int64, viewer *uuid.UUID, cursorStr string, limit int) (*commentModel.ReplyPage, error) {
+	params, err := pageParams(cursorStr, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := s.repo.GetCommentById(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := eventService.GetVisibleEvent(ctx, s.eventRepo, root.EventID, viewer); err != nil {
+		return nil, err
+	}
+	params.RootID = commentId
+
+	replies, err := s.repo.GetReplies(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &commentModel.ReplyPage{}
+	page.Replies, page.NextCursor = trimPage(replies, params.Limit-1)
+	if page.Replies == nil {
+		page.Replies = []commentModel.Comment{}
+	}
+	return page, nil
+}
+
+// canModerate allows the event creator and organizer members who manage its events
+func (s *service) canModerate(ctx context.Context, eventId int64, userId uuid.UUID) (bool, error) {
+	event, err := s.eventRepo.GetEventById(ctx, int(eventId))
+	if err != nil {
+		return false, err
+	}
+
+	if event.CreatedBy != nil && *event.CreatedBy == userId {
+		return true, nil
+	}
+	if event.OrganizerID == nil {
+		return false, nil
+	}
+
+	role, err := s.organizerRepo.GetMemberRole(ctx, *event.OrganizerID, userId)
+	if err != nil {
+		return false, err
+	}
+	return organizerModel.RoleCan(role, organizerModel.PermissionManageEvents), nil
+}
+
+// resolveMentions turns @username mentions into user ids, unknown names are ignored
+func (s *service) resolveMentions(ctx context.Context, body string) ([]uuid.UUID, error) {
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		username := match[1]
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+	}
+	if len(usernames) > MaxMentions {
+		return nil, errors.Errorf("Too many mentions, at most %d allowed", MaxMentions)
+	}
+
+	users, err := s.userRepo.GetUsersByUsernames(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	mentions := make([]uuid.UUID, 0, len(users))
+	for _, user := range users {
+		mentions = append(mentions, user.ID)
+	}
+	return mentions, nil
+}
+
+func checkBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", errors.New("Missing comment body")
+	}
+	if utf8.RuneCountInString(body) > MaxBodyLength {
+		return "", errors.Errorf("Comment is longer than %d characters", MaxBodyLength)
+	}
+	return body, nil
+}
+
+// pageParams decodes the cursor, the limit asks for one extra row to detect a next page
+func pageParams(cursorStr string, limit int) (commentModel.GetCommentsQueryParams, error) {
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	params := commentModel.GetCommentsQueryParams{
+		Limit: min(limit, MaxPageLimit) + 1,
+	}
+
+	if cursorStr != "" {
+		var err error
+		params.AfterCreated, params.AfterID, err = cursor.Decode(cursorStr)
+		if err != nil {
+			return params, err
+		}
+	}
+	return params, nil
+}
+
+func trimPage(comments []commentModel.Comment, limit int) ([]commentModel.Comment, string) {
+	if len(comments) <= limit {
+		return comments, ""
+	}
+	comments = comments[:limit]
+	last := comments[limit-1]
+	return comments, cursor.Encode(last.CreatedAt, last.CommentID)
+}
@@ -0,0 +1,34 @@
+package event
+
+import (
+	"context"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	imageModel "github.com/quietguido/mapnu/mainservice/internal/repo/image/model"
+)
+
+// fillImages attaches the image URLs of every event in one query.
+func (s *service) fillImages(ctx context.Context, events []*eventModel.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	eventIds := make([]int64, 0, len(events))
+	for _, event := range events {
+		eventIds = append(eventIds, event.EventID)
+	}
+
+	images, err := s.imageRepo.GetImagesForEvents(ctx, eventIds)
+	if err != nil {
+		return err
+	}
+
+	byEvent := make(map[int64][]imageModel.ImageURLs)
+	for _, image := range images {
+		byEvent[image.EventID] = append(byEvent[image.EventID], image.URLs(s.storage.URL))
+	}
+	for _, event := range events {
+		event.Images = byEvent[event.EventID]
+	}
+	return nil
+}
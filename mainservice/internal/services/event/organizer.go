@@ -0,0 +1,45 @@
+package event
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	organizerModel "github.com/quietguido/mapnu/mainservice/internal/repo/organizer/model"
+)
+
+// resolveOrganizer checks the acting user may publish for the organizer and takes the
+// display name from its profile
+func (s *service) resolveOrganizer(ctx context.Context, createEvent *eventModel.CreateEvent, userId *uuid.UUID) error {
+	if createEvent.OrganizerID == nil {
+		return nil
+	}
+
+	if userId == nil {
+		return errors.New("Missing user for organizer event")
+	}
+	if err := s.checkOrganizerPermission(ctx, *createEvent.OrganizerID, *userId); err != nil {
+		return err
+	}
+
+	organizer, err := s.organizerRepo.GetOrganizerById(ctx, *createEvent.OrganizerID)
+	if err != nil {
+		return errors.Wrap(err, "Unknown organizer")
+	}
+
+	createEvent.Organizer = organizer.Name
+	return nil
+}
+
+func (s *service) checkOrganizerPermission(ctx context.Context, organizerId int64, userId uuid.UUID) error {
+	role, err := s.organizerRepo.GetMemberRole(ctx, organizerId, userId)
+	if err != nil {
+		return err
+	}
+	if !organizerModel.RoleCan(role, organizerModel.PermissionManageEvents) {
+		return errors.New("User may not manage events of this organizer")
+	}
+	return nil
+}
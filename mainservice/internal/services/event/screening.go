@@ -0,0 +1,253 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	lockModel "github.com/quietguido/mapnu/mainservice/internal/repo/lock/model"
+)
+
+const (
+	// events scoring this or more are held for moderation instead of being published
+	HoldScore = 1.0
+
+	HourlyQuota = 10
+	DailyQuota  = 50
+
+	// imports and series create many events at once, each of them counts
+	BulkDailyQuota = 1000
+
+	MaxLinks = 3
+
+	// anonymous events can not be held to a quota, so they start out slightly suspicious
+	anonymousScore = 0.5
+	linkScore      = 0.2
+)
+
+var linkPattern = regexp.MustCompile(`(?i)https?://\S+|www\.\S+`)
+
+type quota struct {
+	window time.Duration
+	limit  int
+}
+
+var (
+	singleQuotas = []quota{{time.Hour, HourlyQuota}, {24 * time.Hour, DailyQuota}}
+	bulkQuotas   = []quota{{24 * time.Hour, BulkDailyQuota}}
+)
+
+// ScreeningResult is what a single check thinks of a new event
+type ScreeningResult struct {
+	Score  float64 // added up over all checks and compared to HoldScore
+	Reason string  // shown to moderators, empty when the check has nothing to say
+	Reject bool    // refuse the event outright
+}
+
+// RejectedError is returned for an event refused by one of the checks
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return e.Reason
+}
+
+// ScreeningCheck inspects an event before it is created
+type ScreeningCheck func(ctx context.Context, createEvent eventModel.CreateEvent) (ScreeningResult, error)
+
+// AddScreeningCheck registers a check run on every event created through Create,
+// imported or materialized as the first occurrences of a new series
+func (s *service) AddScreeningCheck(check ScreeningCheck) {
+	s.checks = append(s.checks, check)
+}
+
+// defaultChecks run on every new event, quotas are checked separately since a batch
+// of events counts against them as a whole
+func (s *service) defaultChecks() []ScreeningCheck {
+	checks := []ScreeningCheck{
+		s.checkDuplicate,
+		checkLinks,
+	}
+
+	// comma separated, e.g. BANNED_WORDS="casino,crypto giveaway"
+	if bannedWords, exists := os.LookupEnv("BANNED_WORDS"); exists {
+		checks = append(checks, BannedWords(strings.Split(bannedWords, ",")))
+	}
+	return checks
+}
+
+// ScreenEvent runs the screening checks on an event created in bulk, the batch is checked
+// against the quota by CheckBulkQuota. A rejected event returns an error, a suspicious one
+// is marked hidden with the reasons for the moderation report filed together with it.
+func (s *service) ScreenEvent(ctx context.Context, createEvent *eventModel.CreateEvent) error {
+	return s.hold(ctx, createEvent, s.checks)
+}
+
+// screenSingle screens an event created on its own, the single event quotas included
+func (s *service) screenSingle(ctx context.Context, createEvent *eventModel.CreateEvent) error {
+	checks := append([]ScreeningCheck{s.checkQuota}, s.checks...)
+	return s.hold(ctx, createEvent, checks)
+}
+
+func (s *service) hold(ctx context.Context, createEvent *eventModel.CreateEvent, checks []ScreeningCheck) error {
+	hold, reasons, err := screen(ctx, *createEvent, checks)
+	if err != nil {
+		return err
+	}
+	// held events are created hidden, a moderator publishes them by restoring the event
+	createEvent.Hidden = hold
+	if hold {
+		createEvent.HoldReason = "Held by screening: " + strings.Join(reasons, "; ")
+	}
+	return nil
+}
+
+// screen runs the checks and reports whether the event has to be held for moderation
+func screen(ctx context.Context, createEvent eventModel.CreateEvent, checks []ScreeningCheck) (bool, []string, error) {
+	var (
+		score   float64
+		reasons []string
+	)
+	for _, check := range checks {
+		result, err := check(ctx, createEvent)
+		if err != nil {
+			return false, nil, err
+		}
+		if result.Reject {
+			return false, nil, &RejectedError{Reason: result.Reason}
+		}
+		score += result.Score
+		if result.Reason != "" && result.Score > 0 {
+			reasons = append(reasons, result.Reason)
+		}
+	}
+	return score >= HoldScore, reasons, nil
+}
+
+// checkDuplicate refuses an event the same user already created. Anonymous events can't be
+// told apart by their creator, so they are refused when anyone created the same event.
+func (s *service) checkDuplicate(ctx context.Context, createEvent eventModel.CreateEvent) (ScreeningResult, error) {
+	count, err := s.repo.CountSimilar(ctx, createEvent.CreatedBy, createEvent)
+	if err != nil {
+		return ScreeningResult{}, err
+	}
+	if count == 0 {
+		return ScreeningResult{}, nil
+	}
+	if createEvent.CreatedBy == nil {
+		return ScreeningResult{Reject: true, Reason: "Event duplicates an existing one"}, nil
+	}
+	return ScreeningResult{Reject: true, Reason: "Event duplicates one you already created"}, nil
+}
+
+// checkQuota refuses events over the per-user hourly and daily quotas
+func (s *service) checkQuota(ctx context.Context, createEvent eventModel.CreateEvent) (ScreeningResult, error) {
+	if createEvent.CreatedBy == nil {
+		return ScreeningResult{Score: anonymousScore, Reason: "anonymous event"}, nil
+	}
+
+	return s.quotaResult(ctx, *createEvent.CreatedBy, 1, singleQuotas)
+}
+
+// LockQuota serializes the creations of one user from the quota and duplicate checks until
+// the new events are inserted, so concurrent requests can't slip past them. Anonymous
+// creations share one lock. release must be called once the events are inserted.
+func (s *service) LockQuota(ctx context.Context, createdBy *uuid.UUID) (release func(), err error) {
+	key := ""
+	if createdBy != nil {
+		key = createdBy.String()
+	}
+	return s.lockRepo.Lock(ctx, lockModel.NamespaceEventQuota, key)
+}
+
+// CheckBulkQuota refuses a batch of events (an import, the first occurrences of a series)
+// that would take the user over the bulk quota, every event of the batch counts
+func (s *service) CheckBulkQuota(ctx context.Context, createdBy *uuid.UUID, count int) error {
+	if count == 0 {
+		return nil
+	}
+	if createdBy == nil {
+		return errors.New("Events can only be created in bulk by signed in users")
+	}
+
+	result, err := s.quotaResult(ctx, *createdBy, count, bulkQuotas)
+	if err != nil {
+		return err
+	}
+	if result.Reject {
+		return errors.New(result.Reason)
+	}
+	return nil
+}
+
+// quotaResult rejects when count more events take the user over one of the quotas
+func (s *service) quotaResult(ctx context.Context, userId uuid.UUID, count int, quotas []quota) (ScreeningResult, error) {
+	now := time.Now()
+	for _, quota := range quotas {
+		created, err := s.repo.CountCreatedSince(ctx, userId, now.Add(-quota.window))
+		if err != nil {
+			return ScreeningResult{}, err
+		}
+		if created+count > quota.limit {
+			return ScreeningResult{
+				Reject: true,
+				Reason: fmt.Sprintf("Quota of %d events per %s reached", quota.limit, quota.window),
+			}, nil
+		}
+	}
+	return ScreeningResult{}, nil
+}
+
+// checkLinks adds suspicion for every link, holding events with more than MaxLinks
+func checkLinks(_ context.Context, createEvent eventModel.CreateEvent) (ScreeningResult, error) {
+	links := len(linkPattern.FindAllString(createEvent.Name+" "+createEvent.Description, -1))
+	if links == 0 {
+		return ScreeningResult{}, nil
+	}
+
+	result := ScreeningResult{
+		Score:  float64(links) * linkScore,
+		Reason: fmt.Sprintf("%d links", links),
+	}
+	if links > MaxLinks {
+		result.Score = HoldScore
+	}
+	return result, nil
+}
+
+// BannedWords holds events whose name or description contains one of the words or phrases
+func BannedWords(words []string) ScreeningCheck {
+	var banned []string
+	for _, word := range words {
+		if word = normalizeText(word); word != "" {
+			banned = append(banned, word)
+		}
+	}
+
+	return func(_ context.Context, createEvent eventModel.CreateEvent) (ScreeningResult, error) {
+		// padded with spaces so only whole words match
+		text := " " + normalizeText(createEvent.Name+" "+createEvent.Description) + " "
+		for _, word := range banned {
+			if strings.Contains(text, " "+word+" ") {
+				return ScreeningResult{Score: HoldScore, Reason: "banned word " + word}, nil
+			}
+		}
+		return ScreeningResult{}, nil
+	}
+}
+
+// normalizeText lowercases s and collapses everything that is not a letter or digit into single spaces
+func normalizeText(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
@@ -0,0 +1,296 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/teambition/rrule-go"
+	"go.uber.org/zap"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	lockModel "github.com/quietguido/mapnu/mainservice/internal/repo/lock/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/worker"
+)
+
+const (
+	// occurrences are materialized this far ahead of now
+	SeriesHorizon = 90 * 24 * time.Hour
+
+	// upper bound of occurrences generated for one series in one pass,
+	// the rest is picked up by the next materializer run
+	maxOccurrencesPerPass = 500
+
+	defaultSeriesTimezone = "UTC"
+)
+
+func (s *service) CreateSeries(ctx context.Context, createSeries eventModel.CreateSeries) (int64, error) {
+	if err := s.resolveVenue(ctx, &createSeries.CreateEvent); err != nil {
+		return 0, err
+	}
+	if err := s.resolveOrganizer(ctx, &createSeries.CreateEvent, createSeries.CreatedBy); err != nil {
+		return 0, err
+	}
+	if err := prepareSeries(&createSeries); err != nil {
+		return 0, err
+	}
+
+	set, err := buildRuleSet(createSeries.RRule, createSeries.Timezone, createSeries.StartDate, createSeries.ExDates)
+	if err != nil {
+		return 0, err
+	}
+
+	release, err := s.LockQuota(ctx, createSeries.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	// the template is screened once, every occurrence of the first pass counts against the quota
+	if err := s.ScreenEvent(ctx, &createSeries.CreateEvent); err != nil {
+		return 0, err
+	}
+
+	starts, materializedUntil := expand(set, createSeries.StartDate, true, time.Now().Add(SeriesHorizon))
+	if err := s.CheckBulkQuota(ctx, createSeries.CreatedBy, len(starts)); err != nil {
+		return 0, err
+	}
+	if createSeries.Hidden && len(starts) == 0 {
+		return 0, errors.New("Series is held by screening and has no occurrence to review yet")
+	}
+
+	occurrences := occurrenceEvents(createSeries.CreateEvent, starts)
+	// a held series is reviewed through its first occurrence, restoring it publishes the series
+	for i := 1; i < len(occurrences); i++ {
+		occurrences[i].HoldReason = ""
+	}
+
+	seriesId, err := s.repo.CreateSeries(ctx, createSeries, occurrences, materializedUntil)
+	if err != nil {
+		return 0, err
+	}
+
+	if createSeries.Hidden {
+		s.lg.Info("Series held for moderation", zap.Int64("series_id", seriesId), zap.String("reason", createSeries.HoldReason))
+	}
+	return seriesId, nil
+}
+
+func (s *service) GetSeriesById(ctx context.Context, seriesId int64) (*eventModel.Series, error) {
+	return s.repo.GetSeriesById(ctx, seriesId)
+}
+
+// UpdateOccurrence edits a single occurrence ("this occurrence"), later series edits leave it alone.
+func (s *service) UpdateOccurrence(ctx context.Context, seriesId, eventId int64, update eventModel.UpdateOccurrence) error {
+	if _, err := s.getOwnedSeries(ctx, seriesId, update.UserID); err != nil {
+		return err
+	}
+
+	if err := s.resolveVenue(ctx, &update.CreateEvent); err != nil {
+		return err
+	}
+	if err := s.resolveOrganizer(ctx, &update.CreateEvent, &update.UserID); err != nil {
+		return err
+	}
+	if err := PrepareEvent(&update.CreateEvent); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateOccurrence(ctx, seriesId, eventId, update.CreateEvent)
+}
+
+// UpdateSeries edits the series for every occurrence starting at update.From ("all future occurrences").
+func (s *service) UpdateSeries(ctx context.Context, seriesId int64, update eventModel.UpdateSeries) error {
+	series, err := s.getOwnedSeries(ctx, seriesId, update.UserID)
+	if err != nil {
+		return err
+	}
+
+	if update.From.IsZero() {
+		return errors.New("Missing from date")
+	}
+	if update.ExDates == nil {
+		update.ExDates = series.ExDates
+	}
+	update.CreatedBy = series.CreatedBy
+	// occurrences added by the edit stay hidden while the series is held
+	update.Hidden = series.Hidden
+
+	if err := s.resolveVenue(ctx, &update.CreateEvent); err != nil {
+		return err
+	}
+	if err := s.resolveOrganizer(ctx, &update.CreateEvent, &update.UserID); err != nil {
+		return err
+	}
+	if err := prepareSeries(&update.CreateSeries); err != nil {
+		return err
+	}
+
+	set, err := buildRuleSet(update.RRule, update.Timezone, update.StartDate, update.ExDates)
+	if err != nil {
+		return err
+	}
+
+	horizon := time.Now().Add(SeriesHorizon)
+	if series.MaterializedUntil.After(horizon) {
+		horizon = series.MaterializedUntil
+	}
+
+	starts, materializedUntil := expand(set, update.From, true, horizon)
+	occurrences := occurrenceEvents(update.CreateEvent, starts)
+
+	return s.repo.UpdateSeriesFrom(ctx, seriesId, update.CreateSeries, update.From, occurrences, materializedUntil)
+}
+
+// CancelOccurrence removes a single occurrence and excludes it from the rule.
+func (s *service) CancelOccurrence(ctx context.Context, seriesId, eventId int64, userId uuid.UUID) error {
+	if _, err := s.getOwnedSeries(ctx, seriesId, userId); err != nil {
+		return err
+	}
+
+	return s.repo.CancelOccurrence(ctx, seriesId, eventId)
+}
+
+// MaterializeSeries generates occurrences of every series up to the horizon.
+// Only one instance materializes at a time, the others skip the round.
+func (s *service) MaterializeSeries(ctx context.Context) error {
+	release, ok, err := s.lockRepo.TryLock(ctx, lockModel.KeySeriesMaterializer)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	defer release()
+
+	horizon := time.Now().Add(SeriesHorizon)
+
+	seriesList, err := s.repo.GetSeriesToMaterialize(ctx, horizon)
+	if err != nil {
+		return err
+	}
+
+	for _, series := range seriesList {
+		set, err := buildRuleSet(series.RRule, series.Timezone, series.StartDate, series.ExDates)
+		if err != nil {
+			s.lg.Error("Failed to build series rule", zap.Int64("series_id", series.SeriesID), zap.Error(err))
+			continue
+		}
+
+		starts, materializedUntil := expand(set, series.MaterializedUntil, false, horizon)
+		occurrences := occurrenceEvents(series.Template(), starts)
+
+		err = s.repo.AddOccurrences(ctx, series.SeriesID, occurrences, series.MaterializedUntil, materializedUntil)
+		if err != nil {
+			s.lg.Error("Failed to materialize series", zap.Int64("series_id", series.SeriesID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// RunSeriesMaterializer keeps occurrences materialized up to the horizon until ctx is done.
+func (s *service) RunSeriesMaterializer(ctx context.Context, interval time.Duration) {
+	worker.Every(ctx, s.lg, interval, "materialize series", s.MaterializeSeries)
+}
+
+func (s *service) getOwnedSeries(ctx context.Context, seriesId int64, userId uuid.UUID) (*eventModel.Series, error) {
+	series, err := s.repo.GetSeriesById(ctx, seriesId)
+	if err != nil {
+		return nil, err
+	}
+
+	if series.CreatedBy != nil && *series.CreatedBy == userId {
+		return series, nil
+	}
+
+	// members of the owning organizer may manage its series as well
+	if series.OrganizerID != nil {
+		if err := s.checkOrganizerPermission(ctx, *series.OrganizerID, userId); err == nil {
+			return series, nil
+		}
+	}
+	return nil, errors.New("Series does not belong to user")
+}
+
+// prepareSeries validates the series and fills in defaults
+func prepareSeries(createSeries *eventModel.CreateSeries) error {
+	if err := PrepareEvent(&createSeries.CreateEvent); err != nil {
+		return err
+	}
+
+	if createSeries.RRule == "" {
+		return errors.New("Missing rrule")
+	}
+	if createSeries.Timezone == "" {
+		createSeries.Timezone = defaultSeriesTimezone
+	}
+	if _, err := time.LoadLocation(createSeries.Timezone); err != nil {
+		return errors.Wrap(err, "Incorrect timezone")
+	}
+	if createSeries.ExDates == nil {
+		createSeries.ExDates = []time.Time{}
+	}
+	return nil
+}
+
+// buildRuleSet expands the rule in the series timezone so local times stay fixed across DST changes
+func buildRuleSet(rule, timezone string, dtstart time.Time, exdates []time.Time) (*rrule.Set, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, errors.Wrap(err, "Incorrect timezone")
+	}
+
+	option, err := rrule.StrToROptionInLocation(rule, loc)
+	if err != nil {
+		return nil, errors.Wrap(err, "Incorrect rrule")
+	}
+	option.Dtstart = dtstart.In(loc)
+
+	r, err := rrule.NewRRule(*option)
+	if err != nil {
+		return nil, errors.Wrap(err, "Incorrect rrule")
+	}
+
+	set := &rrule.Set{}
+	set.RRule(r)
+	for _, exdate := range exdates {
+		set.ExDate(exdate.In(loc))
+	}
+	return set, nil
+}
+
+// expand returns occurrence starts after from (inclusive if inc) up to horizon, capped at
+// maxOccurrencesPerPass, and the point up to which the series is now materialized.
+func expand(set *rrule.Set, from time.Time, inc bool, horizon time.Time) ([]time.Time, time.Time) {
+	next := set.Iterator()
+
+	var starts []time.Time
+	for len(starts) < maxOccurrencesPerPass {
+		start, ok := next()
+		if !ok || start.After(horizon) {
+			return starts, horizon
+		}
+		if start.Before(from) || (!inc && start.Equal(from)) {
+			continue
+		}
+		starts = append(starts, start)
+	}
+	return starts, starts[len(starts)-1]
+}
+
+func occurrenceEvents(template eventModel.CreateEvent, starts []time.Time) []eventModel.CreateEvent {
+	occurrences := make([]eventModel.CreateEvent, 0, len(starts))
+	for _, start := range starts {
+		occurrence := template
+		occurrence.StartDate = start
+		occurrence.OccurrenceDate = &start
+
+		if template.EndDate != nil {
+			end := start.Add(template.EndDate.Sub(template.StartDate))
+			occurrence.EndDate = &end
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	return occurrences
+}
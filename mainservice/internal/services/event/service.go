@@ -2,33 +2,307 @@ package event
 
 import (
 	"context"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	"github.com/quietguido/mapnu/mainservice/pkg/storage"
 	"go.uber.org/zap"
 
 	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	voteModel "github.com/quietguido/mapnu/mainservice/internal/repo/vote/model"
 )
 
+const (
+	UpVote   = "up"
+	DownVote = "down"
+
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 200
+)
+
+var ErrHidden = errors.New("Event is hidden by moderation")
+
+// QueryError is returned for map, trending, search and feed parameters the caller got
+// wrong, every other error of those reads is a failure on our side
+type QueryError struct {
+	Reason string
+}
+
+func (e *QueryError) Error() string {
+	return e.Reason
+}
+
 type service struct {
-	lg   *zap.Logger
-	repo repo.EventRepository
+	lg            *zap.Logger
+	repo          repo.EventRepository
+	voteRepo      repo.VoteRepository
+	venueRepo     repo.VenueRepository
+	organizerRepo repo.OrganizerRepository
+	imageRepo     repo.ImageRepository
+	bookingRepo   repo.BookingReposity
+	streamRepo    repo.StreamRepository
+	lockRepo      repo.LockRepository
+	storage       storage.Storage
+	checks        []ScreeningCheck
 }
 
-func InitService(lg *zap.Logger, repo repo.EventRepository) *service {
-	return &service{
-		lg:   lg,
-		repo: repo,
+func InitService(
+	lg *zap.Logger,
+	repo repo.EventRepository,
+	voteRepo repo.VoteRepository,
+	venueRepo repo.VenueRepository,
+	organizerRepo repo.OrganizerRepository,
+	imageRepo repo.ImageRepository,
+	bookingRepo repo.BookingReposity,
+	streamRepo repo.StreamRepository,
+	lockRepo repo.LockRepository,
+	storage storage.Storage,
+) *service {
+	s := &service{
+		lg:            lg,
+		repo:          repo,
+		voteRepo:      voteRepo,
+		venueRepo:     venueRepo,
+		organizerRepo: organizerRepo,
+		imageRepo:     imageRepo,
+		bookingRepo:   bookingRepo,
+		streamRepo:    streamRepo,
+		lockRepo:      lockRepo,
+		storage:       storage,
 	}
+	s.checks = s.defaultChecks()
+	return s
 }
 
 func (s *service) Create(ctx context.Context, createEvent eventModel.CreateEvent) (int, error) {
-	return s.repo.CreateEvent(ctx, createEvent)
+	if err := s.resolveVenue(ctx, &createEvent); err != nil {
+		return 0, err
+	}
+	if err := s.resolveOrganizer(ctx, &createEvent, createEvent.CreatedBy); err != nil {
+		return 0, err
+	}
+	if err := PrepareEvent(&createEvent); err != nil {
+		return 0, err
+	}
+
+	release, err := s.LockQuota(ctx, createEvent.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	if err := s.screenSingle(ctx, &createEvent); err != nil {
+		return 0, err
+	}
+
+	eventId, err := s.repo.CreateEvent(ctx, createEvent)
+	if err != nil {
+		return 0, err
+	}
+
+	if createEvent.Hidden {
+		s.lg.Info("Event held for moderation", zap.Int("event_id", eventId), zap.String("reason", createEvent.HoldReason))
+	}
+	return eventId, nil
+}
+
+func (s *service) GetEventById(ctx context.Context, eventId int, viewer *uuid.UUID) (*eventModel.Event, error) {
+	event, err := s.repo.GetEventById(ctx, eventId)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckHidden(event, viewer); err != nil {
+		return nil, err
+	}
+	if err := CheckAccess(ctx, s.repo, event, viewer); err != nil {
+		return nil, err
+	}
+
+	refs := []*eventModel.Event{event}
+	if err := s.fillViewerVotes(ctx, refs, viewer); err != nil {
+		return nil, err
+	}
+	if err := s.fillImages(ctx, refs); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// CheckHidden keeps events hidden by moderation from everyone but their creator,
+// who can still see what was moderated
+func CheckHidden(event *eventModel.Event, viewer *uuid.UUID) error {
+	if !event.Hidden {
+		return nil
+	}
+	if viewer != nil && event.CreatedBy != nil && *event.CreatedBy == *viewer {
+		return nil
+	}
+	return ErrHidden
+}
+
+func (s *service) GetMapForQuadrant(ctx context.Context, mapQuery eventModel.GetMapQueryParams, viewer *uuid.UUID) ([]eventModel.Event, error) {
+	filters, err := normalizeFilters(mapQuery.EventFilters)
+	if err != nil {
+		return nil, &QueryError{Reason: err.Error()}
+	}
+	mapQuery.EventFilters = filters
+	mapQuery.Viewer = viewer
+
+	switch {
+	case mapQuery.From.IsZero() && mapQuery.To.IsZero():
+		// the calendar day of the requested date, in the offset it was given in
+		date := mapQuery.Date
+		mapQuery.From = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+		mapQuery.To = mapQuery.From.AddDate(0, 0, 1)
+	case !mapQuery.To.After(mapQuery.From):
+		return nil, &QueryError{Reason: "Date range end must be after its start"}
+	}
+
+	events, err := s.repo.GetMapForQuadrant(ctx, mapQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withViewerVotes(ctx, events, viewer)
+}
+
+func (s *service) Search(ctx context.Context, params eventModel.SearchQueryParams, viewer *uuid.UUID) ([]eventModel.Event, error) {
+	filters, err := normalizeFilters(params.EventFilters)
+	if err != nil {
+		return nil, &QueryError{Reason: err.Error()}
+	}
+	params.EventFilters = filters
+	params.Viewer = viewer
+	params.Query = strings.TrimSpace(params.Query)
+
+	if !params.From.IsZero() && !params.To.IsZero() && !params.To.After(params.From) {
+		return nil, &QueryError{Reason: "Date range end must be after its start"}
+	}
+	if params.Limit <= 0 {
+		params.Limit = DefaultSearchLimit
+	}
+	params.Limit = min(params.Limit, MaxSearchLimit)
+
+	events, err := s.repo.SearchEvents(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withViewerVotes(ctx, events, viewer)
+}
+
+func (s *service) withViewerVotes(ctx context.Context, events []eventModel.Event, viewer *uuid.UUID) ([]eventModel.Event, error) {
+	refs := make([]*eventModel.Event, 0, len(events))
+	for i := range events {
+		refs = append(refs, &events[i])
+	}
+
+	if err := s.fillViewerVotes(ctx, refs, viewer); err != nil {
+		return nil, err
+	}
+	if err := s.fillImages(ctx, refs); err != nil {
+		return nil, err
+	}
+	return events, nil
 }
 
-func (s *service) GetEventById(ctx context.Context, eventId int) (*eventModel.Event, error) {
-	return s.repo.GetEventById(ctx, eventId)
+func (s *service) Vote(ctx context.Context, setVote voteModel.SetVote) error {
+	value, ok := voteValue(setVote.Vote)
+	if !ok {
+		return errors.New("Incorrect vote")
+	}
+	if _, err := GetVisibleEvent(ctx, s.repo, setVote.EventID, &setVote.UserID); err != nil {
+		return err
+	}
+
+	return s.voteRepo.SetVote(ctx, setVote.UserID, setVote.EventID, value)
 }
 
-func (s *service) GetMapForQuadrant(ctx context.Context, mapQuery eventModel.GetMapQueryParams) ([]eventModel.Event, error) {
-	return s.repo.GetMapForQuadrant(ctx, mapQuery)
+func (s *service) RemoveVote(ctx context.Context, userId uuid.UUID, eventId int64) error {
+	return s.voteRepo.DeleteVote(ctx, userId, eventId)
+}
+
+// fillViewerVotes sets MyVote on every event the viewer has voted on.
+func (s *service) fillViewerVotes(ctx context.Context, events []*eventModel.Event, viewer *uuid.UUID) error {
+	if viewer == nil || len(events) == 0 {
+		return nil
+	}
+
+	eventIds := make([]int64, 0, len(events))
+	for _, event := range events {
+		eventIds = append(eventIds, event.EventID)
+	}
+
+	votes, err := s.voteRepo.GetUserVotes(ctx, *viewer, eventIds)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		switch votes[event.EventID] {
+		case 1:
+			event.MyVote = UpVote
+		case -1:
+			event.MyVote = DownVote
+		}
+	}
+	return nil
+}
+
+// PrepareEvent validates the event and fills in defaults, every path that creates events goes through it
+func PrepareEvent(createEvent *eventModel.CreateEvent) error {
+	if err := checkEventDates(createEvent.StartDate, createEvent.EndDate); err != nil {
+		return err
+	}
+
+	if createEvent.Category == "" {
+		createEvent.Category = DefaultCategory
+	}
+	if !checkCategory(createEvent.Category) {
+		return errors.New("Incorrect category")
+	}
+
+	tags, err := normalizeTags(createEvent.Tags)
+	if err != nil {
+		return err
+	}
+	createEvent.Tags = tags
+
+	if createEvent.Visibility == "" {
+		createEvent.Visibility = eventModel.VisibilityPublic
+	}
+	if !checkVisibility(createEvent.Visibility) {
+		return errors.New("Incorrect visibility")
+	}
+	return nil
+}
+
+func checkEventDates(startDate time.Time, endDate *time.Time) error {
+	if startDate.IsZero() {
+		return errors.New("Missing start date")
+	}
+	if endDate == nil {
+		return nil
+	}
+	if !endDate.After(startDate) {
+		return errors.New("End date must be after start date")
+	}
+	if endDate.Sub(startDate) > eventModel.MaxDuration {
+		return errors.Errorf("Event can not last longer than %d days", int(eventModel.MaxDuration.Hours()/24))
+	}
+	return nil
+}
+
+func voteValue(vote string) (int, bool) {
+	switch vote {
+	case UpVote:
+		return 1, true
+	case DownVote:
+		return -1, true
+	default:
+		return 0, false
+	}
 }
@@ -0,0 +1,31 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	streamModel "github.com/quietguido/mapnu/mainservice/internal/repo/stream/model"
+)
+
+// HandleEventCancelled tells everyone with a pending or confirmed booking that the event is off
+func (s *service) HandleEventCancelled(ctx context.Context, message outboxModel.Message) error {
+	var event outboxModel.EventPayload
+	if err := json.Unmarshal(message.Payload, &event); err != nil {
+		return errors.Wrap(err, "Failed to decode event")
+	}
+
+	attendees, err := s.bookingRepo.GetAttendeeIds(ctx, event.EventID)
+	if err != nil {
+		return err
+	}
+
+	payload := streamModel.EventPayload{
+		EventID:   event.EventID,
+		Name:      event.Name,
+		StartDate: event.StartDate,
+	}
+	return s.streamRepo.AppendEvents(ctx, attendees, streamModel.TypeEventCancelled, payload, message.MessageID)
+}
@@ -0,0 +1,70 @@
+package event
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+)
+
+const (
+	DefaultCategory = "other"
+
+	MaxTags      = 10
+	MaxTagLength = 32
+)
+
+// Categories must stay in sync with the CHECK constraint on event.category
+var Categories = []string{
+	"music",
+	"sports",
+	"meetup",
+	"arts",
+	"food",
+	"education",
+	"tech",
+	"nightlife",
+	"family",
+	DefaultCategory,
+}
+
+func checkCategory(category string) bool {
+	return slices.Contains(Categories, category)
+}
+
+// normalizeTags lowercases, trims and deduplicates tags, dropping empty ones
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || slices.Contains(normalized, tag) {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, errors.Errorf("Tag %q is longer than %d characters", tag, MaxTagLength)
+		}
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > MaxTags {
+		return nil, errors.Errorf("Too many tags, at most %d allowed", MaxTags)
+	}
+	return normalized, nil
+}
+
+func normalizeFilters(filters eventModel.EventFilters) (eventModel.EventFilters, error) {
+	filters.Category = strings.ToLower(strings.TrimSpace(filters.Category))
+	if filters.Category != "" && !checkCategory(filters.Category) {
+		return filters, errors.New("Incorrect category")
+	}
+
+	tags, err := normalizeTags(filters.Tags)
+	if err != nil {
+		return filters, err
+	}
+	filters.Tags = tags
+	return filters, nil
+}
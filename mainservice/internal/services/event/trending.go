@@ -0,0 +1,91 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/ranking"
+	"github.com/quietguido/mapnu/mainservice/pkg/worker"
+)
+
+const (
+	DefaultTrendingLimit = 20
+	MaxTrendingLimit     = 100
+
+	// events that ended up to this long ago still get a trending score
+	trendingLookback = 24 * time.Hour
+)
+
+func (s *service) GetTrending(ctx context.Context, params eventModel.GetTrendingQueryParams, viewer *uuid.UUID) ([]eventModel.TrendingEvent, error) {
+	filters, err := normalizeFilters(params.EventFilters)
+	if err != nil {
+		return nil, &QueryError{Reason: err.Error()}
+	}
+	params.EventFilters = filters
+	params.Viewer = viewer
+
+	if !params.To.After(params.From) {
+		return nil, &QueryError{Reason: "Date range end must be after its start"}
+	}
+	if params.Limit <= 0 {
+		params.Limit = DefaultTrendingLimit
+	}
+	params.Limit = min(params.Limit, MaxTrendingLimit)
+
+	events, err := s.repo.GetTrending(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]*eventModel.Event, 0, len(events))
+	for i := range events {
+		refs = append(refs, &events[i].Event)
+	}
+
+	if err := s.fillViewerVotes(ctx, refs, viewer); err != nil {
+		return nil, err
+	}
+	if err := s.fillImages(ctx, refs); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// RefreshTrendingScores recomputes the cached score of every upcoming event.
+func (s *service) RefreshTrendingScores(ctx context.Context) error {
+	now := time.Now()
+	weights := ranking.DefaultWeights
+
+	candidates, err := s.repo.GetTrendingCandidates(ctx, now.Add(-trendingLookback), now.Add(-weights.VelocityWindow))
+	if err != nil {
+		return err
+	}
+
+	scores := make([]eventModel.TrendingScore, 0, len(candidates))
+	for _, candidate := range candidates {
+		scores = append(scores, eventModel.TrendingScore{
+			EventID: candidate.EventID,
+			Score: ranking.Score(ranking.Inputs{
+				Upvotes:            candidate.Upvote,
+				Downvotes:          candidate.Downvote,
+				RecentBookings:     candidate.RecentBookings,
+				Interested:         candidate.Interested,
+				CreatedAt:          candidate.CreatedAt,
+				RatingCount:        candidate.RatingCount,
+				RatingSum:          candidate.RatingSum,
+				CreatorRatingCount: candidate.CreatorRatingCount,
+				CreatorRatingSum:   candidate.CreatorRatingSum,
+			}, now, weights),
+		})
+	}
+
+	return s.repo.SaveTrendingScores(ctx, scores)
+}
+
+// RunTrendingRefresher refreshes trending scores every interval until ctx is done.
+func (s *service) RunTrendingRefresher(ctx context.Context, interval time.Duration) {
+	worker.Every(ctx, s.lg, interval, "refresh trending scores", s.RefreshTrendingScores)
+}
@@ -0,0 +1,26 @@
+package event
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+)
+
+// resolveVenue places an event referencing a venue at the venue's point, so every
+// event at the venue shares the same coordinates
+func (s *service) resolveVenue(ctx context.Context, createEvent *eventModel.CreateEvent) error {
+	if createEvent.VenueID == nil {
+		return nil
+	}
+
+	venue, err := s.venueRepo.GetVenueById(ctx, *createEvent.VenueID)
+	if err != nil {
+		return errors.Wrap(err, "Unknown venue")
+	}
+
+	createEvent.Location_lat = venue.Location_lat
+	createEvent.Location_lon = venue.Location_lon
+	return nil
+}
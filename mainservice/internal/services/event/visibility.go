@@ -0,0 +1,91 @@
+package event
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+)
+
+var ErrInviteOnly = errors.New("Event is invite only")
+
+func checkVisibility(visibility string) bool {
+	switch visibility {
+	case eventModel.VisibilityPublic, eventModel.VisibilityUnlisted, eventModel.VisibilityInviteOnly:
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckAccess lets anyone see and book public and unlisted events, invite-only events only
+// the creator, organizer members and invited users
+func CheckAccess(ctx context.Context, eventRepo repo.EventRepository, event *eventModel.Event, viewer *uuid.UUID) error {
+	if event.Visibility != eventModel.VisibilityInviteOnly {
+		return nil
+	}
+	if viewer == nil {
+		return ErrInviteOnly
+	}
+
+	allowed, err := eventRepo.HasAccess(ctx, event.EventID, *viewer)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrInviteOnly
+	}
+	return nil
+}
+
+// GetVisibleEvent loads the event for a feature hanging off it (comments, votes, reviews,
+// bookings) and refuses events the viewer could not open through GetEventById
+func GetVisibleEvent(ctx context.Context, eventRepo repo.EventRepository, eventId int64, viewer *uuid.UUID) (*eventModel.Event, error) {
+	event, err := eventRepo.GetEventById(ctx, int(eventId))
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckHidden(event, viewer); err != nil {
+		return nil, err
+	}
+	if err := CheckAccess(ctx, eventRepo, event, viewer); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// SetVisibility changes who can see a standalone event, occurrences follow their series
+func (s *service) SetVisibility(ctx context.Context, eventId int64, setVisibility eventModel.SetVisibility) error {
+	if !checkVisibility(setVisibility.Visibility) {
+		return errors.New("Incorrect visibility")
+	}
+
+	event, err := s.repo.GetEventById(ctx, int(eventId))
+	if err != nil {
+		return err
+	}
+	if event.SeriesID != nil {
+		return errors.New("Change the visibility of a series through the series")
+	}
+	if err := s.checkManage(ctx, event, setVisibility.UserID); err != nil {
+		return err
+	}
+
+	return s.repo.SetVisibility(ctx, eventId, setVisibility.Visibility)
+}
+
+// checkManage allows the event creator and members of the owning organizer who may manage its events
+func (s *service) checkManage(ctx context.Context, event *eventModel.Event, userId uuid.UUID) error {
+	if event.CreatedBy != nil && *event.CreatedBy == userId {
+		return nil
+	}
+	if event.OrganizerID != nil {
+		if err := s.checkOrganizerPermission(ctx, *event.OrganizerID, userId); err == nil {
+			return nil
+		}
+	}
+	return errors.New("Event does not belong to user")
+}
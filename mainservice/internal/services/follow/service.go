@@ -0,0 +1,118 @@
+package follow
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	followModel "github.com/quietguido/mapnu/mainservice/internal/repo/follow/model"
+	eventService "github.com/quietguido/mapnu/mainservice/internal/services/event"
+	"github.com/quietguido/mapnu/mainservice/pkg/cursor"
+)
+
+const (
+	DefaultFeedLimit = 20
+	MaxFeedLimit     = 100
+)
+
+type service struct {
+	lg        *zap.Logger
+	repo      repo.FollowRepository
+	eventRepo repo.EventRepository
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.FollowRepository,
+	eventRepo repo.EventRepository,
+) *service {
+	return &service{
+		lg:        lg,
+		repo:      repo,
+		eventRepo: eventRepo,
+	}
+}
+
+func (s *service) Follow(ctx context.Context, userId uuid.UUID, target string, targetId int64) error {
+	switch target {
+	case followModel.TargetOrganizer:
+		return s.repo.FollowOrganizer(ctx, userId, targetId)
+	case followModel.TargetVenue:
+		return s.repo.FollowVenue(ctx, userId, targetId)
+	default:
+		return errors.New("Incorrect follow target")
+	}
+}
+
+func (s *service) Unfollow(ctx context.Context, userId uuid.UUID, target string, targetId int64) error {
+	switch target {
+	case followModel.TargetOrganizer:
+		return s.repo.UnfollowOrganizer(ctx, userId, targetId)
+	case followModel.TargetVenue:
+		return s.repo.UnfollowVenue(ctx, userId, targetId)
+	default:
+		return errors.New("Incorrect follow target")
+	}
+}
+
+func (s *service) GetFollowing(ctx context.Context, userId uuid.UUID) (*followModel.Following, error) {
+	return s.repo.GetFollowing(ctx, userId)
+}
+
+// GetFeed returns a page of the user's feed, cursorStr is the next_cursor of the previous page
+func (s *service) GetFeed(ctx context.Context, userId uuid.UUID, cursorStr string, limit int) (*eventModel.FeedPage, error) {
+	if limit <= 0 {
+		limit = DefaultFeedLimit
+	}
+	limit = min(limit, MaxFeedLimit)
+
+	params := eventModel.GetFeedQueryParams{
+		UserID: userId,
+		Now:    time.Now(),
+		Limit:  limit + 1, // one extra row tells whether there is a next page
+	}
+	if cursorStr != "" {
+		var err error
+		params.AfterStart, params.AfterID, err = cursor.Decode(cursorStr)
+		if err != nil {
+			return nil, &eventService.QueryError{Reason: err.Error()}
+		}
+	}
+
+	items, err := s.eventRepo.GetFeed(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &eventModel.FeedPage{Items: []eventModel.FeedItem{}}
+	if len(items) > limit {
+		items = items[:limit]
+		last := items[limit-1]
+		page.NextCursor = cursor.Encode(last.StartDate, last.EventID)
+	}
+
+	for _, item := range items {
+		item.Reasons = feedReasons(item)
+		page.Items = append(page.Items, item)
+	}
+	return page, nil
+}
+
+func feedReasons(item eventModel.FeedItem) []string {
+	reasons := make([]string, 0, 3)
+	if item.ViaOrganizer {
+		reasons = append(reasons, eventModel.FeedReasonOrganizer)
+	}
+	if item.ViaVenue {
+		reasons = append(reasons, eventModel.FeedReasonVenue)
+	}
+	if len(item.Friends) > 0 {
+		reasons = append(reasons, eventModel.FeedReasonFriendBooking)
+	}
+	return reasons
+}
@@ -0,0 +1,285 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	imageModel "github.com/quietguido/mapnu/mainservice/internal/repo/image/model"
+	organizerModel "github.com/quietguido/mapnu/mainservice/internal/repo/organizer/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+	"github.com/quietguido/mapnu/mainservice/pkg/imaging"
+	"github.com/quietguido/mapnu/mainservice/pkg/storage"
+)
+
+const (
+	MaxUploadSize     = 10 << 20 // 10 MB
+	MaxImagesPerEvent = 10
+
+	// decoding is refused above these to keep memory bounded
+	MaxDimension = 8000
+	MaxPixels    = 40_000_000
+
+	jpegQuality = 85
+
+	defaultUploadsDir = "uploads"
+	defaultPublicURL  = "http://localhost:8080"
+
+	// UploadsPath is where the local storage is served from
+	UploadsPath = "/uploads/"
+)
+
+// accepted content types, sniffed from the data rather than trusting the client
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+type service struct {
+	lg            *zap.Logger
+	repo          repo.ImageRepository
+	eventRepo     repo.EventRepository
+	organizerRepo repo.OrganizerRepository
+	storage       storage.Storage
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.ImageRepository,
+	eventRepo repo.EventRepository,
+	organizerRepo repo.OrganizerRepository,
+	storage storage.Storage,
+) *service {
+	return &service{
+		lg:            lg,
+		repo:          repo,
+		eventRepo:     eventRepo,
+		organizerRepo: organizerRepo,
+		storage:       storage,
+	}
+}
+
+// NewStorage stores uploads in UPLOADS_DIR, served under PUBLIC_URL
+func NewStorage() storage.Storage {
+	dir, exists := os.LookupEnv("UPLOADS_DIR")
+	if !exists {
+		dir = defaultUploadsDir
+	}
+	publicURL, exists := os.LookupEnv("PUBLIC_URL")
+	if !exists {
+		publicURL = defaultPublicURL
+	}
+
+	store, err := storage.NewLocal(dir, strings.TrimSuffix(publicURL, "/")+UploadsPath)
+	assert.ErrorNil(err, "failed to init upload storage")
+	return store
+}
+
+// FileHandler serves the stored files when the storage can serve them itself, nil otherwise
+func (s *service) FileHandler() http.Handler {
+	handler, _ := s.storage.(http.Handler)
+	return handler
+}
+
+// Upload validates the image, strips its metadata and stores it with its thumbnails
+func (s *service) Upload(ctx context.Context, eventId int64, userId uuid.UUID, file io.Reader) (*imageModel.ImageURLs, error) {
+	if err := s.checkPermission(ctx, eventId, userId); err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountImages(ctx, eventId)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxImagesPerEvent {
+		return nil, errors.Errorf("Event already has %d images", MaxImagesPerEvent)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, MaxUploadSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read upload")
+	}
+	if len(data) > MaxUploadSize {
+		return nil, errors.New("Image is larger than 10 MB")
+	}
+
+	img, format, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// re-encoding from pixels drops EXIF and any other embedded metadata
+	original, err := encode(img, format)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := imageModel.Image{
+		EventID:    eventId,
+		UploadedBy: &userId,
+		StorageKey: fmt.Sprintf("events/%d/%s", eventId, randomName()),
+		Format:     format,
+		Width:      img.Rect.Dx(),
+		Height:     img.Rect.Dy(),
+		SizeBytes:  len(original),
+	}
+
+	files := map[string][]byte{stored.OriginalKey(): original}
+	for name, size := range imageModel.ThumbnailSizes {
+		thumbnail, err := encode(imaging.Fit(img, size), format)
+		if err != nil {
+			return nil, err
+		}
+		files[stored.ThumbnailKey(name)] = thumbnail
+	}
+
+	if err := s.putAll(ctx, files, "image/"+format); err != nil {
+		return nil, err
+	}
+
+	stored.ImageID, err = s.repo.CreateImage(ctx, stored)
+	if err != nil {
+		s.deleteFiles(ctx, stored)
+		return nil, err
+	}
+
+	urls := stored.URLs(s.storage.URL)
+	return &urls, nil
+}
+
+// Delete removes the image of the event together with its files
+func (s *service) Delete(ctx context.Context, eventId, imageId int64, userId uuid.UUID) error {
+	if err := s.checkPermission(ctx, eventId, userId); err != nil {
+		return err
+	}
+
+	image, err := s.repo.GetImageById(ctx, imageId)
+	if err != nil {
+		return err
+	}
+	if image.EventID != eventId {
+		return errors.New("Image does not belong to event")
+	}
+
+	if err := s.repo.DeleteImage(ctx, imageId); err != nil {
+		return err
+	}
+	s.deleteFiles(ctx, *image)
+	return nil
+}
+
+// checkPermission allows the event creator and organizer members who manage its events
+func (s *service) checkPermission(ctx context.Context, eventId int64, userId uuid.UUID) error {
+	event, err := s.eventRepo.GetEventById(ctx, int(eventId))
+	if err != nil {
+		return err
+	}
+	if event.CreatedBy != nil && *event.CreatedBy == userId {
+		return nil
+	}
+
+	if event.OrganizerID != nil {
+		role, err := s.organizerRepo.GetMemberRole(ctx, *event.OrganizerID, userId)
+		if err != nil {
+			return err
+		}
+		if organizerModel.RoleCan(role, organizerModel.PermissionManageEvents) {
+			return nil
+		}
+	}
+	return errors.New("User may not manage images of this event")
+}
+
+// putAll stores the files, removing the ones already written when one fails
+func (s *service) putAll(ctx context.Context, files map[string][]byte, contentType string) error {
+	var written []string
+	for key, data := range files {
+		if err := s.storage.Put(ctx, key, bytes.NewReader(data), contentType); err != nil {
+			for _, key := range written {
+				s.storage.Delete(ctx, key)
+			}
+			return err
+		}
+		written = append(written, key)
+	}
+	return nil
+}
+
+func (s *service) deleteFiles(ctx context.Context, image imageModel.Image) {
+	keys := []string{image.OriginalKey()}
+	for name := range imageModel.ThumbnailSizes {
+		keys = append(keys, image.ThumbnailKey(name))
+	}
+
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			s.lg.Error("Failed to delete image file", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// decode checks type and dimensions before decoding, turns JPEGs upright and
+// picks the stored format
+func decode(data []byte) (*image.NRGBA, string, error) {
+	contentType := http.DetectContentType(data)
+	if !allowedTypes[contentType] {
+		return nil, "", errors.New("Unsupported image type, expected JPEG, PNG or GIF")
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Invalid image")
+	}
+	if config.Width > MaxDimension || config.Height > MaxDimension || config.Width*config.Height > MaxPixels {
+		return nil, "", errors.Errorf("Image is larger than %dx%d pixels", MaxDimension, MaxDimension)
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "Invalid image")
+	}
+	img := imaging.ToNRGBA(decoded)
+
+	if contentType == "image/jpeg" {
+		return imaging.ApplyOrientation(img, imaging.Orientation(data)), imageModel.FormatJPEG, nil
+	}
+	// GIFs are stored as PNG, only their first frame is kept
+	return img, imageModel.FormatPNG, nil
+}
+
+func encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == imageModel.FormatPNG {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode image")
+	}
+	return buf.Bytes(), nil
+}
+
+// randomName keeps storage keys unguessable and unique per upload
+func randomName() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+)
+
+// decodeCSV reads a CSV file with a header row, header names are mapped to event fields
+func decodeCSV(file io.Reader, mapping map[string]string, loc *time.Location) ([]eventModel.ImportRow, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // short rows are reported per row
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read CSV header")
+	}
+
+	columns := make([]string, len(header))
+	for i, name := range header {
+		if target, ok := targetField(mapping, name); ok {
+			columns[i] = target
+		}
+	}
+
+	var rows []eventModel.ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to read CSV")
+		}
+		if len(rows) >= MaxRows {
+			return nil, errors.Errorf("Too many rows, at most %d allowed", MaxRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := eventModel.ImportRow{Line: line}
+		for i, value := range record {
+			if i >= len(columns) || columns[i] == "" {
+				continue
+			}
+			if err := setField(&row.Event, columns[i], value, loc); err != nil {
+				markInvalid(&row, err)
+				break
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
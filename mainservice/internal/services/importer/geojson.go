@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+)
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Type     string `json:"type"`
+	Geometry *struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// decodeGeoJSON reads a FeatureCollection of Point features, the location comes from
+// the geometry and the other fields from mapped properties
+func decodeGeoJSON(file io.Reader, mapping map[string]string, loc *time.Location) ([]eventModel.ImportRow, error) {
+	var collection featureCollection
+	if err := json.NewDecoder(file).Decode(&collection); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode GeoJSON")
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, errors.New("GeoJSON must be a FeatureCollection")
+	}
+	if len(collection.Features) > MaxRows {
+		return nil, errors.Errorf("Too many rows, at most %d allowed", MaxRows)
+	}
+
+	rows := make([]eventModel.ImportRow, 0, len(collection.Features))
+	for i, feature := range collection.Features {
+		row := eventModel.ImportRow{Line: i + 1}
+		if err := decodeFeature(&row.Event, feature, mapping, loc); err != nil {
+			markInvalid(&row, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func decodeFeature(event *eventModel.CreateEvent, feature feature, mapping map[string]string, loc *time.Location) error {
+	for name, value := range feature.Properties {
+		field, ok := targetField(mapping, name)
+		if !ok {
+			continue
+		}
+		if err := setField(event, field, propertyString(value), loc); err != nil {
+			return err
+		}
+	}
+
+	// the geometry wins over location properties
+	if feature.Geometry == nil || feature.Geometry.Type != "Point" || len(feature.Geometry.Coordinates) < 2 {
+		return errors.New("Feature geometry must be a Point")
+	}
+	event.Location_lon = feature.Geometry.Coordinates[0]
+	event.Location_lat = feature.Geometry.Coordinates[1]
+	return nil
+}
+
+func propertyString(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []any:
+		// tags given as a JSON array
+		var joined string
+		for i, item := range value {
+			if i > 0 {
+				joined += ";"
+			}
+			joined += propertyString(item)
+		}
+		return joined
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/ical"
+)
+
+// decodeICS reads every VEVENT. SUMMARY, DESCRIPTION, DTSTART, DTEND, GEO, ORGANIZER
+// and CATEGORIES are understood natively, other properties (X-...) can be mapped.
+func decodeICS(file io.Reader, mapping map[string]string, loc *time.Location) ([]eventModel.ImportRow, error) {
+	components, err := ical.DecodeEvents(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to decode iCalendar")
+	}
+	if len(components) > MaxRows {
+		return nil, errors.Errorf("Too many rows, at most %d allowed", MaxRows)
+	}
+
+	rows := make([]eventModel.ImportRow, 0, len(components))
+	for _, component := range components {
+		row := eventModel.ImportRow{Line: component.Line}
+		if err := decodeVEvent(&row.Event, component, mapping, loc); err != nil {
+			markInvalid(&row, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func decodeVEvent(event *eventModel.CreateEvent, component ical.Component, mapping map[string]string, loc *time.Location) error {
+	for _, property := range component.Properties {
+		var err error
+		switch property.Name {
+		case "SUMMARY":
+			event.Name = ical.UnescapeText(property.Value)
+		case "DESCRIPTION":
+			event.Description = ical.UnescapeText(property.Value)
+		case "DTSTART":
+			event.StartDate, err = ical.ParseTime(property, loc)
+		case "DTEND":
+			var end time.Time
+			end, err = ical.ParseTime(property, loc)
+			event.EndDate = &end
+		case "GEO":
+			err = parseGeo(event, property.Value)
+		case "ORGANIZER":
+			event.Organizer = organizerName(property)
+		case "CATEGORIES":
+			event.Tags = append(event.Tags, ical.SplitList(property.Value)...)
+		default:
+			if field, ok := mapping[strings.ToLower(property.Name)]; ok {
+				err = setField(event, field, ical.UnescapeText(property.Value), loc)
+			}
+		}
+		if err != nil {
+			return errors.Wrapf(err, "Invalid %s", property.Name)
+		}
+	}
+	return nil
+}
+
+// parseGeo reads "lat;lon"
+func parseGeo(event *eventModel.CreateEvent, value string) error {
+	latStr, lonStr, ok := strings.Cut(value, ";")
+	if !ok {
+		return errors.New("expected lat;lon")
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil {
+		return err
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil {
+		return err
+	}
+	event.Location_lat = lat
+	event.Location_lon = lon
+	return nil
+}
+
+// organizerName prefers the CN parameter over the mailto: address
+func organizerName(property ical.Property) string {
+	if cn := property.Params["CN"]; cn != "" {
+		return cn
+	}
+	value := property.Value
+	if len(value) >= len("mailto:") && strings.EqualFold(value[:len("mailto:")], "mailto:") {
+		value = value[len("mailto:"):]
+	}
+	return value
+}
@@ -0,0 +1,396 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/internal/services/event"
+)
+
+const (
+	MaxRows       = 5000
+	MaxUploadSize = 10 << 20 // 10 MB
+
+	defaultTimezone = "UTC"
+)
+
+// event fields a source column or property can be mapped to
+const (
+	fieldName        = "name"
+	fieldDescription = "description"
+	fieldLat         = "location_lat"
+	fieldLon         = "location_lon"
+	fieldStartDate   = "start_date"
+	fieldEndDate     = "end_date"
+	fieldOrganizer   = "organizer"
+	fieldCategory    = "category"
+	fieldTags        = "tags"
+)
+
+var fields = []string{
+	fieldName,
+	fieldDescription,
+	fieldLat,
+	fieldLon,
+	fieldStartDate,
+	fieldEndDate,
+	fieldOrganizer,
+	fieldCategory,
+	fieldTags,
+}
+
+// local date layouts accepted besides RFC3339, read in the request timezone
+var localDateLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+// Screener is the screening pipeline of the event service, imported rows go through it
+// like events created one by one
+type Screener interface {
+	ScreenEvent(ctx context.Context, createEvent *eventModel.CreateEvent) error
+	CheckBulkQuota(ctx context.Context, createdBy *uuid.UUID, count int) error
+	LockQuota(ctx context.Context, createdBy *uuid.UUID) (release func(), err error)
+}
+
+type service struct {
+	lg        *zap.Logger
+	eventRepo repo.EventRepository
+	screener  Screener
+}
+
+func InitService(
+	lg *zap.Logger,
+	eventRepo repo.EventRepository,
+	screener Screener,
+) *service {
+	return &service{
+		lg:        lg,
+		eventRepo: eventRepo,
+		screener:  screener,
+	}
+}
+
+// Import parses the file, validates every row and inserts the valid, non duplicate ones
+// unless the request is a dry run. Row level problems are reported in the result,
+// an error is only returned when the file as a whole can not be processed.
+func (s *service) Import(ctx context.Context, request eventModel.ImportRequest, file io.Reader) (*eventModel.ImportResult, error) {
+	if request.Timezone == "" {
+		request.Timezone = defaultTimezone
+	}
+	loc, err := time.LoadLocation(request.Timezone)
+	if err != nil {
+		return nil, errors.Wrap(err, "Incorrect timezone")
+	}
+
+	mapping, err := normalizeMapping(request.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []eventModel.ImportRow
+	switch strings.ToLower(request.Format) {
+	case eventModel.ImportFormatCSV:
+		rows, err = decodeCSV(file, mapping, loc)
+	case eventModel.ImportFormatGeoJSON:
+		rows, err = decodeGeoJSON(file, mapping, loc)
+	case eventModel.ImportFormatICS:
+		rows, err = decodeICS(file, mapping, loc)
+	default:
+		return nil, errors.New("Unsupported import format")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > MaxRows {
+		return nil, errors.Errorf("Too many rows, at most %d allowed", MaxRows)
+	}
+
+	result := &eventModel.ImportResult{
+		Format: strings.ToLower(request.Format),
+		DryRun: request.DryRun,
+		Total:  len(rows),
+		Rows:   rows,
+	}
+
+	s.validate(rows, request)
+
+	// held until the rows are inserted, duplicates and quota are checked against a stable count
+	release, err := s.screener.LockQuota(ctx, request.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := s.markDuplicates(ctx, rows); err != nil {
+		return nil, err
+	}
+	if err := s.screen(ctx, rows); err != nil {
+		return nil, err
+	}
+
+	var (
+		pending []eventModel.CreateEvent
+		indexes []int
+	)
+	for i, row := range rows {
+		switch row.Status {
+		case eventModel.ImportRowValid:
+			result.Valid++
+			if row.Held {
+				result.Held++
+			}
+			pending = append(pending, row.Event)
+			indexes = append(indexes, i)
+		case eventModel.ImportRowDuplicate:
+			result.Duplicates++
+		default:
+			result.Invalid++
+		}
+	}
+
+	// every row counts against the quota, so a dry run tells whether the import would go through
+	if err := s.screener.CheckBulkQuota(ctx, request.CreatedBy, len(pending)); err != nil {
+		return nil, err
+	}
+	if request.DryRun || len(pending) == 0 {
+		return result, nil
+	}
+
+	ids, err := s.eventRepo.CreateEvents(ctx, pending)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range indexes {
+		rows[i].Status = eventModel.ImportRowImported
+		rows[i].EventID = ids[j]
+	}
+	result.Imported = len(ids)
+
+	s.lg.Info("Imported events", zap.String("format", result.Format), zap.Int("imported", result.Imported))
+	return result, nil
+}
+
+// validate applies request defaults and the same rules as single event creation
+func (s *service) validate(rows []eventModel.ImportRow, request eventModel.ImportRequest) {
+	for i := range rows {
+		row := &rows[i]
+		if row.Status == eventModel.ImportRowInvalid {
+			continue
+		}
+
+		row.Event.CreatedBy = request.CreatedBy
+		if row.Event.Organizer == "" {
+			row.Event.Organizer = request.Organizer
+		}
+		if row.Event.Tags == nil {
+			row.Event.Tags = []string{}
+		}
+
+		if err := checkRequired(row.Event); err != nil {
+			markInvalid(row, err)
+			continue
+		}
+		if err := event.PrepareEvent(&row.Event); err != nil {
+			markInvalid(row, err)
+			continue
+		}
+		row.Status = eventModel.ImportRowValid
+	}
+}
+
+// markDuplicates flags rows repeating an earlier row of the file or an existing event
+func (s *service) markDuplicates(ctx context.Context, rows []eventModel.ImportRow) error {
+	seen := make(map[string]bool)
+
+	var (
+		candidates []eventModel.CreateEvent
+		indexes    []int
+	)
+	for i := range rows {
+		row := &rows[i]
+		if row.Status != eventModel.ImportRowValid {
+			continue
+		}
+
+		key := dedupKey(row.Event)
+		if seen[key] {
+			row.Status = eventModel.ImportRowDuplicate
+			row.Error = "Duplicate of an earlier row"
+			continue
+		}
+		seen[key] = true
+
+		candidates = append(candidates, row.Event)
+		indexes = append(indexes, i)
+	}
+
+	duplicates, err := s.eventRepo.FindDuplicates(ctx, candidates)
+	if err != nil {
+		return err
+	}
+	for j, i := range indexes {
+		if duplicates[j] {
+			rows[i].Status = eventModel.ImportRowDuplicate
+			rows[i].Error = "Event already exists"
+		}
+	}
+	return nil
+}
+
+// screen runs the valid rows through the screening pipeline, rejected rows become invalid
+// and suspicious ones are imported hidden and queued for moderation
+func (s *service) screen(ctx context.Context, rows []eventModel.ImportRow) error {
+	for i := range rows {
+		row := &rows[i]
+		if row.Status != eventModel.ImportRowValid {
+			continue
+		}
+
+		err := s.screener.ScreenEvent(ctx, &row.Event)
+		var rejected *event.RejectedError
+		if errors.As(err, &rejected) {
+			markInvalid(row, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		row.Held = row.Event.Hidden
+	}
+	return nil
+}
+
+func dedupKey(event eventModel.CreateEvent) string {
+	return fmt.Sprintf("%s|%d|%.4f|%.4f",
+		strings.ToLower(event.Name),
+		event.StartDate.Unix(),
+		event.Location_lon,
+		event.Location_lat,
+	)
+}
+
+func checkRequired(event eventModel.CreateEvent) error {
+	if strings.TrimSpace(event.Name) == "" {
+		return errors.New("Missing name")
+	}
+	if strings.TrimSpace(event.Organizer) == "" {
+		return errors.New("Missing organizer")
+	}
+	if event.Location_lat == 0 && event.Location_lon == 0 {
+		return errors.New("Missing location")
+	}
+	if event.Location_lat < -90 || event.Location_lat > 90 {
+		return errors.New("Latitude out of range")
+	}
+	if event.Location_lon < -180 || event.Location_lon > 180 {
+		return errors.New("Longitude out of range")
+	}
+	return nil
+}
+
+func markInvalid(row *eventModel.ImportRow, err error) {
+	row.Status = eventModel.ImportRowInvalid
+	row.Error = err.Error()
+}
+
+// normalizeMapping lowercases source names and checks every target is a known field
+func normalizeMapping(mapping map[string]string) (map[string]string, error) {
+	normalized := make(map[string]string, len(mapping))
+	for source, target := range mapping {
+		target = strings.ToLower(strings.TrimSpace(target))
+		if !isField(target) {
+			return nil, errors.Errorf("Unknown mapping target %q", target)
+		}
+		normalized[strings.ToLower(strings.TrimSpace(source))] = target
+	}
+	return normalized, nil
+}
+
+func isField(name string) bool {
+	return slices.Contains(fields, name)
+}
+
+// targetField resolves a source column or property to an event field, unmapped
+// sources are used as is when they already name a field
+func targetField(mapping map[string]string, source string) (string, bool) {
+	source = strings.ToLower(strings.TrimSpace(source))
+	if target, ok := mapping[source]; ok {
+		return target, true
+	}
+	return source, isField(source)
+}
+
+// setField parses a textual value into the event field
+func setField(event *eventModel.CreateEvent, field, value string, loc *time.Location) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	switch field {
+	case fieldName:
+		event.Name = value
+	case fieldDescription:
+		event.Description = value
+	case fieldOrganizer:
+		event.Organizer = value
+	case fieldCategory:
+		event.Category = strings.ToLower(value)
+	case fieldTags:
+		event.Tags = splitTags(value)
+	case fieldLat, fieldLon:
+		coordinate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.Errorf("Invalid %s", field)
+		}
+		if field == fieldLat {
+			event.Location_lat = coordinate
+		} else {
+			event.Location_lon = coordinate
+		}
+	case fieldStartDate, fieldEndDate:
+		date, err := parseDate(value, loc)
+		if err != nil {
+			return errors.Errorf("Invalid %s", field)
+		}
+		if field == fieldStartDate {
+			event.StartDate = date
+		} else {
+			event.EndDate = &date
+		}
+	}
+	return nil
+}
+
+func parseDate(value string, loc *time.Location) (time.Time, error) {
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, nil
+	}
+	for _, layout := range localDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, errors.New("Unknown date format")
+}
+
+// splitTags accepts tags separated by ";" or "|", commas are left to the CSV columns
+func splitTags(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ';' || r == '|'
+	})
+}
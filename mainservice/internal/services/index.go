@@ -2,28 +2,79 @@ package services
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"time"
+
 	"github.com/quietguido/mapnu/mainservice/internal/services/oauth"
 
 	"github.com/google/uuid"
 	"github.com/quietguido/mapnu/mainservice/internal/repo"
 	bookingModel "github.com/quietguido/mapnu/mainservice/internal/repo/booking/model"
+	commentModel "github.com/quietguido/mapnu/mainservice/internal/repo/comment/model"
 	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	followModel "github.com/quietguido/mapnu/mainservice/internal/repo/follow/model"
+	imageModel "github.com/quietguido/mapnu/mainservice/internal/repo/image/model"
+	inviteModel "github.com/quietguido/mapnu/mainservice/internal/repo/invite/model"
+	notificationModel "github.com/quietguido/mapnu/mainservice/internal/repo/notification/model"
+	organizerModel "github.com/quietguido/mapnu/mainservice/internal/repo/organizer/model"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	reportModel "github.com/quietguido/mapnu/mainservice/internal/repo/report/model"
+	reviewModel "github.com/quietguido/mapnu/mainservice/internal/repo/review/model"
+	savedSearchModel "github.com/quietguido/mapnu/mainservice/internal/repo/savedsearch/model"
+	streamModel "github.com/quietguido/mapnu/mainservice/internal/repo/stream/model"
 	userModel "github.com/quietguido/mapnu/mainservice/internal/repo/user/model"
+	venueModel "github.com/quietguido/mapnu/mainservice/internal/repo/venue/model"
+	voteModel "github.com/quietguido/mapnu/mainservice/internal/repo/vote/model"
+	webhookModel "github.com/quietguido/mapnu/mainservice/internal/repo/webhook/model"
 	"github.com/quietguido/mapnu/mainservice/internal/services/booking"
+	"github.com/quietguido/mapnu/mainservice/internal/services/bookmark"
+	"github.com/quietguido/mapnu/mainservice/internal/services/calendar"
+	"github.com/quietguido/mapnu/mainservice/internal/services/change"
+	"github.com/quietguido/mapnu/mainservice/internal/services/comment"
 	"github.com/quietguido/mapnu/mainservice/internal/services/event"
+	"github.com/quietguido/mapnu/mainservice/internal/services/follow"
+	"github.com/quietguido/mapnu/mainservice/internal/services/image"
+	"github.com/quietguido/mapnu/mainservice/internal/services/importer"
+	"github.com/quietguido/mapnu/mainservice/internal/services/invite"
+	"github.com/quietguido/mapnu/mainservice/internal/services/live"
+	"github.com/quietguido/mapnu/mainservice/internal/services/moderation"
+	"github.com/quietguido/mapnu/mainservice/internal/services/notification"
+	"github.com/quietguido/mapnu/mainservice/internal/services/organizer"
+	"github.com/quietguido/mapnu/mainservice/internal/services/outbox"
+	"github.com/quietguido/mapnu/mainservice/internal/services/reminder"
+	"github.com/quietguido/mapnu/mainservice/internal/services/review"
+	"github.com/quietguido/mapnu/mainservice/internal/services/savedsearch"
+	"github.com/quietguido/mapnu/mainservice/internal/services/stream"
 	"github.com/quietguido/mapnu/mainservice/internal/services/user"
+	"github.com/quietguido/mapnu/mainservice/internal/services/venue"
+	"github.com/quietguido/mapnu/mainservice/internal/services/webhook"
 	"go.uber.org/zap"
 )
 
 type EventService interface {
 	Create(ctx context.Context, createEvent eventModel.CreateEvent) (int, error)
-	GetEventById(ctx context.Context, eventId int) (*eventModel.Event, error)
-	GetMapForQuadrant(ctx context.Context, mapQuery eventModel.GetMapQueryParams) ([]eventModel.Event, error)
+	GetEventById(ctx context.Context, eventId int, viewer *uuid.UUID) (*eventModel.Event, error)
+	GetMapForQuadrant(ctx context.Context, mapQuery eventModel.GetMapQueryParams, viewer *uuid.UUID) ([]eventModel.Event, error)
+	Search(ctx context.Context, params eventModel.SearchQueryParams, viewer *uuid.UUID) ([]eventModel.Event, error)
+	Vote(ctx context.Context, setVote voteModel.SetVote) error
+	RemoveVote(ctx context.Context, userId uuid.UUID, eventId int64) error
+	SetVisibility(ctx context.Context, eventId int64, setVisibility eventModel.SetVisibility) error
+	GetTrending(ctx context.Context, params eventModel.GetTrendingQueryParams, viewer *uuid.UUID) ([]eventModel.TrendingEvent, error)
+	RunTrendingRefresher(ctx context.Context, interval time.Duration)
+	CreateSeries(ctx context.Context, createSeries eventModel.CreateSeries) (int64, error)
+	GetSeriesById(ctx context.Context, seriesId int64) (*eventModel.Series, error)
+	UpdateOccurrence(ctx context.Context, seriesId, eventId int64, update eventModel.UpdateOccurrence) error
+	UpdateSeries(ctx context.Context, seriesId int64, update eventModel.UpdateSeries) error
+	CancelOccurrence(ctx context.Context, seriesId, eventId int64, userId uuid.UUID) error
+	RunSeriesMaterializer(ctx context.Context, interval time.Duration)
+	AddScreeningCheck(check event.ScreeningCheck)
 }
 
 type UserService interface {
 	CreateUser(ctx context.Context, newUser userModel.CreateUser) error
 	GetUserById(ctx context.Context, userId string) (*userModel.User, error)
+	UpdateSettings(ctx context.Context, settings userModel.UpdateSettings) error
 }
 
 type BookingService interface {
@@ -34,6 +85,150 @@ type BookingService interface {
 	GetBookingApplicationsForOrganizer(ctx context.Context, userId uuid.UUID) ([]bookingModel.Booking, error)
 }
 
+// BookmarkService saves events users are interested in, bookmarks do not reserve a spot
+type BookmarkService interface {
+	Add(ctx context.Context, userId uuid.UUID, eventId int64) error
+	Remove(ctx context.Context, userId uuid.UUID, eventId int64) error
+	GetBookmarks(ctx context.Context, userId uuid.UUID, includePast bool, cursor string, limit int) (*eventModel.BookmarkPage, error)
+}
+
+// InviteService manages who may see and book invite-only events
+type InviteService interface {
+	Invite(ctx context.Context, eventId int64, inviteUsers inviteModel.InviteUsers) (int64, error)
+	RemoveInvite(ctx context.Context, eventId int64, userId, inviteeId uuid.UUID) error
+	GetInvites(ctx context.Context, eventId int64, userId uuid.UUID) ([]inviteModel.Invite, error)
+	CreateLink(ctx context.Context, eventId int64, createLink inviteModel.CreateLink) (*inviteModel.Link, error)
+	GetLinks(ctx context.Context, eventId int64, userId uuid.UUID) ([]inviteModel.Link, error)
+	RevokeLink(ctx context.Context, eventId, linkId int64, userId uuid.UUID) error
+	Redeem(ctx context.Context, eventId int64, redeem inviteModel.RedeemInvite) (*eventModel.Event, error)
+}
+
+type CalendarService interface {
+	EventICS(ctx context.Context, eventId int, viewer *uuid.UUID) ([]byte, error)
+	UserFeed(ctx context.Context, token string) ([]byte, error)
+	CreateFeedToken(ctx context.Context, userId uuid.UUID) (string, string, error)
+	RevokeFeedToken(ctx context.Context, userId uuid.UUID) error
+}
+
+type ImportService interface {
+	Import(ctx context.Context, request eventModel.ImportRequest, file io.Reader) (*eventModel.ImportResult, error)
+}
+
+type VenueService interface {
+	Create(ctx context.Context, createVenue venueModel.CreateVenue) (int64, error)
+	GetVenueById(ctx context.Context, venueId int64) (*venueModel.VenueWithEvents, error)
+	GetMapForQuadrant(ctx context.Context, params venueModel.GetVenuesMapQueryParams) ([]venueModel.VenueWithEvents, error)
+}
+
+type OrganizerService interface {
+	Create(ctx context.Context, createOrganizer organizerModel.CreateOrganizer) (int64, error)
+	GetOrganizerById(ctx context.Context, organizerId int64) (*organizerModel.OrganizerProfile, error)
+	Update(ctx context.Context, organizerId int64, update organizerModel.UpdateOrganizer) error
+	SetMember(ctx context.Context, organizerId int64, setMember organizerModel.SetMember) error
+	RemoveMember(ctx context.Context, organizerId int64, userId, memberId uuid.UUID) error
+}
+
+type FollowService interface {
+	Follow(ctx context.Context, userId uuid.UUID, target string, targetId int64) error
+	Unfollow(ctx context.Context, userId uuid.UUID, target string, targetId int64) error
+	GetFollowing(ctx context.Context, userId uuid.UUID) (*followModel.Following, error)
+	GetFeed(ctx context.Context, userId uuid.UUID, cursor string, limit int) (*eventModel.FeedPage, error)
+}
+
+type CommentService interface {
+	Create(ctx context.Context, createComment commentModel.CreateComment) (*commentModel.Comment, error)
+	Update(ctx context.Context, commentId int64, update commentModel.UpdateComment) error
+	Delete(ctx context.Context, commentId int64, userId uuid.UUID) error
+	Pin(ctx context.Context, commentId int64, pin commentModel.PinComment) error
+	GetThreads(ctx context.Context, eventId int64, viewer *uuid.UUID, cursor string, limit int) (*commentModel.ThreadPage, error)
+	GetReplies(ctx context.Context, commentId int64, viewer *uuid.UUID, cursor string, limit int) (*commentModel.ReplyPage, error)
+	AddModerationHook(hook comment.ModerationHook)
+}
+
+type ReviewService interface {
+	Create(ctx context.Context, createReview reviewModel.CreateReview) (int64, error)
+	GetReviewById(ctx context.Context, reviewId int64) (*reviewModel.Review, error)
+	Update(ctx context.Context, reviewId int64, update reviewModel.UpdateReview) error
+	Delete(ctx context.Context, reviewId int64, userId uuid.UUID) error
+	GetReviewsForEvent(ctx context.Context, eventId int64, viewer *uuid.UUID, cursor string, limit int) (*reviewModel.ReviewPage, error)
+}
+
+type ModerationService interface {
+	Report(ctx context.Context, createReport reportModel.CreateReport) (int64, error)
+	GetQueue(ctx context.Context, moderatorId uuid.UUID, params reportModel.GetQueueQueryParams, cursor string) (*reportModel.QueuePage, error)
+	Assign(ctx context.Context, reportId int64, assign reportModel.AssignReport) error
+	Resolve(ctx context.Context, reportId int64, resolve reportModel.ResolveReport) error
+	SetHidden(ctx context.Context, targetType, targetId string, setHidden reportModel.SetContentHidden) error
+}
+
+type ImageService interface {
+	Upload(ctx context.Context, eventId int64, userId uuid.UUID, file io.Reader) (*imageModel.ImageURLs, error)
+	Delete(ctx context.Context, eventId, imageId int64, userId uuid.UUID) error
+	FileHandler() http.Handler
+}
+
+type LiveService interface {
+	Subscribe() *live.Subscription
+	Run(ctx context.Context)
+}
+
+// ChangeBus delivers the changes committed by the repositories on any instance
+type ChangeBus interface {
+	OnEventChange(handler change.EventHandler)
+	OnSeriesChange(handler change.SeriesHandler)
+	OnBookingChange(handler change.BookingHandler)
+	Run(ctx context.Context)
+}
+
+type StreamService interface {
+	Subscribe(userId uuid.UUID) *stream.Subscription
+	Resume(ctx context.Context, userId uuid.UUID, lastEventId string) (int64, error)
+	GetEventsAfter(ctx context.Context, userId uuid.UUID, afterId int64) ([]streamModel.StreamEvent, error)
+	Run(ctx context.Context)
+}
+
+// Outbox delivers the messages the repositories enqueue with their domain changes
+type Outbox interface {
+	Handle(topic, name string, handler outbox.Handler)
+	Run(ctx context.Context)
+	GetMessages(ctx context.Context, adminId uuid.UUID, params outboxModel.GetMessagesQueryParams, cursor string) (*outboxModel.MessagePage, error)
+	GetMessage(ctx context.Context, adminId uuid.UUID, messageId int64) (*outboxModel.Message, error)
+	Replay(ctx context.Context, adminId uuid.UUID, messageId int64) error
+	ReplayDead(ctx context.Context, replay outboxModel.ReplayMessages) (int64, error)
+}
+
+type NotificationService interface {
+	Send(ctx context.Context, n notification.Notification) error
+	GetPreferences(ctx context.Context, userId uuid.UUID) ([]notificationModel.Preference, error)
+	SetPreference(ctx context.Context, setPreference notificationModel.SetPreference) error
+	RegisterPushDevice(ctx context.Context, device notificationModel.RegisterPushDevice) (int64, error)
+	RemovePushDevice(ctx context.Context, userId uuid.UUID, token string) error
+	GetDeliveries(ctx context.Context, userId uuid.UUID, cursor string, limit int) (*notificationModel.DeliveryPage, error)
+}
+
+type ReminderService interface {
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// WebhookService sends booking and event changes to the endpoints organizers subscribe
+type WebhookService interface {
+	Create(ctx context.Context, organizerId int64, createWebhook webhookModel.CreateWebhook) (*webhookModel.CreatedWebhook, error)
+	GetWebhooks(ctx context.Context, organizerId int64, userId uuid.UUID) ([]webhookModel.Webhook, error)
+	Update(ctx context.Context, organizerId, webhookId int64, update webhookModel.UpdateWebhook) error
+	Delete(ctx context.Context, organizerId, webhookId int64, userId uuid.UUID) error
+	GetDeliveries(ctx context.Context, organizerId, webhookId int64, userId uuid.UUID, cursor string, limit int) (*webhookModel.DeliveryPage, error)
+	SendTest(ctx context.Context, organizerId, webhookId int64, userId uuid.UUID) (*webhookModel.Delivery, error)
+	Run(ctx context.Context)
+}
+
+type SavedSearchService interface {
+	Create(ctx context.Context, save savedSearchModel.SaveSearch) (int64, error)
+	GetSearches(ctx context.Context, userId uuid.UUID) ([]savedSearchModel.SavedSearch, error)
+	Update(ctx context.Context, searchId int64, save savedSearchModel.SaveSearch) error
+	Delete(ctx context.Context, searchId int64, userId uuid.UUID) error
+	Run(ctx context.Context, interval time.Duration)
+}
+
 type OAuthService interface {
 	VerifyIDToken(ctx context.Context, idToken string) (*oauth.Claims, error)
 	GenerateJWT(email, givenName, familyName string) (string, error)
@@ -41,21 +236,186 @@ type OAuthService interface {
 }
 
 type Service struct {
-	Event   EventService
-	User    UserService
-	Booking BookingService
-	OAuth   OAuthService
+	Event        EventService
+	User         UserService
+	Booking      BookingService
+	Bookmark     BookmarkService
+	OAuth        OAuthService
+	Calendar     CalendarService
+	Import       ImportService
+	Venue        VenueService
+	Organizer    OrganizerService
+	Follow       FollowService
+	Comment      CommentService
+	Review       ReviewService
+	Moderation   ModerationService
+	Image        ImageService
+	Live         LiveService
+	Stream       StreamService
+	Change       ChangeBus
+	Outbox       Outbox
+	Notification NotificationService
+	Reminder     ReminderService
+	Webhook      WebhookService
+	SavedSearch  SavedSearchService
+	Invite       InviteService
 }
 
 func InitServices(lg *zap.Logger, repos *repo.Repositories) *Service {
+	uploads := image.NewStorage()
+
+	changeBus := change.InitService(lg, repos.Notify)
+	liveMap := live.InitService(lg, repos.Event)
+	changeBus.OnEventChange(liveMap.HandleEventChange)
+	changeBus.OnSeriesChange(liveMap.HandleSeriesChange)
+
+	events := event.InitService(
+		lg,
+		repos.Event,
+		repos.Vote,
+		repos.Venue,
+		repos.Organizer,
+		repos.Image,
+		repos.Booking,
+		repos.Stream,
+		repos.Lock,
+		uploads,
+	)
+
+	bookings := booking.InitService(
+		lg,
+		repos.Booking,
+		repos.Event,
+		repos.Organizer,
+		repos.Stream,
+	)
+
+	dispatcher := outbox.InitService(
+		lg,
+		repos.Outbox,
+		repos.Notify,
+		repos.User,
+	)
+
+	notifications := notification.InitService(
+		lg,
+		repos.Notification,
+		repos.User,
+		repos.Event,
+		repos.Booking,
+		notification.NewMailer(),
+		notification.NewPushProvider(lg),
+	)
+
+	dispatcher.Handle(outboxModel.TopicBookingCreated, "stream", bookings.HandleBookingCreated)
+	dispatcher.Handle(outboxModel.TopicBookingStatusChanged, "stream", bookings.HandleBookingStatusChanged)
+	dispatcher.Handle(outboxModel.TopicBookingStatusChanged, "notification", notifications.HandleBookingStatusChanged)
+	dispatcher.Handle(outboxModel.TopicEventUpdated, "notification", notifications.HandleEventUpdated)
+	dispatcher.Handle(outboxModel.TopicEventCancelled, "stream", events.HandleEventCancelled)
+	dispatcher.Handle(outboxModel.TopicEventCancelled, "notification", notifications.HandleEventCancelled)
+	dispatcher.Handle(outboxModel.TopicEventReminder, "notification", notifications.HandleEventReminder)
+	dispatcher.Handle(outboxModel.TopicSearchDigest, "notification", notifications.HandleSearchDigest)
+
+	savedSearches := savedsearch.InitService(
+		lg,
+		repos.SavedSearch,
+		repos.Lock,
+	)
+	dispatcher.Handle(outboxModel.TopicEventCreated, "saved_search", savedSearches.HandleEventPublished)
+	dispatcher.Handle(outboxModel.TopicEventPublished, "saved_search", savedSearches.HandleEventPublished)
+
+	webhooks := webhook.InitService(
+		lg,
+		repos.Webhook,
+		repos.Organizer,
+		repos.Event,
+	)
+	for _, topic := range webhookModel.SubscribableEvents {
+		dispatcher.Handle(topic, "webhook", webhooks.HandleOutbox)
+	}
+
 	return &Service{
-		Event: event.InitService(lg, repos.Event),
-		User:  user.InitService(lg, repos.User),
-		Booking: booking.InitService(
+		Event:   events,
+		User:    user.InitService(lg, repos.User),
+		Booking: bookings,
+		Bookmark: bookmark.InitService(
 			lg,
-			repos.Booking,
+			repos.Bookmark,
 			repos.Event,
 		),
 		OAuth: oauth.NewOAuthService(lg),
+		Calendar: calendar.InitService(
+			lg,
+			repos.Calendar,
+			repos.Event,
+			repos.Booking,
+			repos.User,
+		),
+		Import: importer.InitService(lg, repos.Event, events),
+		Venue: venue.InitService(
+			lg,
+			repos.Venue,
+			repos.Event,
+		),
+		Organizer: organizer.InitService(
+			lg,
+			repos.Organizer,
+			repos.Event,
+		),
+		Follow: follow.InitService(
+			lg,
+			repos.Follow,
+			repos.Event,
+		),
+		Comment: comment.InitService(
+			lg,
+			repos.Comment,
+			repos.Event,
+			repos.Organizer,
+			repos.User,
+		),
+		Review: review.InitService(
+			lg,
+			repos.Review,
+			repos.Booking,
+			repos.Event,
+		),
+		Moderation: moderation.InitService(
+			lg,
+			repos.Report,
+			repos.Event,
+			repos.Comment,
+			repos.User,
+		),
+		Image: image.InitService(
+			lg,
+			repos.Image,
+			repos.Event,
+			repos.Organizer,
+			uploads,
+		),
+		Live: liveMap,
+		Stream: stream.InitService(
+			lg,
+			repos.Stream,
+			repos.Notify,
+		),
+		Change:       changeBus,
+		Outbox:       dispatcher,
+		Notification: notifications,
+		Reminder: reminder.InitService(
+			lg,
+			repos.Reminder,
+			repos.Lock,
+		),
+		Webhook:     webhooks,
+		SavedSearch: savedSearches,
+		Invite: invite.InitService(
+			lg,
+			repos.Invite,
+			repos.Event,
+			repos.Organizer,
+			repos.User,
+		),
 	}
 }
@@ -0,0 +1,261 @@
+package invite
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	inviteModel "github.com/quietguido/mapnu/mainservice/internal/repo/invite/model"
+	organizerModel "github.com/quietguido/mapnu/mainservice/internal/repo/organizer/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/invite"
+)
+
+const (
+	MaxInviteesPerRequest = 500
+	MaxActiveLinks        = 20
+
+	defaultPublicURL = "http://localhost:8080"
+)
+
+type service struct {
+	lg            *zap.Logger
+	repo          repo.InviteRepository
+	eventRepo     repo.EventRepository
+	organizerRepo repo.OrganizerRepository
+	userRepo      repo.UserRepository
+	signer        *invite.Signer
+	publicURL     string
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.InviteRepository,
+	eventRepo repo.EventRepository,
+	organizerRepo repo.OrganizerRepository,
+	userRepo repo.UserRepository,
+) *service {
+	// links have to stay valid across restarts and instances, so the secret is never generated
+	// here, and it is never shared with another purpose like signing sessions
+	secret, exists := os.LookupEnv("INVITE_SECRET")
+	if !exists || secret == "" {
+		lg.Fatal("INVITE_SECRET is missing")
+	}
+
+	publicURL, exists := os.LookupEnv("PUBLIC_URL")
+	if !exists {
+		publicURL = defaultPublicURL
+	}
+
+	return &service{
+		lg:            lg,
+		repo:          repo,
+		eventRepo:     eventRepo,
+		organizerRepo: organizerRepo,
+		userRepo:      userRepo,
+		signer:        invite.NewSigner([]byte(secret)),
+		publicURL:     strings.TrimSuffix(publicURL, "/"),
+	}
+}
+
+// Invite puts users on the event's invite list and returns how many were newly invited
+func (s *service) Invite(ctx context.Context, eventId int64, inviteUsers inviteModel.InviteUsers) (int64, error) {
+	if err := s.checkManage(ctx, eventId, inviteUsers.UserID); err != nil {
+		return 0, err
+	}
+
+	if len(inviteUsers.InviteeIDs)+len(inviteUsers.Usernames) == 0 {
+		return 0, errors.New("Missing invitees")
+	}
+	if len(inviteUsers.InviteeIDs)+len(inviteUsers.Usernames) > MaxInviteesPerRequest {
+		return 0, errors.Errorf("At most %d users can be invited at once", MaxInviteesPerRequest)
+	}
+
+	invitees := make([]uuid.UUID, 0, len(inviteUsers.InviteeIDs)+len(inviteUsers.Usernames))
+	invitees = append(invitees, inviteUsers.InviteeIDs...)
+
+	if len(inviteUsers.Usernames) > 0 {
+		users, err := s.userRepo.GetUsersByUsernames(ctx, inviteUsers.Usernames)
+		if err != nil {
+			return 0, err
+		}
+		if len(users) < len(unique(inviteUsers.Usernames)) {
+			return 0, errors.New("Unknown username")
+		}
+		for _, user := range users {
+			invitees = append(invitees, user.ID)
+		}
+	}
+
+	return s.repo.AddInvites(ctx, eventId, inviteUsers.UserID, unique(invitees))
+}
+
+func (s *service) RemoveInvite(ctx context.Context, eventId int64, userId, inviteeId uuid.UUID) error {
+	if err := s.checkManage(ctx, eventId, userId); err != nil {
+		return err
+	}
+	return s.repo.RemoveInvite(ctx, eventId, inviteeId)
+}
+
+func (s *service) GetInvites(ctx context.Context, eventId int64, userId uuid.UUID) ([]inviteModel.Invite, error) {
+	if err := s.checkManage(ctx, eventId, userId); err != nil {
+		return nil, err
+	}
+	return s.repo.GetInvites(ctx, eventId)
+}
+
+// CreateLink creates an invite link with its signed token and short code
+func (s *service) CreateLink(ctx context.Context, eventId int64, createLink inviteModel.CreateLink) (*inviteModel.Link, error) {
+	if err := s.checkManage(ctx, eventId, createLink.UserID); err != nil {
+		return nil, err
+	}
+
+	if createLink.MaxUses != nil && *createLink.MaxUses <= 0 {
+		return nil, errors.New("Max uses must be positive")
+	}
+	if createLink.ExpiresAt != nil && !createLink.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("Expiry must be in the future")
+	}
+
+	active, err := s.repo.CountActiveLinks(ctx, eventId)
+	if err != nil {
+		return nil, err
+	}
+	if active >= MaxActiveLinks {
+		return nil, errors.Errorf("An event can have at most %d active invite links", MaxActiveLinks)
+	}
+
+	code, err := invite.NewCode()
+	if err != nil {
+		return nil, err
+	}
+	createLink.EventID = eventId
+	createLink.Code = code
+
+	link, err := s.repo.CreateLink(ctx, createLink)
+	if err != nil {
+		return nil, err
+	}
+	s.fillLink(link)
+	return link, nil
+}
+
+func (s *service) GetLinks(ctx context.Context, eventId int64, userId uuid.UUID) ([]inviteModel.Link, error) {
+	if err := s.checkManage(ctx, eventId, userId); err != nil {
+		return nil, err
+	}
+
+	links, err := s.repo.GetLinks(ctx, eventId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range links {
+		s.fillLink(&links[i])
+	}
+	return links, nil
+}
+
+func (s *service) RevokeLink(ctx context.Context, eventId, linkId int64, userId uuid.UUID) error {
+	if err := s.checkManage(ctx, eventId, userId); err != nil {
+		return err
+	}
+	return s.repo.RevokeLink(ctx, eventId, linkId)
+}
+
+// Redeem puts the user on the invite list with a link token or code, which lets them see
+// and book the event. It returns the event.
+func (s *service) Redeem(ctx context.Context, eventId int64, redeem inviteModel.RedeemInvite) (*eventModel.Event, error) {
+	if redeem.UserID == uuid.Nil {
+		return nil, errors.New("Missing user ID")
+	}
+
+	link, err := s.resolveLink(ctx, strings.TrimSpace(redeem.Invite))
+	if err != nil {
+		return nil, err
+	}
+	if link.EventID != eventId {
+		return nil, errors.New("Invite is for another event")
+	}
+
+	event, err := s.eventRepo.GetEventById(ctx, int(eventId))
+	if err != nil {
+		return nil, err
+	}
+	if event.Hidden {
+		return nil, errors.New("Event is hidden by moderation")
+	}
+
+	if err := s.repo.RedeemLink(ctx, link.LinkID, redeem.UserID, time.Now()); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// resolveLink finds the link of a signed token or a code
+func (s *service) resolveLink(ctx context.Context, code string) (*inviteModel.Link, error) {
+	if code == "" {
+		return nil, errors.New("Missing invite")
+	}
+	if !invite.IsToken(code) {
+		return s.repo.GetLinkByCode(ctx, invite.NormalizeCode(code))
+	}
+
+	eventId, linkId, err := s.signer.Verify(code)
+	if err != nil {
+		return nil, err
+	}
+	link, err := s.repo.GetLinkById(ctx, linkId)
+	if err != nil {
+		return nil, err
+	}
+	if link.EventID != eventId {
+		return nil, invite.ErrInvalidToken
+	}
+	return link, nil
+}
+
+func (s *service) fillLink(link *inviteModel.Link) {
+	link.Token = s.signer.Sign(link.EventID, link.LinkID)
+	link.URL = fmt.Sprintf("%s/event/%d?invite=%s", s.publicURL, link.EventID, link.Token)
+}
+
+// checkManage allows the event creator and members of the owning organizer who may manage its events
+func (s *service) checkManage(ctx context.Context, eventId int64, userId uuid.UUID) error {
+	event, err := s.eventRepo.GetEventById(ctx, int(eventId))
+	if err != nil {
+		return err
+	}
+	if event.CreatedBy != nil && *event.CreatedBy == userId {
+		return nil
+	}
+	if event.OrganizerID != nil {
+		role, err := s.organizerRepo.GetMemberRole(ctx, *event.OrganizerID, userId)
+		if err != nil {
+			return err
+		}
+		if organizerModel.RoleCan(role, organizerModel.PermissionManageEvents) {
+			return nil
+		}
+	}
+	return errors.New("User may not manage invites of this event")
+}
+
+func unique[T comparable](values []T) []T {
+	seen := make(map[T]struct{}, len(values))
+	result := make([]T, 0, len(values))
+	for _, value := range values {
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		result = append(result, value)
+	}
+	return result
+}
@@ -0,0 +1,161 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	changeModel "github.com/quietguido/mapnu/mainservice/internal/repo/change/model"
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+)
+
+// SendBuffer is how many messages a subscriber may fall behind before it has to resync
+const SendBuffer = 64
+
+// resyncMessage replaces the backlog of a subscriber that could not keep up,
+// the client refetches GET /map for its viewport
+var resyncMessage = []byte(`{"type":"resync"}`)
+
+type service struct {
+	lg            *zap.Logger
+	eventRepo     repo.EventRepository
+	mu            sync.RWMutex
+	subscriptions map[*Subscription]struct{}
+	stopped       bool
+}
+
+func InitService(lg *zap.Logger, eventRepo repo.EventRepository) *service {
+	return &service{
+		lg:            lg,
+		eventRepo:     eventRepo,
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// Run closes all subscriptions once ctx is done
+func (s *service) Run(ctx context.Context) {
+	<-ctx.Done()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	for sub := range s.subscriptions {
+		sub.stop()
+	}
+	clear(s.subscriptions)
+}
+
+// Subscribe registers a subscriber, it receives nothing until it sets a viewport
+func (s *service) Subscribe() *Subscription {
+	sub := &Subscription{
+		messages: make(chan []byte, SendBuffer),
+		done:     make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		sub.stop()
+		return sub
+	}
+	s.subscriptions[sub] = struct{}{}
+	sub.unsubscribe = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscriptions, sub)
+	}
+	return sub
+}
+
+// HandleEventChange turns the change into a delta for the subscribers looking at the event.
+// The event is only loaded when someone is.
+func (s *service) HandleEventChange(ctx context.Context, change changeModel.EventChange) {
+	delta := eventModel.MapDelta{
+		EventID:      change.EventID,
+		SeriesID:     change.SeriesID,
+		Location_lat: change.Location_lat,
+		Location_lon: change.Location_lon,
+		StartDate:    change.StartDate,
+		EndDate:      change.EndDate,
+	}
+	if !s.watched(delta) {
+		return
+	}
+
+	switch change.Type {
+	case changeModel.EventCancelled, changeModel.EventHidden:
+		delta.Type = eventModel.DeltaCancelled
+		s.dispatch(delta)
+		return
+	case changeModel.EventCreated, changeModel.EventRestored:
+		delta.Type = eventModel.DeltaCreated
+	case changeModel.EventUpdated:
+		delta.Type = eventModel.DeltaUpdated
+	case changeModel.EventVotes:
+		delta.Type = eventModel.DeltaVotes
+	default:
+		return
+	}
+
+	event, err := s.eventRepo.GetEventById(ctx, int(change.EventID))
+	if err != nil {
+		s.lg.Error("Failed to load event for map delta", zap.Int64("event_id", change.EventID), zap.Error(err))
+		return
+	}
+	if event.Hidden {
+		return
+	}
+	// the live map is anonymous, events that stop being public are taken off it
+	if event.Visibility != eventModel.VisibilityPublic {
+		delta.Type = eventModel.DeltaCancelled
+		s.dispatch(delta)
+		return
+	}
+	s.dispatch(eventModel.NewMapDelta(delta.Type, *event))
+}
+
+// HandleSeriesChange tells the subscribers looking at the series to refetch the map
+func (s *service) HandleSeriesChange(_ context.Context, change changeModel.SeriesChange) {
+	s.dispatch(eventModel.MapDelta{
+		Type:         eventModel.DeltaRefresh,
+		SeriesID:     &change.SeriesID,
+		Location_lat: change.Location_lat,
+		Location_lon: change.Location_lon,
+		StartDate:    change.From,
+		EndDate:      &change.Until,
+	})
+}
+
+func (s *service) watched(delta eventModel.MapDelta) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for sub := range s.subscriptions {
+		if sub.wants(delta) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *service) dispatch(delta eventModel.MapDelta) {
+	message, err := json.Marshal(delta)
+	if err != nil {
+		s.lg.Error("Failed to encode map delta", zap.Error(err))
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for sub := range s.subscriptions {
+		if sub.wants(delta) {
+			sub.send(message)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package live
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+)
+
+// MaxViewportRange bounds the time range of a viewport like the map queries do
+const MaxViewportRange = 366 * 24 * time.Hour
+
+// Subscription receives the map deltas of one client's viewport
+type Subscription struct {
+	messages    chan []byte
+	done        chan struct{}
+	unsubscribe func()
+	stopOnce    sync.Once
+
+	mu       sync.Mutex
+	viewport *eventModel.Viewport
+}
+
+// Messages are the JSON encoded deltas for the viewport
+func (sub *Subscription) Messages() <-chan []byte {
+	return sub.messages
+}
+
+// Done is closed when the subscription ends, either by Close or by shutdown
+func (sub *Subscription) Done() <-chan struct{} {
+	return sub.done
+}
+
+// SetViewport replaces the viewport, deltas outside of it are no longer delivered
+func (sub *Subscription) SetViewport(viewport eventModel.Viewport) error {
+	for _, lat := range []float64{viewport.FirstQuadLat, viewport.SecondQuadLat} {
+		if lat < -90 || lat > 90 {
+			return errors.New("Latitude must be between -90 and 90")
+		}
+	}
+	for _, lon := range []float64{viewport.FirstQuadLon, viewport.SecondQuadLon} {
+		if lon < -180 || lon > 180 {
+			return errors.New("Longitude must be between -180 and 180")
+		}
+	}
+	if !viewport.To.After(viewport.From) {
+		return errors.New("Date range end must be after its start")
+	}
+	if viewport.To.Sub(viewport.From) > MaxViewportRange {
+		return errors.New("Date range must not exceed a year")
+	}
+
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	sub.viewport = &viewport
+	return nil
+}
+
+// Close ends the subscription
+func (sub *Subscription) Close() {
+	if sub.unsubscribe != nil {
+		sub.unsubscribe()
+	}
+	sub.stop()
+}
+
+func (sub *Subscription) stop() {
+	sub.stopOnce.Do(func() {
+		close(sub.done)
+	})
+}
+
+func (sub *Subscription) wants(delta eventModel.MapDelta) bool {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	return sub.viewport != nil && sub.viewport.Contains(delta)
+}
+
+// send never blocks the dispatcher: a subscriber with a full buffer loses its
+// backlog and is told to resync instead. dispatch is the only sender.
+func (sub *Subscription) send(message []byte) {
+	select {
+	case sub.messages <- message:
+		return
+	default:
+	}
+
+drain:
+	for {
+		select {
+		case <-sub.messages:
+		default:
+			break drain
+		}
+	}
+	select {
+	case sub.messages <- resyncMessage:
+	default:
+	}
+}
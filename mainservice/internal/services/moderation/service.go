@@ -0,0 +1,246 @@
+package moderation
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	commentModel "github.com/quietguido/mapnu/mainservice/internal/repo/comment/model"
+	reportModel "github.com/quietguido/mapnu/mainservice/internal/repo/report/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/cursor"
+)
+
+const (
+	MaxDetailsLength = 1000
+
+	DefaultQueueLimit = 50
+	MaxQueueLimit     = 200
+)
+
+type service struct {
+	lg          *zap.Logger
+	repo        repo.ReportRepository
+	eventRepo   repo.EventRepository
+	commentRepo repo.CommentRepository
+	userRepo    repo.UserRepository
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.ReportRepository,
+	eventRepo repo.EventRepository,
+	commentRepo repo.CommentRepository,
+	userRepo repo.UserRepository,
+) *service {
+	return &service{
+		lg:          lg,
+		repo:        repo,
+		eventRepo:   eventRepo,
+		commentRepo: commentRepo,
+		userRepo:    userRepo,
+	}
+}
+
+// Report files a report on an event, comment or user
+func (s *service) Report(ctx context.Context, createReport reportModel.CreateReport) (int64, error) {
+	createReport.Reason = strings.ToLower(strings.TrimSpace(createReport.Reason))
+	if !slices.Contains(reportModel.Reasons, createReport.Reason) {
+		return 0, errors.Errorf("Unknown reason, expected one of %s", strings.Join(reportModel.Reasons, ", "))
+	}
+
+	createReport.Details = strings.TrimSpace(createReport.Details)
+	if utf8.RuneCountInString(createReport.Details) > MaxDetailsLength {
+		return 0, errors.Errorf("Details are longer than %d characters", MaxDetailsLength)
+	}
+
+	targetId, err := s.checkTarget(ctx, createReport.TargetType, createReport.TargetID)
+	if err != nil {
+		return 0, err
+	}
+	createReport.TargetID = targetId
+
+	return s.repo.CreateReport(ctx, createReport)
+}
+
+// GetQueue returns a page of reports for moderators
+func (s *service) GetQueue(ctx context.Context, moderatorId uuid.UUID, params reportModel.GetQueueQueryParams, cursorStr string) (*reportModel.QueuePage, error) {
+	if err := s.requireModerator(ctx, moderatorId); err != nil {
+		return nil, err
+	}
+
+	switch params.Status {
+	case "":
+		params.Status = reportModel.StatusOpen
+	case reportModel.StatusOpen, reportModel.StatusResolved, reportModel.StatusDismissed:
+	default:
+		return nil, errors.New("Unknown report status")
+	}
+
+	if params.Limit <= 0 {
+		params.Limit = DefaultQueueLimit
+	}
+	limit := min(params.Limit, MaxQueueLimit)
+	params.Limit = limit + 1 // one extra row tells whether there is a next page
+
+	if cursorStr != "" {
+		var err error
+		params.AfterCreated, params.AfterID, err = cursor.Decode(cursorStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reports, err := s.repo.GetQueue(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &reportModel.QueuePage{Reports: []reportModel.Report{}}
+	if len(reports) > limit {
+		reports = reports[:limit]
+		last := reports[limit-1]
+		page.NextCursor = cursor.Encode(last.CreatedAt, last.ReportID)
+	}
+	page.Reports = append(page.Reports, reports...)
+	return page, nil
+}
+
+// Assign hands the report to a moderator, or back to the queue when no assignee is given
+func (s *service) Assign(ctx context.Context, reportId int64, assign reportModel.AssignReport) error {
+	if err := s.requireModerator(ctx, assign.ModeratorID); err != nil {
+		return err
+	}
+	if assign.AssigneeID != nil {
+		if err := s.requireModerator(ctx, *assign.AssigneeID); err != nil {
+			return errors.Wrap(err, "Assignee")
+		}
+	}
+
+	return s.repo.AssignReport(ctx, reportId, assign.AssigneeID)
+}
+
+// Resolve closes all open reports on the report's target, hiding the content when asked to
+func (s *service) Resolve(ctx context.Context, reportId int64, resolve reportModel.ResolveReport) error {
+	if err := s.requireModerator(ctx, resolve.ModeratorID); err != nil {
+		return err
+	}
+
+	report, err := s.repo.GetReportById(ctx, reportId)
+	if err != nil {
+		return err
+	}
+	if report.Status != reportModel.StatusOpen {
+		return errors.New("Report is already closed")
+	}
+
+	status := reportModel.StatusDismissed
+	if resolve.Hide {
+		status = reportModel.StatusResolved
+	}
+
+	closed, err := s.repo.ResolveTarget(ctx, report.TargetType, report.TargetID, resolve.Hide, status, resolve.ModeratorID, strings.TrimSpace(resolve.Note))
+	if err != nil {
+		return err
+	}
+
+	s.lg.Info("Reports closed",
+		zap.String("target_type", report.TargetType),
+		zap.String("target_id", report.TargetID),
+		zap.String("status", status),
+		zap.Int64("reports", closed),
+	)
+	return nil
+}
+
+// SetHidden hides or restores content directly, e.g. comments held by moderation hooks
+func (s *service) SetHidden(ctx context.Context, targetType, targetId string, setHidden reportModel.SetContentHidden) error {
+	if err := s.requireModerator(ctx, setHidden.ModeratorID); err != nil {
+		return err
+	}
+
+	targetId, err := s.checkTarget(ctx, targetType, targetId)
+	if err != nil {
+		return err
+	}
+	return s.setHidden(ctx, targetType, targetId, setHidden.Hidden)
+}
+
+func (s *service) setHidden(ctx context.Context, targetType, targetId string, hidden bool) error {
+	switch targetType {
+	case reportModel.TargetEvent:
+		eventId, err := strconv.ParseInt(targetId, 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "Invalid event ID")
+		}
+		return s.eventRepo.SetEventHidden(ctx, eventId, hidden)
+	case reportModel.TargetComment:
+		commentId, err := strconv.ParseInt(targetId, 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "Invalid comment ID")
+		}
+		status := commentModel.StatusVisible
+		if hidden {
+			status = commentModel.StatusHidden
+		}
+		return s.commentRepo.SetStatus(ctx, commentId, status)
+	case reportModel.TargetUser:
+		return s.userRepo.SetUserHidden(ctx, targetId, hidden)
+	default:
+		return errors.New("Unknown report target")
+	}
+}
+
+// checkTarget makes sure the target exists and returns its id in canonical form
+func (s *service) checkTarget(ctx context.Context, targetType, targetId string) (string, error) {
+	targetId = strings.TrimSpace(targetId)
+
+	switch targetType {
+	case reportModel.TargetEvent:
+		eventId, err := strconv.Atoi(targetId)
+		if err != nil {
+			return "", errors.New("Invalid event ID")
+		}
+		if _, err := s.eventRepo.GetEventById(ctx, eventId); err != nil {
+			return "", errors.Wrap(err, "Unknown event")
+		}
+		return strconv.Itoa(eventId), nil
+	case reportModel.TargetComment:
+		commentId, err := strconv.ParseInt(targetId, 10, 64)
+		if err != nil {
+			return "", errors.New("Invalid comment ID")
+		}
+		if _, err := s.commentRepo.GetCommentById(ctx, commentId); err != nil {
+			return "", errors.Wrap(err, "Unknown comment")
+		}
+		return strconv.FormatInt(commentId, 10), nil
+	case reportModel.TargetUser:
+		userId, err := uuid.Parse(targetId)
+		if err != nil {
+			return "", errors.New("Invalid user ID")
+		}
+		if _, err := s.userRepo.GetUserById(ctx, userId.String()); err != nil {
+			return "", errors.Wrap(err, "Unknown user")
+		}
+		return userId.String(), nil
+	default:
+		return "", errors.New("Unknown report target")
+	}
+}
+
+func (s *service) requireModerator(ctx context.Context, userId uuid.UUID) error {
+	user, err := s.userRepo.GetUserById(ctx, userId.String())
+	if err != nil {
+		return err
+	}
+	if !user.IsModerator() {
+		return errors.New("User is not a moderator")
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package notification
+
+import (
+	"context"
+	dbsql "database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	changeModel "github.com/quietguido/mapnu/mainservice/internal/repo/change/model"
+	notificationModel "github.com/quietguido/mapnu/mainservice/internal/repo/notification/model"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	reminderModel "github.com/quietguido/mapnu/mainservice/internal/repo/reminder/model"
+	savedSearchModel "github.com/quietguido/mapnu/mainservice/internal/repo/savedsearch/model"
+)
+
+const (
+	ConfirmedBookingStatus = "confirmed"
+	RejectedBookingStatus  = "rejected"
+)
+
+// EventData is what the templates of the event and booking types render
+type EventData struct {
+	EventID   int64
+	Name      string
+	StartDate time.Time
+	URL       string
+}
+
+// DigestData is what the saved search digest templates render
+type DigestData struct {
+	Events []DigestEventData
+	More   int // events left out
+	Total  int
+}
+
+type DigestEventData struct {
+	EventData
+	SearchName string
+}
+
+func (s *service) eventData(eventId int64, name string, startDate time.Time) EventData {
+	return EventData{
+		EventID:   eventId,
+		Name:      name,
+		StartDate: startDate,
+		URL:       fmt.Sprintf("%s/event/%d", s.publicURL, eventId),
+	}
+}
+
+// HandleBookingStatusChanged tells the attendee that the booking was confirmed or rejected
+func (s *service) HandleBookingStatusChanged(ctx context.Context, message outboxModel.Message) error {
+	var booking changeModel.BookingChange
+	if err := json.Unmarshal(message.Payload, &booking); err != nil {
+		return errors.Wrap(err, "Failed to decode booking")
+	}
+
+	var notificationType string
+	switch booking.Status {
+	case ConfirmedBookingStatus:
+		notificationType = notificationModel.TypeBookingConfirmed
+	case RejectedBookingStatus:
+		notificationType = notificationModel.TypeBookingRejected
+	default:
+		return nil
+	}
+
+	event, err := s.eventRepo.GetEventById(ctx, int(booking.EventID))
+	if errors.Is(err, dbsql.ErrNoRows) {
+		return nil // the event is gone, its cancellation was sent instead
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.Send(ctx, Notification{
+		UserID:    booking.UserID,
+		Type:      notificationType,
+		DedupeKey: outboxDedupeKey(message),
+		Data:      s.eventData(event.EventID, event.Name, event.StartDate),
+		PushData:  map[string]string{"event_id": strconv.FormatInt(event.EventID, 10)},
+	})
+}
+
+// HandleEventUpdated tells everyone with a pending or confirmed booking about the change
+func (s *service) HandleEventUpdated(ctx context.Context, message outboxModel.Message) error {
+	return s.notifyAttendees(ctx, message, notificationModel.TypeEventUpdated)
+}
+
+// HandleEventCancelled tells everyone with a pending or confirmed booking that the event is off
+func (s *service) HandleEventCancelled(ctx context.Context, message outboxModel.Message) error {
+	return s.notifyAttendees(ctx, message, notificationModel.TypeEventCancelled)
+}
+
+// HandleEventReminder reminds the attendee of the upcoming event
+func (s *service) HandleEventReminder(ctx context.Context, message outboxModel.Message) error {
+	var reminder reminderModel.Reminder
+	if err := json.Unmarshal(message.Payload, &reminder); err != nil {
+		return errors.Wrap(err, "Failed to decode reminder")
+	}
+
+	return s.Send(ctx, Notification{
+		UserID:    reminder.UserID,
+		Type:      notificationModel.TypeEventReminder,
+		DedupeKey: outboxDedupeKey(message),
+		Data:      s.eventData(reminder.EventID, reminder.Name, reminder.StartDate),
+		PushData:  map[string]string{"event_id": strconv.FormatInt(reminder.EventID, 10)},
+	})
+}
+
+// HandleSearchDigest sends the user's batch of new events matching their saved searches
+func (s *service) HandleSearchDigest(ctx context.Context, message outboxModel.Message) error {
+	var digest savedSearchModel.Digest
+	if err := json.Unmarshal(message.Payload, &digest); err != nil {
+		return errors.Wrap(err, "Failed to decode digest")
+	}
+
+	data := DigestData{
+		Events: make([]DigestEventData, 0, len(digest.Events)),
+		More:   digest.More,
+		Total:  len(digest.Events) + digest.More,
+	}
+	for _, event := range digest.Events {
+		data.Events = append(data.Events, DigestEventData{
+			EventData:  s.eventData(event.EventID, event.Name, event.StartDate),
+			SearchName: event.SearchName,
+		})
+	}
+
+	return s.Send(ctx, Notification{
+		UserID:    digest.UserID,
+		Type:      notificationModel.TypeSearchDigest,
+		DedupeKey: outboxDedupeKey(message),
+		Data:      data,
+		PushData:  map[string]string{"screen": "saved_searches"},
+	})
+}
+
+func (s *service) notifyAttendees(ctx context.Context, message outboxModel.Message, notificationType string) error {
+	var event outboxModel.EventPayload
+	if err := json.Unmarshal(message.Payload, &event); err != nil {
+		return errors.Wrap(err, "Failed to decode event")
+	}
+
+	attendees, err := s.bookingRepo.GetAttendeeIds(ctx, event.EventID)
+	if err != nil {
+		return err
+	}
+
+	// everyone is tried, the ones already notified are skipped on the retry
+	var firstErr error
+	for _, userId := range attendees {
+		err := s.Send(ctx, Notification{
+			UserID:    userId,
+			Type:      notificationType,
+			DedupeKey: outboxDedupeKey(message),
+			Data:      s.eventData(event.EventID, event.Name, event.StartDate),
+			PushData:  map[string]string{"event_id": strconv.FormatInt(event.EventID, 10)},
+		})
+		if err != nil && firstErr == nil {
+			firstErr = errors.Wrapf(err, "User %s", userId)
+		}
+	}
+	return firstErr
+}
+
+func outboxDedupeKey(message outboxModel.Message) string {
+	return "outbox:" + strconv.FormatInt(message.MessageID, 10)
+}
@@ -0,0 +1,116 @@
+package notification
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	notificationModel "github.com/quietguido/mapnu/mainservice/internal/repo/notification/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/cursor"
+)
+
+const (
+	MaxTokenLength = 512
+
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// GetPreferences returns the preference of every notification type, defaults included
+func (s *service) GetPreferences(ctx context.Context, userId uuid.UUID) ([]notificationModel.Preference, error) {
+	stored, err := s.repo.GetPreferences(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make([]notificationModel.Preference, 0, len(notificationModel.Types))
+	for _, notificationType := range notificationModel.Types {
+		preference := notificationModel.DefaultPreference(userId, notificationType)
+		if i := slices.IndexFunc(stored, func(p notificationModel.Preference) bool { return p.Type == notificationType }); i >= 0 {
+			preference = stored[i]
+		}
+		preferences = append(preferences, preference)
+	}
+	return preferences, nil
+}
+
+func (s *service) SetPreference(ctx context.Context, setPreference notificationModel.SetPreference) error {
+	if !slices.Contains(notificationModel.Types, setPreference.Type) {
+		return errors.Errorf("Unknown notification type, expected one of %v", notificationModel.Types)
+	}
+
+	return s.repo.SetPreference(ctx, notificationModel.Preference{
+		UserID: setPreference.UserID,
+		Type:   setPreference.Type,
+		Email:  setPreference.Email,
+		Push:   setPreference.Push,
+	})
+}
+
+// preference returns the user's preference for the type
+func (s *service) preference(ctx context.Context, userId uuid.UUID, notificationType string) (notificationModel.Preference, error) {
+	stored, err := s.repo.GetPreferences(ctx, userId)
+	if err != nil {
+		return notificationModel.Preference{}, err
+	}
+	for _, preference := range stored {
+		if preference.Type == notificationType {
+			return preference, nil
+		}
+	}
+	return notificationModel.DefaultPreference(userId, notificationType), nil
+}
+
+func (s *service) RegisterPushDevice(ctx context.Context, device notificationModel.RegisterPushDevice) (int64, error) {
+	device.Token = strings.TrimSpace(device.Token)
+	if device.Token == "" {
+		return 0, errors.New("Missing push token")
+	}
+	if len(device.Token) > MaxTokenLength {
+		return 0, errors.Errorf("Push token is longer than %d characters", MaxTokenLength)
+	}
+	if !slices.Contains(notificationModel.Platforms, device.Platform) {
+		return 0, errors.Errorf("Unknown platform, expected one of %v", notificationModel.Platforms)
+	}
+
+	return s.repo.AddPushDevice(ctx, device)
+}
+
+func (s *service) RemovePushDevice(ctx context.Context, userId uuid.UUID, token string) error {
+	return s.repo.DeletePushDevice(ctx, userId, token)
+}
+
+// GetDeliveries returns a page of the user's delivery log, newest first
+func (s *service) GetDeliveries(ctx context.Context, userId uuid.UUID, cursorStr string, limit int) (*notificationModel.DeliveryPage, error) {
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	limit = min(limit, MaxPageLimit)
+
+	// one extra row tells whether there is a next page
+	params := notificationModel.GetDeliveriesQueryParams{Limit: limit + 1}
+	if cursorStr != "" {
+		var err error
+		params.BeforeCreated, params.BeforeID, err = cursor.Decode(cursorStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	deliveries, err := s.repo.GetDeliveries(ctx, userId, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &notificationModel.DeliveryPage{Deliveries: []notificationModel.Delivery{}}
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+		last := deliveries[limit-1]
+		page.NextCursor = cursor.Encode(last.CreatedAt, last.DeliveryID)
+	}
+	page.Deliveries = append(page.Deliveries, deliveries...)
+	return page, nil
+}
@@ -0,0 +1,237 @@
+package notification
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	notificationModel "github.com/quietguido/mapnu/mainservice/internal/repo/notification/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+	"github.com/quietguido/mapnu/mainservice/pkg/mail"
+	"github.com/quietguido/mapnu/mainservice/pkg/push"
+)
+
+const (
+	// a local SMTP sink such as Mailpit, see NewMailer
+	defaultSMTPHost  = "localhost"
+	defaultSMTPPort  = "1025"
+	defaultSMTPFrom  = "MapNu <no-reply@mapnu.local>"
+	defaultPublicURL = "http://localhost:8080"
+)
+
+// Notification is sent to one user on every channel the user keeps on for its type
+type Notification struct {
+	UserID    uuid.UUID
+	Type      string            // one of notificationModel.Types
+	DedupeKey string            // a notification is sent at most once per key and recipient
+	Data      any               // rendered by the templates of the type
+	PushData  map[string]string // handed to the app with the push
+}
+
+type service struct {
+	lg          *zap.Logger
+	repo        repo.NotificationRepository
+	userRepo    repo.UserRepository
+	eventRepo   repo.EventRepository
+	bookingRepo repo.BookingReposity
+	mailer      mail.Sender
+	pusher      push.Provider
+	templates   templates
+	publicURL   string
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.NotificationRepository,
+	userRepo repo.UserRepository,
+	eventRepo repo.EventRepository,
+	bookingRepo repo.BookingReposity,
+	mailer mail.Sender,
+	pusher push.Provider,
+) *service {
+	publicURL, exists := os.LookupEnv("PUBLIC_URL")
+	if !exists {
+		publicURL = defaultPublicURL
+	}
+
+	return &service{
+		lg:          lg,
+		repo:        repo,
+		userRepo:    userRepo,
+		eventRepo:   eventRepo,
+		bookingRepo: bookingRepo,
+		mailer:      mailer,
+		pusher:      pusher,
+		templates:   loadTemplates(),
+		publicURL:   strings.TrimSuffix(publicURL, "/"),
+	}
+}
+
+// NewMailer sends through SMTP_HOST:SMTP_PORT, which defaults to a local sink on localhost:1025
+func NewMailer() mail.Sender {
+	host, exists := os.LookupEnv("SMTP_HOST")
+	if !exists {
+		host = defaultSMTPHost
+	}
+	port, exists := os.LookupEnv("SMTP_PORT")
+	if !exists {
+		port = defaultSMTPPort
+	}
+	from, exists := os.LookupEnv("SMTP_FROM")
+	if !exists {
+		from = defaultSMTPFrom
+	}
+
+	mailer, err := mail.NewSMTP(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+	assert.ErrorNil(err, "failed to init mailer")
+	return mailer
+}
+
+// NewPushProvider returns the provider named by PUSH_PROVIDER, the fake is the only one so far
+func NewPushProvider(lg *zap.Logger) push.Provider {
+	switch provider := os.Getenv("PUSH_PROVIDER"); provider {
+	case "", "fake":
+		return push.NewFake(lg)
+	default:
+		panic("unknown push provider " + provider)
+	}
+}
+
+// Send renders the notification for the user and delivers it on every channel
+// the user's preference keeps on, logging each delivery. Recipients that already
+// got the notification are skipped, so a failed Send can be retried as a whole.
+func (s *service) Send(ctx context.Context, n Notification) error {
+	user, err := s.userRepo.GetUserById(ctx, n.UserID.String())
+	if err != nil {
+		return err
+	}
+
+	preference, err := s.preference(ctx, n.UserID, n.Type)
+	if err != nil {
+		return err
+	}
+
+	msg, err := s.templates.render(user, n.Type, n.Data)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if preference.Email {
+		errs = append(errs, s.sendEmail(ctx, n, user.Email, msg))
+	} else {
+		errs = append(errs, s.logSkipped(ctx, n, notificationModel.ChannelEmail, msg))
+	}
+
+	if preference.Push {
+		errs = append(errs, s.sendPushes(ctx, n, msg))
+	} else {
+		errs = append(errs, s.logSkipped(ctx, n, notificationModel.ChannelPush, msg))
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) sendEmail(ctx context.Context, n Notification, to string, msg *rendered) error {
+	sent, err := s.repo.WasSent(ctx, n.DedupeKey, notificationModel.ChannelEmail, to)
+	if err != nil || sent {
+		return err
+	}
+
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      to,
+		Subject: msg.Subject,
+		Body:    msg.Body,
+	})
+	if logErr := s.logDelivery(ctx, n, notificationModel.ChannelEmail, to, msg, err); logErr != nil {
+		return logErr
+	}
+	if errors.Is(err, mail.ErrInvalidAddress) {
+		return nil // retrying won't fix the address
+	}
+	return errors.Wrap(err, "Failed to send email")
+}
+
+func (s *service) sendPushes(ctx context.Context, n Notification, msg *rendered) error {
+	devices, err := s.repo.GetPushDevices(ctx, n.UserID)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, device := range devices {
+		sent, err := s.repo.WasSent(ctx, n.DedupeKey, notificationModel.ChannelPush, device.Token)
+		if err != nil {
+			return err
+		}
+		if sent {
+			continue
+		}
+
+		err = s.pusher.Send(ctx, device.Token, push.Message{
+			Title: msg.Subject,
+			Body:  msg.Push,
+			Data:  n.PushData,
+		})
+		if logErr := s.logDelivery(ctx, n, notificationModel.ChannelPush, device.Token, msg, err); logErr != nil {
+			return logErr
+		}
+
+		switch {
+		case errors.Is(err, push.ErrInvalidToken):
+			// the app was uninstalled or the token rotated
+			if err := s.repo.DeletePushDevice(ctx, n.UserID, device.Token); err != nil {
+				s.lg.Error("Failed to drop invalid push device", zap.Int64("device_id", device.DeviceID), zap.Error(err))
+			}
+		case err != nil && firstErr == nil:
+			firstErr = errors.Wrap(err, "Failed to send push")
+		}
+	}
+	return firstErr
+}
+
+func (s *service) logDelivery(ctx context.Context, n Notification, channel, recipient string, msg *rendered, sendErr error) error {
+	delivery := notificationModel.Delivery{
+		UserID:    n.UserID,
+		Type:      n.Type,
+		Channel:   channel,
+		Recipient: recipient,
+		DedupeKey: n.DedupeKey,
+		Status:    notificationModel.StatusSent,
+		Subject:   msg.Subject,
+	}
+	if sendErr != nil {
+		errStr := sendErr.Error()
+		delivery.Status = notificationModel.StatusFailed
+		delivery.Error = &errStr
+		s.lg.Warn("Notification delivery failed",
+			zap.String("user_id", n.UserID.String()),
+			zap.String("type", n.Type),
+			zap.String("channel", channel),
+			zap.Error(sendErr),
+		)
+	}
+	return s.repo.LogDelivery(ctx, delivery)
+}
+
+// logSkipped records that the user turned the channel off, once per notification
+func (s *service) logSkipped(ctx context.Context, n Notification, channel string, msg *rendered) error {
+	return s.repo.LogDelivery(ctx, notificationModel.Delivery{
+		UserID:    n.UserID,
+		Type:      n.Type,
+		Channel:   channel,
+		DedupeKey: n.DedupeKey,
+		Status:    notificationModel.StatusSkipped,
+		Subject:   msg.Subject,
+	})
+}
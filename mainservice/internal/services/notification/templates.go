@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	notificationModel "github.com/quietguido/mapnu/mainservice/internal/repo/notification/model"
+	userModel "github.com/quietguido/mapnu/mainservice/internal/repo/user/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+)
+
+// templates/<locale>/<type>.tmpl define "subject", "body" (plain text email) and "push"
+//
+//go:embed templates
+var templateFS embed.FS
+
+const defaultLocale = "en"
+
+// Go only knows english month names, so other locales use numeric dates
+var dateLayouts = map[string]string{
+	"en": "Mon, Jan 2 2006 at 15:04 MST",
+	"ru": "02.01.2006 в 15:04 MST",
+}
+
+// templateData is what the templates are executed with
+type templateData struct {
+	User *userModel.User
+	Data any
+}
+
+type rendered struct {
+	Subject string
+	Body    string
+	Push    string
+}
+
+type templates map[string]*template.Template // by "<locale>/<type>"
+
+// loadTemplates parses the templates of every type in every locale, a missing one fails startup
+func loadTemplates() templates {
+	t := make(templates)
+	for _, locale := range userModel.Locales {
+		for _, notificationType := range notificationModel.Types {
+			name := path.Join("templates", locale, notificationType+".tmpl")
+			tmpl, err := template.New(path.Base(name)).
+				Option("missingkey=error").
+				Funcs(template.FuncMap{"date": func(time.Time) string { return "" }}).
+				ParseFS(templateFS, name)
+			assert.ErrorNil(err, "failed to parse notification template")
+			t[locale+"/"+notificationType] = tmpl
+		}
+	}
+	return t
+}
+
+// render executes the templates of the type in the user's locale, dates are shown in the user's timezone
+func (t templates) render(user *userModel.User, notificationType string, data any) (*rendered, error) {
+	locale := user.Locale
+	tmpl, ok := t[locale+"/"+notificationType]
+	if !ok {
+		locale = defaultLocale
+		if tmpl, ok = t[locale+"/"+notificationType]; !ok {
+			return nil, errors.Errorf("No template for notification type %q", notificationType)
+		}
+	}
+
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	tmpl, err = tmpl.Clone()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to clone template")
+	}
+	tmpl.Funcs(template.FuncMap{
+		"date": func(t time.Time) string { return t.In(loc).Format(dateLayouts[locale]) },
+	})
+
+	input := templateData{User: user, Data: data}
+	var out rendered
+	for name, dst := range map[string]*string{"subject": &out.Subject, "body": &out.Body, "push": &out.Push} {
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, name, input); err != nil {
+			return nil, errors.Wrapf(err, "Failed to render %s of %s", name, notificationType)
+		}
+		*dst = strings.TrimSpace(buf.String())
+	}
+	return &out, nil
+}
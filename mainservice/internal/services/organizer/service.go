@@ -0,0 +1,174 @@
+package organizer
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	organizerModel "github.com/quietguido/mapnu/mainservice/internal/repo/organizer/model"
+)
+
+const (
+	UpcomingEventsLimit = 20
+)
+
+type service struct {
+	lg        *zap.Logger
+	repo      repo.OrganizerRepository
+	eventRepo repo.EventRepository
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.OrganizerRepository,
+	eventRepo repo.EventRepository,
+) *service {
+	return &service{
+		lg:        lg,
+		repo:      repo,
+		eventRepo: eventRepo,
+	}
+}
+
+func (s *service) Create(ctx context.Context, createOrganizer organizerModel.CreateOrganizer) (int64, error) {
+	createOrganizer.Name = strings.TrimSpace(createOrganizer.Name)
+	if createOrganizer.Name == "" {
+		return 0, errors.New("Missing name")
+	}
+
+	return s.repo.CreateOrganizer(ctx, createOrganizer)
+}
+
+// GetOrganizerById returns the public profile with members and upcoming events
+func (s *service) GetOrganizerById(ctx context.Context, organizerId int64) (*organizerModel.OrganizerProfile, error) {
+	organizer, err := s.repo.GetOrganizerById(ctx, organizerId)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.repo.GetMembers(ctx, organizerId)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.eventRepo.GetUpcomingForOrganizer(ctx, organizerId, time.Now(), UpcomingEventsLimit)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []eventModel.Event{}
+	}
+
+	return &organizerModel.OrganizerProfile{
+		Organizer:      *organizer,
+		Members:        members,
+		UpcomingEvents: events,
+	}, nil
+}
+
+func (s *service) Update(ctx context.Context, organizerId int64, update organizerModel.UpdateOrganizer) error {
+	if _, err := s.requirePermission(ctx, organizerId, update.UserID, organizerModel.PermissionEditProfile); err != nil {
+		return err
+	}
+
+	update.Name = strings.TrimSpace(update.Name)
+	if update.Name == "" {
+		return errors.New("Missing name")
+	}
+
+	return s.repo.UpdateOrganizer(ctx, organizerId, update)
+}
+
+// SetMember adds a member or changes their role. Only owners may grant or take away ownership.
+func (s *service) SetMember(ctx context.Context, organizerId int64, setMember organizerModel.SetMember) error {
+	if !organizerModel.CheckRole(setMember.Role) {
+		return errors.New("Incorrect role")
+	}
+
+	actorRole, err := s.requirePermission(ctx, organizerId, setMember.UserID, organizerModel.PermissionManageMembers)
+	if err != nil {
+		return err
+	}
+
+	currentRole, err := s.repo.GetMemberRole(ctx, organizerId, setMember.MemberID)
+	if err != nil {
+		return err
+	}
+
+	if (setMember.Role == organizerModel.RoleOwner || currentRole == organizerModel.RoleOwner) && actorRole != organizerModel.RoleOwner {
+		return errors.New("Only owners may change ownership")
+	}
+	if currentRole == organizerModel.RoleOwner && setMember.Role != organizerModel.RoleOwner {
+		if err := s.checkNotLastOwner(ctx, organizerId); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.SetMember(ctx, organizerId, setMember.MemberID, setMember.Role)
+}
+
+// RemoveMember removes a member, members may always leave on their own
+func (s *service) RemoveMember(ctx context.Context, organizerId int64, userId, memberId uuid.UUID) error {
+	currentRole, err := s.repo.GetMemberRole(ctx, organizerId, memberId)
+	if err != nil {
+		return err
+	}
+	if currentRole == "" {
+		return errors.New("User is not a member")
+	}
+
+	if userId != memberId {
+		actorRole, err := s.requirePermission(ctx, organizerId, userId, organizerModel.PermissionManageMembers)
+		if err != nil {
+			return err
+		}
+		if currentRole == organizerModel.RoleOwner && actorRole != organizerModel.RoleOwner {
+			return errors.New("Only owners may remove owners")
+		}
+	}
+
+	if currentRole == organizerModel.RoleOwner {
+		if err := s.checkNotLastOwner(ctx, organizerId); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.DeleteMember(ctx, organizerId, memberId)
+}
+
+// requirePermission returns the user's role when it grants the permission
+func (s *service) requirePermission(ctx context.Context, organizerId int64, userId uuid.UUID, permission string) (string, error) {
+	role, err := s.repo.GetMemberRole(ctx, organizerId, userId)
+	if err != nil {
+		return "", err
+	}
+	if !organizerModel.RoleCan(role, permission) {
+		return "", errors.New("User is not allowed to do this for the organizer")
+	}
+	return role, nil
+}
+
+// checkNotLastOwner keeps at least one owner on every organizer
+func (s *service) checkNotLastOwner(ctx context.Context, organizerId int64) error {
+	members, err := s.repo.GetMembers(ctx, organizerId)
+	if err != nil {
+		return err
+	}
+
+	owners := 0
+	for _, member := range members {
+		if member.Role == organizerModel.RoleOwner {
+			owners++
+		}
+	}
+	if owners <= 1 {
+		return errors.New("Organizer must keep at least one owner")
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/cursor"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// GetMessages returns a page of messages, newest first, for admins
+func (s *service) GetMessages(ctx context.Context, adminId uuid.UUID, params outboxModel.GetMessagesQueryParams, cursorStr string) (*outboxModel.MessagePage, error) {
+	if err := s.requireAdmin(ctx, adminId); err != nil {
+		return nil, err
+	}
+
+	switch params.Status {
+	case "", outboxModel.StatusPending, outboxModel.StatusDelivered, outboxModel.StatusDead:
+	default:
+		return nil, errors.New("Unknown outbox message status")
+	}
+
+	if params.Limit <= 0 {
+		params.Limit = DefaultPageLimit
+	}
+	limit := min(params.Limit, MaxPageLimit)
+	params.Limit = limit + 1 // one extra row tells whether there is a next page
+
+	if cursorStr != "" {
+		var err error
+		params.BeforeCreated, params.BeforeID, err = cursor.Decode(cursorStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	messages, err := s.repo.GetMessages(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &outboxModel.MessagePage{Messages: []outboxModel.Message{}}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		last := messages[limit-1]
+		page.NextCursor = cursor.Encode(last.CreatedAt, last.MessageID)
+	}
+	page.Messages = append(page.Messages, messages...)
+	return page, nil
+}
+
+func (s *service) GetMessage(ctx context.Context, adminId uuid.UUID, messageId int64) (*outboxModel.Message, error) {
+	if err := s.requireAdmin(ctx, adminId); err != nil {
+		return nil, err
+	}
+	return s.repo.GetMessageById(ctx, messageId)
+}
+
+// Replay sends a pending or dead message to the dispatcher again right away
+func (s *service) Replay(ctx context.Context, adminId uuid.UUID, messageId int64) error {
+	if err := s.requireAdmin(ctx, adminId); err != nil {
+		return err
+	}
+
+	if err := s.repo.Replay(ctx, messageId); err != nil {
+		return err
+	}
+	s.lg.Info("Outbox message replayed", zap.Int64("message_id", messageId), zap.String("admin_id", adminId.String()))
+	return nil
+}
+
+// ReplayDead replays the dead-lettered messages, of a topic when given
+func (s *service) ReplayDead(ctx context.Context, replay outboxModel.ReplayMessages) (int64, error) {
+	if err := s.requireAdmin(ctx, replay.AdminID); err != nil {
+		return 0, err
+	}
+
+	replayed, err := s.repo.ReplayDead(ctx, replay.Topic)
+	if err != nil {
+		return 0, err
+	}
+	s.lg.Info("Dead outbox messages replayed",
+		zap.String("topic", replay.Topic),
+		zap.Int64("replayed", replayed),
+		zap.String("admin_id", replay.AdminID.String()),
+	)
+	return replayed, nil
+}
+
+func (s *service) requireAdmin(ctx context.Context, userId uuid.UUID) error {
+	user, err := s.userRepo.GetUserById(ctx, userId.String())
+	if err != nil {
+		return err
+	}
+	if !user.IsAdmin() {
+		return errors.New("User is not an admin")
+	}
+	return nil
+}
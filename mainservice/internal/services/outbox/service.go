@@ -0,0 +1,238 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/worker"
+)
+
+const (
+	// due messages are also picked up without a notification, e.g. retries
+	PollInterval = 5 * time.Second
+	BatchSize    = 50
+
+	// a claimed message is left alone this long. The lease is renewed before
+	// every consumer runs, so it only has to outlast a single HandlerTimeout.
+	Lease          = 2 * time.Minute
+	HandlerTimeout = 30 * time.Second
+
+	// retries back off exponentially from RetryBase up to RetryMax, the
+	// message is dead-lettered after MaxAttempts, within about a day
+	RetryBase   = 10 * time.Second
+	RetryMax    = 6 * time.Hour
+	MaxAttempts = 15
+
+	// delivered messages are kept this long for inspection
+	Retention     = 7 * 24 * time.Hour
+	pruneInterval = time.Hour
+)
+
+// Handler delivers a message to one consumer. A message can be delivered more
+// than once, e.g. when the process dies before recording the delivery, so
+// handlers must be idempotent; the message id is stable across attempts.
+// A returned error schedules a retry of the message.
+type Handler func(ctx context.Context, message outboxModel.Message) error
+
+// errLeaseLost stops a delivery whose lease ran out before the next consumer,
+// the message belongs to whichever dispatcher claims it next
+var errLeaseLost = errors.New("Outbox lease ran out")
+
+type consumer struct {
+	name    string
+	handler Handler
+}
+
+type service struct {
+	lg         *zap.Logger
+	repo       repo.OutboxRepository
+	notifyRepo repo.NotifyRepository
+	userRepo   repo.UserRepository
+	mu         sync.RWMutex
+	consumers  map[string][]consumer
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.OutboxRepository,
+	notifyRepo repo.NotifyRepository,
+	userRepo repo.UserRepository,
+) *service {
+	return &service{
+		lg:         lg,
+		repo:       repo,
+		notifyRepo: notifyRepo,
+		userRepo:   userRepo,
+		consumers:  make(map[string][]consumer),
+	}
+}
+
+// Handle registers a consumer of the topic. A message is delivered once all
+// consumers of its topic handled it, a retry only reruns the ones that failed.
+// The name is recorded with the message and must stay stable.
+func (s *service) Handle(topic, name string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.consumers[topic] = append(s.consumers[topic], consumer{name: name, handler: handler})
+}
+
+// Run dispatches due messages until ctx is done. Any number of instances can
+// run it, each message is claimed by one of them at a time.
+func (s *service) Run(ctx context.Context) {
+	wake := make(chan struct{}, 1)
+	go func() {
+		err := s.notifyRepo.Listen(ctx, []string{outboxModel.Channel}, func(string, []byte) {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		})
+		s.lg.Info("outbox listener stopped", zap.Error(err))
+	}()
+	go worker.Every(ctx, s.lg, pruneInterval, "prune outbox messages", s.prune)
+
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.dispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// dispatchDue delivers batches until no due message is left
+func (s *service) dispatchDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		messages, err := s.repo.ClaimDue(ctx, BatchSize, Lease)
+		if err != nil {
+			s.lg.Error("Failed to claim outbox messages", zap.Error(err))
+			return
+		}
+
+		for _, message := range messages {
+			s.deliver(ctx, message)
+		}
+		if len(messages) < BatchSize {
+			return
+		}
+	}
+}
+
+func (s *service) deliver(ctx context.Context, message outboxModel.Message) {
+	err := s.handle(ctx, message)
+	if ctx.Err() != nil {
+		// shutting down, the lease runs out and the message is delivered again
+		return
+	}
+
+	fields := []zap.Field{
+		zap.Int64("message_id", message.MessageID),
+		zap.String("topic", message.Topic),
+		zap.Int("attempt", message.Attempts),
+	}
+
+	if errors.Is(err, errLeaseLost) {
+		s.lg.Warn("Outbox lease ran out, leaving the message to the next claim", fields...)
+		return
+	}
+
+	if err == nil {
+		if err := s.repo.MarkDelivered(ctx, message.MessageID, message.Attempts); err != nil {
+			s.lg.Error("Failed to mark outbox message delivered", append(fields, zap.Error(err))...)
+		}
+		return
+	}
+
+	if message.Attempts >= MaxAttempts {
+		s.lg.Error("Outbox message dead-lettered", append(fields, zap.Error(err))...)
+		if err := s.repo.DeadLetter(ctx, message.MessageID, message.Attempts, err.Error()); err != nil {
+			s.lg.Error("Failed to dead-letter outbox message", append(fields, zap.Error(err))...)
+		}
+		return
+	}
+
+	retryAt := time.Now().Add(backoff(message.Attempts))
+	s.lg.Warn("Outbox message failed, retrying", append(fields, zap.Time("retry_at", retryAt), zap.Error(err))...)
+	if err := s.repo.Retry(ctx, message.MessageID, message.Attempts, err.Error(), retryAt); err != nil {
+		s.lg.Error("Failed to schedule outbox retry", append(fields, zap.Error(err))...)
+	}
+}
+
+// handle runs the consumers of the message's topic that haven't handled it yet,
+// a topic without consumers has nothing to deliver. Messages of a batch wait
+// for the ones before them, so the lease is renewed before each consumer and
+// a message whose lease ran out in the meantime isn't handled twice at once.
+func (s *service) handle(ctx context.Context, message outboxModel.Message) error {
+	s.mu.RLock()
+	consumers := s.consumers[message.Topic]
+	s.mu.RUnlock()
+
+	for _, c := range consumers {
+		if slices.Contains(message.Handled, c.name) {
+			continue
+		}
+		renewed, err := s.repo.RenewLease(ctx, message.MessageID, message.Attempts, Lease)
+		if err != nil {
+			return err
+		}
+		if !renewed {
+			return errLeaseLost
+		}
+		if err := s.runHandler(ctx, c.handler, message); err != nil {
+			return errors.Wrap(err, c.name)
+		}
+		if err := s.repo.MarkHandled(ctx, message.MessageID, message.Attempts, c.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) runHandler(ctx context.Context, handler Handler, message outboxModel.Message) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, HandlerTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, message)
+}
+
+// backoff doubles the delay with every attempt, jittered so that messages
+// failing together don't retry together
+func backoff(attempt int) time.Duration {
+	delay := RetryMax
+	if attempt < 20 {
+		delay = min(RetryBase<<(attempt-1), RetryMax)
+	}
+	return delay/2 + rand.N(delay/2)
+}
+
+func (s *service) prune(ctx context.Context) error {
+	deleted, err := s.repo.DeleteDeliveredBefore(ctx, time.Now().Add(-Retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.lg.Info("pruned outbox messages", zap.Int64("deleted", deleted))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package reminder
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	lockModel "github.com/quietguido/mapnu/mainservice/internal/repo/lock/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+	"github.com/quietguido/mapnu/mainservice/pkg/worker"
+)
+
+const (
+	// REMINDER_OFFSETS overrides them, e.g. "48h,3h,15m"
+	DefaultOffsets = "24h,1h"
+
+	// recorded reminders are kept this long after the event started
+	Retention = 7 * 24 * time.Hour
+)
+
+type service struct {
+	lg           *zap.Logger
+	reminderRepo repo.ReminderRepository
+	lockRepo     repo.LockRepository
+	offsets      []time.Duration // longest first
+}
+
+func InitService(
+	lg *zap.Logger,
+	reminderRepo repo.ReminderRepository,
+	lockRepo repo.LockRepository,
+) *service {
+	spec, exists := os.LookupEnv("REMINDER_OFFSETS")
+	if !exists {
+		spec = DefaultOffsets
+	}
+	offsets, err := parseOffsets(spec)
+	assert.ErrorNil(err, "invalid REMINDER_OFFSETS")
+
+	return &service{
+		lg:           lg,
+		reminderRepo: reminderRepo,
+		lockRepo:     lockRepo,
+		offsets:      offsets,
+	}
+}
+
+// Run schedules due reminders right away and then every interval until ctx is done.
+// Delivery goes through the outbox, which renders them in the user's locale and
+// timezone and honours the user's preference for reminders.
+func (s *service) Run(ctx context.Context, interval time.Duration) {
+	worker.Every(ctx, s.lg, interval, "schedule reminders", s.schedule)
+}
+
+// schedule enqueues the reminders that fell due. Only one instance schedules at
+// a time, the others skip the round. Each offset covers the events starting
+// between it and the next shorter offset, so a booking confirmed 3 hours before
+// the start gets the 1h reminder only, and one confirmed 20 hours before gets
+// the 24h reminder right away.
+func (s *service) schedule(ctx context.Context) error {
+	release, ok, err := s.lockRepo.TryLock(ctx, lockModel.KeyReminderScheduler)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	defer release()
+
+	for i, offset := range s.offsets {
+		var notBefore time.Duration
+		if i+1 < len(s.offsets) {
+			notBefore = s.offsets[i+1]
+		}
+
+		enqueued, err := s.reminderRepo.EnqueueDue(ctx, offset, notBefore)
+		if err != nil {
+			return errors.Wrapf(err, "Offset %s", offset)
+		}
+		if enqueued > 0 {
+			s.lg.Info("reminders enqueued", zap.Duration("offset", offset), zap.Int("reminders", enqueued))
+		}
+	}
+
+	if _, err := s.reminderRepo.DeleteBefore(ctx, time.Now().Add(-Retention)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseOffsets reads a comma separated list of durations, longest first without duplicates
+func parseOffsets(spec string) ([]time.Duration, error) {
+	var offsets []time.Duration
+	for _, part := range strings.Split(spec, ",") {
+		offset, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		if offset < time.Minute {
+			return nil, errors.Errorf("Reminder offset %s is shorter than a minute", offset)
+		}
+		offsets = append(offsets, offset)
+	}
+
+	slices.Sort(offsets)
+	slices.Reverse(offsets)
+	return slices.Compact(offsets), nil
+}
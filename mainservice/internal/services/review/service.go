@@ -0,0 +1,169 @@
+package review
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	reviewModel "github.com/quietguido/mapnu/mainservice/internal/repo/review/model"
+	eventService "github.com/quietguido/mapnu/mainservice/internal/services/event"
+	"github.com/quietguido/mapnu/mainservice/pkg/cursor"
+)
+
+const (
+	// only attendees whose booking was confirmed may review, bookings have no check-in state
+	ConfirmedBookingStatus = "confirmed"
+
+	MaxBodyLength = 2000
+
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+type service struct {
+	lg          *zap.Logger
+	repo        repo.ReviewRepository
+	bookingRepo repo.BookingReposity
+	eventRepo   repo.EventRepository
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.ReviewRepository,
+	bookingRepo repo.BookingReposity,
+	eventRepo repo.EventRepository,
+) *service {
+	return &service{
+		lg:          lg,
+		repo:        repo,
+		bookingRepo: bookingRepo,
+		eventRepo:   eventRepo,
+	}
+}
+
+// Create reviews the event of the booking once it has ended
+func (s *service) Create(ctx context.Context, createReview reviewModel.CreateReview) (int64, error) {
+	body, err := checkReview(createReview.Rating, createReview.Body)
+	if err != nil {
+		return 0, err
+	}
+	createReview.Body = body
+
+	booking, err := s.bookingRepo.GetBookingById(ctx, int(createReview.BookingID))
+	if err != nil {
+		return 0, errors.Wrap(err, "Unknown booking")
+	}
+	if booking.UserID != createReview.UserID {
+		return 0, errors.New("Booking does not belong to user")
+	}
+	if booking.BookingStatus != ConfirmedBookingStatus {
+		return 0, errors.New("Only confirmed attendees can review an event")
+	}
+
+	event, err := s.eventRepo.GetEventById(ctx, int(booking.EventID))
+	if err != nil {
+		return 0, err
+	}
+	end := event.StartDate
+	if event.EndDate != nil {
+		end = *event.EndDate
+	}
+	if time.Now().Before(end) {
+		return 0, errors.New("Event has not ended yet")
+	}
+
+	createReview.EventID = booking.EventID
+	return s.repo.CreateReview(ctx, createReview)
+}
+
+func (s *service) GetReviewById(ctx context.Context, reviewId int64) (*reviewModel.Review, error) {
+	return s.repo.GetReviewById(ctx, reviewId)
+}
+
+// Update changes rating and text, only the author may edit
+func (s *service) Update(ctx context.Context, reviewId int64, update reviewModel.UpdateReview) error {
+	if err := s.checkAuthor(ctx, reviewId, update.UserID); err != nil {
+		return err
+	}
+
+	body, err := checkReview(update.Rating, update.Body)
+	if err != nil {
+		return err
+	}
+	update.Body = body
+
+	return s.repo.UpdateReview(ctx, reviewId, update)
+}
+
+func (s *service) Delete(ctx context.Context, reviewId int64, userId uuid.UUID) error {
+	if err := s.checkAuthor(ctx, reviewId, userId); err != nil {
+		return err
+	}
+	return s.repo.DeleteReview(ctx, reviewId)
+}
+
+// GetReviewsForEvent returns a page of the event's reviews newest first
+func (s *service) GetReviewsForEvent(ctx context.Context, eventId int64, viewer *uuid.UUID, cursorStr string, limit int) (*reviewModel.ReviewPage, error) {
+	if _, err := eventService.GetVisibleEvent(ctx, s.eventRepo, eventId, viewer); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	limit = min(limit, MaxPageLimit)
+
+	params := reviewModel.GetReviewsQueryParams{
+		EventID: eventId,
+		Limit:   limit + 1, // one extra row tells whether there is a next page
+	}
+	if cursorStr != "" {
+		var err error
+		params.BeforeCreated, params.BeforeID, err = cursor.Decode(cursorStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reviews, err := s.repo.GetReviewsForEvent(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &reviewModel.ReviewPage{Reviews: []reviewModel.Review{}}
+	if len(reviews) > limit {
+		reviews = reviews[:limit]
+		last := reviews[limit-1]
+		page.NextCursor = cursor.Encode(last.CreatedAt, last.ReviewID)
+	}
+	page.Reviews = append(page.Reviews, reviews...)
+	return page, nil
+}
+
+func (s *service) checkAuthor(ctx context.Context, reviewId int64, userId uuid.UUID) error {
+	review, err := s.repo.GetReviewById(ctx, reviewId)
+	if err != nil {
+		return err
+	}
+	if review.UserID != userId {
+		return errors.New("Review does not belong to user")
+	}
+	return nil
+}
+
+func checkReview(rating int, body string) (string, error) {
+	if rating < reviewModel.MinRating || rating > reviewModel.MaxRating {
+		return "", errors.Errorf("Rating must be between %d and %d", reviewModel.MinRating, reviewModel.MaxRating)
+	}
+	body = strings.TrimSpace(body)
+	if utf8.RuneCountInString(body) > MaxBodyLength {
+		return "", errors.Errorf("Review is longer than %d characters", MaxBodyLength)
+	}
+	return body, nil
+}
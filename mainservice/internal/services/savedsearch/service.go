@@ -0,0 +1,241 @@
+package savedsearch
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	lockModel "github.com/quietguido/mapnu/mainservice/internal/repo/lock/model"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+	savedSearchModel "github.com/quietguido/mapnu/mainservice/internal/repo/savedsearch/model"
+	"github.com/quietguido/mapnu/mainservice/internal/services/event"
+	"github.com/quietguido/mapnu/mainservice/pkg/assert"
+	"github.com/quietguido/mapnu/mainservice/pkg/worker"
+)
+
+const (
+	MaxSearchesPerUser = 20
+	MaxNameLength      = 100
+	MaxQueryLength     = 200
+	MaxRadiusM         = 200_000
+	MaxWithinDays      = 366
+
+	// SEARCH_DIGEST_HOUR overrides it, digests go out at this hour of the user's local time
+	DefaultDigestHour = 9
+	DigestBatchSize   = 100
+	MaxDigestEvents   = 20
+
+	// matches are kept this long after their digest
+	Retention = 30 * 24 * time.Hour
+)
+
+type service struct {
+	lg         *zap.Logger
+	repo       repo.SavedSearchRepository
+	lockRepo   repo.LockRepository
+	digestHour int
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.SavedSearchRepository,
+	lockRepo repo.LockRepository,
+) *service {
+	digestHour := DefaultDigestHour
+	if value, exists := os.LookupEnv("SEARCH_DIGEST_HOUR"); exists {
+		var err error
+		digestHour, err = strconv.Atoi(value)
+		assert.ErrorNil(err, "invalid SEARCH_DIGEST_HOUR")
+		if digestHour < 0 || digestHour > 23 {
+			panic("SEARCH_DIGEST_HOUR must be between 0 and 23")
+		}
+	}
+
+	return &service{
+		lg:         lg,
+		repo:       repo,
+		lockRepo:   lockRepo,
+		digestHour: digestHour,
+	}
+}
+
+func (s *service) Create(ctx context.Context, save savedSearchModel.SaveSearch) (int64, error) {
+	if err := normalize(&save); err != nil {
+		return 0, err
+	}
+
+	count, err := s.repo.CountSearches(ctx, save.UserID)
+	if err != nil {
+		return 0, err
+	}
+	if count >= MaxSearchesPerUser {
+		return 0, errors.Errorf("Users may have at most %d saved searches", MaxSearchesPerUser)
+	}
+
+	return s.repo.CreateSearch(ctx, save)
+}
+
+func (s *service) GetSearches(ctx context.Context, userId uuid.UUID) ([]savedSearchModel.SavedSearch, error) {
+	searches, err := s.repo.GetSearches(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if searches == nil {
+		searches = []savedSearchModel.SavedSearch{}
+	}
+	return searches, nil
+}
+
+// Update replaces the criteria, events already matched stay in the next digest
+func (s *service) Update(ctx context.Context, searchId int64, save savedSearchModel.SaveSearch) error {
+	if _, err := s.ownSearch(ctx, searchId, save.UserID); err != nil {
+		return err
+	}
+	if err := normalize(&save); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateSearch(ctx, searchId, save)
+}
+
+func (s *service) Delete(ctx context.Context, searchId int64, userId uuid.UUID) error {
+	if _, err := s.ownSearch(ctx, searchId, userId); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteSearch(ctx, searchId)
+}
+
+func (s *service) ownSearch(ctx context.Context, searchId int64, userId uuid.UUID) (*savedSearchModel.SavedSearch, error) {
+	search, err := s.repo.GetSearchById(ctx, searchId)
+	if err != nil {
+		return nil, err
+	}
+	if search.UserID != userId {
+		return nil, errors.New("No saved search found with the given ID")
+	}
+	return search, nil
+}
+
+// HandleEventPublished matches an event that is new or just became public against
+// every saved search, the matches wait for their users' next digest
+func (s *service) HandleEventPublished(ctx context.Context, message outboxModel.Message) error {
+	var published outboxModel.EventPayload
+	if err := json.Unmarshal(message.Payload, &published); err != nil {
+		return errors.Wrap(err, "Failed to decode event")
+	}
+
+	_, err := s.repo.MatchEvent(ctx, published.EventID)
+	return err
+}
+
+// Run sends the digests that fell due right away and then every interval until
+// ctx is done. Every user gets at most one digest a day, at the digest hour of
+// their timezone or with the first match after it.
+func (s *service) Run(ctx context.Context, interval time.Duration) {
+	worker.Every(ctx, s.lg, interval, "send saved search digests", s.digest)
+}
+
+// digest hands the due digests to the outbox, only one instance does it at a time
+func (s *service) digest(ctx context.Context) error {
+	release, ok, err := s.lockRepo.TryLock(ctx, lockModel.KeySearchDigest)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	defer release()
+
+	for ctx.Err() == nil {
+		userIds, err := s.repo.GetDueDigestUsers(ctx, s.digestHour, DigestBatchSize)
+		if err != nil {
+			return err
+		}
+
+		enqueued := 0
+		for _, userId := range userIds {
+			ok, err := s.repo.EnqueueDigest(ctx, userId, MaxDigestEvents)
+			if err != nil {
+				return errors.Wrapf(err, "User %s", userId)
+			}
+			if ok {
+				enqueued++
+			}
+		}
+		if enqueued > 0 {
+			s.lg.Info("saved search digests enqueued", zap.Int("digests", enqueued))
+		}
+		if len(userIds) < DigestBatchSize {
+			break
+		}
+	}
+
+	_, err = s.repo.DeleteDigestedBefore(ctx, time.Now().Add(-Retention))
+	return err
+}
+
+// normalize trims the search and checks its criteria, at least one has to be set
+func normalize(save *savedSearchModel.SaveSearch) error {
+	save.Name = strings.TrimSpace(save.Name)
+	if save.Name == "" {
+		return errors.New("Missing name")
+	}
+	if utf8.RuneCountInString(save.Name) > MaxNameLength {
+		return errors.Errorf("Name is longer than %d characters", MaxNameLength)
+	}
+
+	save.Query = strings.TrimSpace(save.Query)
+	if utf8.RuneCountInString(save.Query) > MaxQueryLength {
+		return errors.Errorf("Query is longer than %d characters", MaxQueryLength)
+	}
+
+	if save.Category != nil {
+		category := strings.ToLower(strings.TrimSpace(*save.Category))
+		save.Category = nil
+		if category != "" {
+			if !slices.Contains(event.Categories, category) {
+				return errors.New("Incorrect category")
+			}
+			save.Category = &category
+		}
+	}
+
+	if (save.Location_lat == nil) != (save.Location_lon == nil) {
+		return errors.New("Location needs both latitude and longitude")
+	}
+	if (save.Location_lat == nil) != (save.RadiusM == nil) {
+		return errors.New("Area needs both a location and a radius")
+	}
+	if save.Location_lat != nil {
+		if *save.Location_lat < -90 || *save.Location_lat > 90 || *save.Location_lon < -180 || *save.Location_lon > 180 {
+			return errors.New("Location is out of range")
+		}
+		if *save.RadiusM <= 0 || *save.RadiusM > MaxRadiusM {
+			return errors.Errorf("Radius must be between 1 and %d meters", MaxRadiusM)
+		}
+	}
+
+	if save.DateFrom != nil && save.DateTo != nil && !save.DateTo.After(*save.DateFrom) {
+		return errors.New("Date range end must be after its start")
+	}
+	if save.WithinDays != nil && (*save.WithinDays <= 0 || *save.WithinDays > MaxWithinDays) {
+		return errors.Errorf("Within days must be between 1 and %d", MaxWithinDays)
+	}
+
+	if save.Query == "" && save.Category == nil && save.Location_lat == nil &&
+		save.DateFrom == nil && save.DateTo == nil && save.WithinDays == nil {
+		return errors.New("Saved search needs at least one criterion")
+	}
+	return nil
+}
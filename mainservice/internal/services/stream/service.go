@@ -0,0 +1,145 @@
+package stream
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	streamModel "github.com/quietguido/mapnu/mainservice/internal/repo/stream/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/worker"
+)
+
+const (
+	// events are kept this long, a stream can resume within this window
+	Retention     = 7 * 24 * time.Hour
+	pruneInterval = time.Hour
+
+	// events read from the log at once
+	BatchSize = 100
+)
+
+type service struct {
+	lg            *zap.Logger
+	repo          repo.StreamRepository
+	notifyRepo    repo.NotifyRepository
+	mu            sync.Mutex
+	subscriptions map[uuid.UUID]map[*Subscription]struct{}
+	stopped       bool
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.StreamRepository,
+	notifyRepo repo.NotifyRepository,
+) *service {
+	return &service{
+		lg:            lg,
+		repo:          repo,
+		notifyRepo:    notifyRepo,
+		subscriptions: make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+// Run wakes the local streams of users with new events, from any instance, and
+// prunes the log until ctx is done. Then all subscriptions are closed.
+func (s *service) Run(ctx context.Context) {
+	go worker.Every(ctx, s.lg, pruneInterval, "prune stream events", s.prune)
+
+	err := s.notifyRepo.Listen(ctx, []string{streamModel.Channel}, s.wake)
+	s.lg.Info("event stream stopped", zap.Error(err))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	for _, subs := range s.subscriptions {
+		for sub := range subs {
+			sub.stop()
+		}
+	}
+	clear(s.subscriptions)
+}
+
+// Subscribe registers a stream of the user, it is woken whenever events are appended for the user
+func (s *service) Subscribe(userId uuid.UUID) *Subscription {
+	sub := &Subscription{
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		sub.stop()
+		return sub
+	}
+	if s.subscriptions[userId] == nil {
+		s.subscriptions[userId] = make(map[*Subscription]struct{})
+	}
+	s.subscriptions[userId][sub] = struct{}{}
+
+	sub.unsubscribe = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.subscriptions[userId], sub)
+		if len(s.subscriptions[userId]) == 0 {
+			delete(s.subscriptions, userId)
+		}
+	}
+	return sub
+}
+
+// Resume turns a Last-Event-ID into the seq to continue after. Without one the
+// stream starts with the next event.
+func (s *service) Resume(ctx context.Context, userId uuid.UUID, lastEventId string) (int64, error) {
+	lastEventId = strings.TrimSpace(lastEventId)
+	if lastEventId == "" {
+		return s.repo.GetLatestSeq(ctx, userId)
+	}
+
+	afterId, err := strconv.ParseInt(lastEventId, 10, 64)
+	if err != nil || afterId < 0 {
+		return 0, errors.New("Invalid Last-Event-ID")
+	}
+	return afterId, nil
+}
+
+// GetEventsAfter returns the next batch of the user's events
+func (s *service) GetEventsAfter(ctx context.Context, userId uuid.UUID, afterId int64) ([]streamModel.StreamEvent, error) {
+	return s.repo.GetEventsAfter(ctx, userId, afterId, BatchSize)
+}
+
+func (s *service) wake(_ string, payload []byte) {
+	userId, err := uuid.ParseBytes(payload)
+	if err != nil {
+		s.lg.Error("Invalid stream notification", zap.ByteString("payload", payload), zap.Error(err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subscriptions[userId] {
+		sub.notify()
+	}
+}
+
+func (s *service) prune(ctx context.Context) error {
+	deleted, err := s.repo.DeleteEventsBefore(ctx, time.Now().Add(-Retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.lg.Info("pruned stream events", zap.Int64("deleted", deleted))
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package stream
+
+import (
+	"sync"
+)
+
+// Subscription is one open stream of a user
+type Subscription struct {
+	wake        chan struct{}
+	done        chan struct{}
+	unsubscribe func()
+	stopOnce    sync.Once
+}
+
+// Wake receives when new events may be in the log, wake-ups are coalesced
+func (sub *Subscription) Wake() <-chan struct{} {
+	return sub.wake
+}
+
+// Done is closed when the subscription ends, either by Close or by shutdown
+func (sub *Subscription) Done() <-chan struct{} {
+	return sub.done
+}
+
+// Close ends the subscription
+func (sub *Subscription) Close() {
+	if sub.unsubscribe != nil {
+		sub.unsubscribe()
+	}
+	sub.stop()
+}
+
+func (sub *Subscription) stop() {
+	sub.stopOnce.Do(func() {
+		close(sub.done)
+	})
+}
+
+func (sub *Subscription) notify() {
+	select {
+	case sub.wake <- struct{}{}:
+	default:
+	}
+}
@@ -2,6 +2,8 @@ package user
 
 import (
 	"context"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -33,5 +35,36 @@ func (s *service) GetUserById(ctx context.Context, userId string) (*userModel.Us
 		return nil, errors.Wrap(err, "not proper uuid")
 	}
 
-	return s.repo.GetUserById(ctx, userId)
+	user, err := s.repo.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user.Hidden {
+		return nil, errors.New("User is hidden by moderation")
+	}
+	return user, nil
+}
+
+// UpdateSettings changes the locale and timezone, an empty field keeps the current value
+func (s *service) UpdateSettings(ctx context.Context, settings userModel.UpdateSettings) error {
+	user, err := s.repo.GetUserById(ctx, settings.UserID.String())
+	if err != nil {
+		return err
+	}
+
+	if settings.Locale == "" {
+		settings.Locale = user.Locale
+	}
+	if !slices.Contains(userModel.Locales, settings.Locale) {
+		return errors.Errorf("Unsupported locale, expected one of %v", userModel.Locales)
+	}
+
+	if settings.Timezone == "" {
+		settings.Timezone = user.Timezone
+	}
+	if _, err := time.LoadLocation(settings.Timezone); err != nil || settings.Timezone == "Local" {
+		return errors.New("Unknown timezone")
+	}
+
+	return s.repo.UpdateSettings(ctx, settings)
 }
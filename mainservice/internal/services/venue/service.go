@@ -0,0 +1,125 @@
+package venue
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	venueModel "github.com/quietguido/mapnu/mainservice/internal/repo/venue/model"
+)
+
+const (
+	DefaultUpcomingEvents = 5
+	MaxUpcomingEvents     = 20
+)
+
+type service struct {
+	lg        *zap.Logger
+	repo      repo.VenueRepository
+	eventRepo repo.EventRepository
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.VenueRepository,
+	eventRepo repo.EventRepository,
+) *service {
+	return &service{
+		lg:        lg,
+		repo:      repo,
+		eventRepo: eventRepo,
+	}
+}
+
+func (s *service) Create(ctx context.Context, createVenue venueModel.CreateVenue) (int64, error) {
+	createVenue.Name = strings.TrimSpace(createVenue.Name)
+	createVenue.Address = strings.TrimSpace(createVenue.Address)
+
+	if createVenue.Name == "" {
+		return 0, errors.New("Missing name")
+	}
+	hasPoint := createVenue.Location_lat != 0 || createVenue.Location_lon != 0
+	if !hasPoint && len(createVenue.Area) == 0 {
+		return 0, errors.New("Missing location or area")
+	}
+	if createVenue.Location_lat < -90 || createVenue.Location_lat > 90 ||
+		createVenue.Location_lon < -180 || createVenue.Location_lon > 180 {
+		return 0, errors.New("Location out of range")
+	}
+	if createVenue.Capacity != nil && *createVenue.Capacity <= 0 {
+		return 0, errors.New("Capacity must be positive")
+	}
+
+	return s.repo.CreateVenue(ctx, createVenue)
+}
+
+// GetVenueById returns the venue with its next events
+func (s *service) GetVenueById(ctx context.Context, venueId int64) (*venueModel.VenueWithEvents, error) {
+	venue, err := s.repo.GetVenueById(ctx, venueId)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.eventRepo.GetUpcomingForVenues(ctx, []int64{venueId}, time.Now(), MaxUpcomingEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	return &venueModel.VenueWithEvents{
+		Venue:          *venue,
+		UpcomingEvents: nonNil(events),
+	}, nil
+}
+
+// GetMapForQuadrant returns the venues inside the box, each with its next events
+func (s *service) GetMapForQuadrant(ctx context.Context, params venueModel.GetVenuesMapQueryParams) ([]venueModel.VenueWithEvents, error) {
+	if params.From.IsZero() {
+		params.From = time.Now()
+	}
+	if params.EventsLimit <= 0 {
+		params.EventsLimit = DefaultUpcomingEvents
+	}
+	params.EventsLimit = min(params.EventsLimit, MaxUpcomingEvents)
+
+	venues, err := s.repo.GetVenuesForQuadrant(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	venueIds := make([]int64, 0, len(venues))
+	for _, venue := range venues {
+		venueIds = append(venueIds, venue.VenueID)
+	}
+
+	events, err := s.eventRepo.GetUpcomingForVenues(ctx, venueIds, params.From, params.EventsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	byVenue := make(map[int64][]eventModel.Event, len(venues))
+	for _, event := range events {
+		byVenue[*event.VenueID] = append(byVenue[*event.VenueID], event)
+	}
+
+	result := make([]venueModel.VenueWithEvents, 0, len(venues))
+	for _, venue := range venues {
+		result = append(result, venueModel.VenueWithEvents{
+			Venue:          venue,
+			UpcomingEvents: nonNil(byVenue[venue.VenueID]),
+		})
+	}
+	return result, nil
+}
+
+// nonNil keeps empty event lists as [] in JSON
+func nonNil(events []eventModel.Event) []eventModel.Event {
+	if events == nil {
+		return []eventModel.Event{}
+	}
+	return events
+}
@@ -0,0 +1,248 @@
+package webhook
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	organizerModel "github.com/quietguido/mapnu/mainservice/internal/repo/organizer/model"
+	webhookModel "github.com/quietguido/mapnu/mainservice/internal/repo/webhook/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/cursor"
+	"github.com/quietguido/mapnu/mainservice/pkg/webhook"
+)
+
+const (
+	MaxWebhooksPerOrganizer = 10
+	MaxURLLength            = 2048
+
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// Create subscribes a new endpoint, the response carries the signing secret,
+// which isn't shown again
+func (s *service) Create(ctx context.Context, organizerId int64, createWebhook webhookModel.CreateWebhook) (*webhookModel.CreatedWebhook, error) {
+	if err := s.requirePermission(ctx, organizerId, createWebhook.UserID); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if createWebhook.URL, err = s.checkURL(createWebhook.URL); err != nil {
+		return nil, err
+	}
+	if createWebhook.EventTypes, err = checkEventTypes(createWebhook.EventTypes); err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountWebhooks(ctx, organizerId)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxWebhooksPerOrganizer {
+		return nil, errors.Errorf("Organizers may have at most %d webhooks", MaxWebhooksPerOrganizer)
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	webhookId, err := s.repo.CreateWebhook(ctx, organizerId, createWebhook, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.GetWebhookById(ctx, webhookId)
+	if err != nil {
+		return nil, err
+	}
+	return &webhookModel.CreatedWebhook{Webhook: *created, Secret: secret}, nil
+}
+
+func (s *service) GetWebhooks(ctx context.Context, organizerId int64, userId uuid.UUID) ([]webhookModel.Webhook, error) {
+	if err := s.requirePermission(ctx, organizerId, userId); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := s.repo.GetWebhooks(ctx, organizerId)
+	if err != nil {
+		return nil, err
+	}
+	if webhooks == nil {
+		webhooks = []webhookModel.Webhook{}
+	}
+	return webhooks, nil
+}
+
+// Update replaces the settings of the webhook, setting it active again after
+// it was disabled resumes its pending deliveries
+func (s *service) Update(ctx context.Context, organizerId, webhookId int64, update webhookModel.UpdateWebhook) error {
+	if _, err := s.webhook(ctx, organizerId, webhookId, update.UserID); err != nil {
+		return err
+	}
+
+	var err error
+	if update.URL, err = s.checkURL(update.URL); err != nil {
+		return err
+	}
+	if update.EventTypes, err = checkEventTypes(update.EventTypes); err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateWebhook(ctx, webhookId, update); err != nil {
+		return err
+	}
+	if update.Active {
+		s.signal()
+	}
+	return nil
+}
+
+func (s *service) Delete(ctx context.Context, organizerId, webhookId int64, userId uuid.UUID) error {
+	if _, err := s.webhook(ctx, organizerId, webhookId, userId); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteWebhook(ctx, webhookId)
+}
+
+// GetDeliveries returns a page of the webhook's delivery history, newest first
+func (s *service) GetDeliveries(ctx context.Context, organizerId, webhookId int64, userId uuid.UUID, cursorStr string, limit int) (*webhookModel.DeliveryPage, error) {
+	if _, err := s.webhook(ctx, organizerId, webhookId, userId); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	limit = min(limit, MaxPageLimit)
+
+	// one extra row tells whether there is a next page
+	params := webhookModel.GetDeliveriesQueryParams{Limit: limit + 1}
+	if cursorStr != "" {
+		var err error
+		params.BeforeCreated, params.BeforeID, err = cursor.Decode(cursorStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	deliveries, err := s.repo.GetDeliveries(ctx, webhookId, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &webhookModel.DeliveryPage{Deliveries: []webhookModel.Delivery{}}
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+		last := deliveries[limit-1]
+		page.NextCursor = cursor.Encode(last.CreatedAt, last.DeliveryID)
+	}
+	page.Deliveries = append(page.Deliveries, deliveries...)
+	return page, nil
+}
+
+// SendTest sends a EventTest delivery right away and returns its outcome. It is
+// sent to disabled webhooks too, isn't retried and doesn't count towards
+// disabling, so organizers can check an endpoint before turning it back on.
+func (s *service) SendTest(ctx context.Context, organizerId, webhookId int64, userId uuid.UUID) (*webhookModel.Delivery, error) {
+	hook, err := s.webhook(ctx, organizerId, webhookId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.repo.CreateClaimedDelivery(ctx, webhookId, webhookModel.EventTest, map[string]any{
+		"organizer_id": organizerId,
+		"webhook_id":   webhookId,
+		"message":      "This is a test delivery",
+	}, Lease)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.post(ctx, hook.URL, hook.Secret, *delivery)
+	if result.Status != webhookModel.StatusSucceeded {
+		result.Status = webhookModel.StatusFailed
+	}
+	if _, err := s.repo.RecordAttempt(ctx, result, 0); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	durationMs := int(result.Duration.Milliseconds())
+	delivery.Status = result.Status
+	delivery.ResponseStatus = result.ResponseStatus
+	delivery.ResponseBody = result.ResponseBody
+	delivery.Error = result.Error
+	delivery.DurationMs = &durationMs
+	delivery.NextAttemptAt = result.NextAttemptAt
+	delivery.LastAttemptAt = &now
+	return delivery, nil
+}
+
+// webhook returns the organizer's webhook when the user may manage it
+func (s *service) webhook(ctx context.Context, organizerId, webhookId int64, userId uuid.UUID) (*webhookModel.Webhook, error) {
+	if err := s.requirePermission(ctx, organizerId, userId); err != nil {
+		return nil, err
+	}
+
+	hook, err := s.repo.GetWebhookById(ctx, webhookId)
+	if err != nil {
+		return nil, err
+	}
+	if hook.OrganizerID != organizerId {
+		return nil, errors.New("No webhook found with the given ID")
+	}
+	return hook, nil
+}
+
+func (s *service) requirePermission(ctx context.Context, organizerId int64, userId uuid.UUID) error {
+	role, err := s.organizerRepo.GetMemberRole(ctx, organizerId, userId)
+	if err != nil {
+		return err
+	}
+	if !organizerModel.RoleCan(role, organizerModel.PermissionManageWebhooks) {
+		return errors.New("User is not allowed to manage webhooks of the organizer")
+	}
+	return nil
+}
+
+// checkURL requires an absolute https URL, plain http only when insecure webhooks are allowed
+func (s *service) checkURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", errors.New("Missing URL")
+	}
+	if len(rawURL) > MaxURLLength {
+		return "", errors.Errorf("URL is longer than %d characters", MaxURLLength)
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return "", errors.New("Invalid URL")
+	}
+	if parsed.Scheme != "https" && !(s.allowInsecure && parsed.Scheme == "http") {
+		return "", errors.New("Webhook URL must use https")
+	}
+	return rawURL, nil
+}
+
+func checkEventTypes(eventTypes []string) ([]string, error) {
+	if len(eventTypes) == 0 {
+		return nil, errors.Errorf("Missing event types, expected some of %v", webhookModel.SubscribableEvents)
+	}
+	for _, eventType := range eventTypes {
+		if !slices.Contains(webhookModel.SubscribableEvents, eventType) {
+			return nil, errors.Errorf("Unknown event type %q, expected some of %v", eventType, webhookModel.SubscribableEvents)
+		}
+	}
+
+	eventTypes = slices.Clone(eventTypes)
+	slices.Sort(eventTypes)
+	return slices.Compact(eventTypes), nil
+}
@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"context"
+	dbsql "database/sql"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	changeModel "github.com/quietguido/mapnu/mainservice/internal/repo/change/model"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+)
+
+// HandleOutbox turns a message of one of the SubscribableEvents into deliveries
+// for the subscribed webhooks of the event's organizer, the message payload
+// becomes the data of the delivery. Handling a message again adds nothing.
+func (s *service) HandleOutbox(ctx context.Context, message outboxModel.Message) error {
+	organizerId, err := s.organizerOf(ctx, message)
+	if err != nil {
+		return err
+	}
+	if organizerId == nil {
+		return nil
+	}
+
+	created, err := s.repo.EnqueueDeliveries(ctx, *organizerId, message.Topic, message.Payload, message.MessageID)
+	if err != nil {
+		return err
+	}
+	if created > 0 {
+		s.lg.Debug("enqueued webhook deliveries", zap.Int64("message_id", message.MessageID), zap.Int64("deliveries", created))
+		s.signal()
+	}
+	return nil
+}
+
+// organizerOf returns the organizer of the message's event, nil for events without one
+func (s *service) organizerOf(ctx context.Context, message outboxModel.Message) (*int64, error) {
+	switch message.Topic {
+	case outboxModel.TopicBookingCreated, outboxModel.TopicBookingStatusChanged:
+		var booking changeModel.BookingChange
+		if err := json.Unmarshal(message.Payload, &booking); err != nil {
+			return nil, errors.Wrap(err, "Failed to decode booking")
+		}
+
+		event, err := s.eventRepo.GetEventById(ctx, int(booking.EventID))
+		if errors.Is(err, dbsql.ErrNoRows) {
+			return nil, nil // the event is gone, its cancellation was sent instead
+		}
+		if err != nil {
+			return nil, err
+		}
+		return event.OrganizerID, nil
+
+	default:
+		var event outboxModel.EventPayload
+		if err := json.Unmarshal(message.Payload, &event); err != nil {
+			return nil, errors.Wrap(err, "Failed to decode event")
+		}
+		return event.OrganizerID, nil
+	}
+}
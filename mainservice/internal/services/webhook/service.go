@@ -0,0 +1,263 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/quietguido/mapnu/mainservice/internal/repo"
+	webhookModel "github.com/quietguido/mapnu/mainservice/internal/repo/webhook/model"
+	"github.com/quietguido/mapnu/mainservice/pkg/webhook"
+	"github.com/quietguido/mapnu/mainservice/pkg/worker"
+)
+
+const (
+	// due deliveries are also picked up without a wake-up, e.g. retries
+	PollInterval = 5 * time.Second
+	BatchSize    = 20
+	Concurrency  = 8
+
+	// a claimed delivery is left alone this long, it must outlast RequestTimeout
+	Lease          = time.Minute
+	RequestTimeout = 10 * time.Second
+
+	// retries back off exponentially from RetryBase up to RetryMax, the
+	// delivery fails for good after MaxAttempts, within about 8 hours
+	RetryBase   = time.Minute
+	RetryMax    = 6 * time.Hour
+	MaxAttempts = 10
+
+	// a webhook is disabled once this many attempts in a row failed, across deliveries
+	DisableAfter = 20
+
+	// the start of the response body is kept with the delivery
+	MaxResponseBody = 2048
+
+	// finished deliveries are kept this long as history
+	Retention     = 30 * 24 * time.Hour
+	pruneInterval = time.Hour
+
+	userAgent = "MapNu-Webhooks/1.0"
+)
+
+// envelope is the request body of every delivery
+type envelope struct {
+	ID        int64           `json:"id"` // delivery id, same as the DeliveryHeader
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type service struct {
+	lg            *zap.Logger
+	repo          repo.WebhookRepository
+	organizerRepo repo.OrganizerRepository
+	eventRepo     repo.EventRepository
+	client        *http.Client
+	allowInsecure bool
+	wake          chan struct{}
+}
+
+func InitService(
+	lg *zap.Logger,
+	repo repo.WebhookRepository,
+	organizerRepo repo.OrganizerRepository,
+	eventRepo repo.EventRepository,
+) *service {
+	// WEBHOOK_ALLOW_INSECURE permits plain http and private addresses, for local development
+	allowInsecure, _ := strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_INSECURE"))
+
+	return &service{
+		lg:            lg,
+		repo:          repo,
+		organizerRepo: organizerRepo,
+		eventRepo:     eventRepo,
+		client:        webhook.NewClient(RequestTimeout, allowInsecure),
+		allowInsecure: allowInsecure,
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// Run sends due deliveries until ctx is done. Any number of instances can run
+// it, each delivery is claimed by one of them at a time. Deliveries of a webhook
+// are sent concurrently and retried independently, so receivers must not rely
+// on their order.
+func (s *service) Run(ctx context.Context) {
+	go worker.Every(ctx, s.lg, pruneInterval, "prune webhook deliveries", s.prune)
+
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.dispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// dispatchDue sends batches until no due delivery is left
+func (s *service) dispatchDue(ctx context.Context) {
+	sem := make(chan struct{}, Concurrency)
+
+	for ctx.Err() == nil {
+		attempts, err := s.repo.ClaimDue(ctx, BatchSize, Lease)
+		if err != nil {
+			s.lg.Error("Failed to claim webhook deliveries", zap.Error(err))
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, attempt := range attempts {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() { <-sem; wg.Done() }()
+				s.deliver(ctx, attempt)
+			}()
+		}
+		wg.Wait()
+
+		if len(attempts) < BatchSize {
+			return
+		}
+	}
+}
+
+func (s *service) deliver(ctx context.Context, attempt webhookModel.Attempt) {
+	result := s.post(ctx, attempt.URL, attempt.Secret, attempt.Delivery)
+	if ctx.Err() != nil {
+		// shutting down, the lease runs out and the delivery is sent again
+		return
+	}
+
+	fields := []zap.Field{
+		zap.Int64("delivery_id", attempt.DeliveryID),
+		zap.Int64("webhook_id", attempt.WebhookID),
+		zap.String("event_type", attempt.EventType),
+		zap.Int("attempt", attempt.Attempts),
+	}
+
+	if result.Status != webhookModel.StatusSucceeded {
+		if attempt.Attempts >= MaxAttempts {
+			result.Status = webhookModel.StatusFailed
+		} else {
+			result.Status = webhookModel.StatusPending
+			result.NextAttemptAt = time.Now().Add(backoff(attempt.Attempts))
+		}
+		s.lg.Warn("Webhook delivery failed", append(fields, zap.Stringp("error", result.Error), zap.Intp("response_status", result.ResponseStatus))...)
+	}
+
+	disabled, err := s.repo.RecordAttempt(ctx, result, DisableAfter)
+	if err != nil {
+		s.lg.Error("Failed to record webhook delivery", append(fields, zap.Error(err))...)
+		return
+	}
+	if disabled {
+		s.lg.Warn("Webhook disabled after repeated failures", fields...)
+	}
+}
+
+// post sends the delivery once. The result is succeeded on a 2xx response,
+// the caller decides what any other outcome means.
+func (s *service) post(ctx context.Context, url, secret string, delivery webhookModel.Delivery) webhookModel.AttemptResult {
+	result := webhookModel.AttemptResult{
+		DeliveryID:    delivery.DeliveryID,
+		WebhookID:     delivery.WebhookID,
+		Attempt:       delivery.Attempts,
+		NextAttemptAt: time.Now(),
+	}
+	fail := func(err error) webhookModel.AttemptResult {
+		msg := err.Error()
+		result.Error = &msg
+		return result
+	}
+
+	body, err := json.Marshal(envelope{
+		ID:        delivery.DeliveryID,
+		Type:      delivery.EventType,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fail(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(webhook.EventHeader, delivery.EventType)
+	req.Header.Set(webhook.DeliveryHeader, strconv.FormatInt(delivery.DeliveryID, 10))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(secret, time.Now(), body))
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	result.Duration = time.Since(start)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, MaxResponseBody))
+	// drain a little more so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	statusCode := resp.StatusCode
+	text := strings.ReplaceAll(strings.ToValidUTF8(string(respBody), ""), "\x00", "")
+	result.ResponseStatus = &statusCode
+	result.ResponseBody = &text
+
+	if statusCode < 200 || statusCode > 299 {
+		msg := "Unexpected response status " + resp.Status
+		result.Error = &msg
+		return result
+	}
+	result.Status = webhookModel.StatusSucceeded
+	return result
+}
+
+// backoff doubles the delay with every attempt, jittered so that deliveries
+// failing together don't retry together
+func backoff(attempt int) time.Duration {
+	delay := RetryMax
+	if attempt < 20 {
+		delay = min(RetryBase<<(attempt-1), RetryMax)
+	}
+	return delay/2 + rand.N(delay/2)
+}
+
+// signal wakes the dispatcher of this instance, the others find the deliveries when polling
+func (s *service) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *service) prune(ctx context.Context) error {
+	deleted, err := s.repo.DeleteFinishedBefore(ctx, time.Now().Add(-Retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.lg.Info("pruned webhook deliveries", zap.Int64("deleted", deleted))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	bookmarkModel "github.com/quietguido/mapnu/mainservice/internal/repo/bookmark/model"
+)
+
+func (st *restH) AddBookmarkHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	var createBookmark bookmarkModel.CreateBookmark
+	if err := JsonBodyDecoding(r, &createBookmark); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if createBookmark.UserID == uuid.Nil {
+		RespondWithError(w, http.StatusBadRequest, "Missing user ID")
+		return
+	}
+
+	if err := st.services.Bookmark.Add(r.Context(), createBookmark.UserID, eventId); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to bookmark event")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"event_id": eventId,
+		"message":  "Event bookmarked",
+	})
+}
+
+func (st *restH) RemoveBookmarkHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := st.services.Bookmark.Remove(r.Context(), userID, eventId); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to remove bookmark")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"event_id": eventId,
+		"message":  "Bookmark removed",
+	})
+}
+
+// GetBookmarksHandler pages through the user's bookmarks with ?cursor=<next_cursor of the previous page>,
+// events that already ended are left out unless ?include_past=true
+func (st *restH) GetBookmarksHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	query := r.URL.Query()
+
+	userID, err := uuid.Parse(query.Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var includePast bool
+	if includePastStr := query.Get("include_past"); includePastStr != "" {
+		includePast, err = strconv.ParseBool(includePastStr)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid include_past parameter")
+			return
+		}
+	}
+
+	limit, err := QueryLimit(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+
+	page, err := st.services.Bookmark.GetBookmarks(r.Context(), userID, includePast, query.Get("cursor"), limit)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to retrieve bookmarks")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, page)
+}
@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	calendarModel "github.com/quietguido/mapnu/mainservice/internal/repo/calendar/model"
+)
+
+const icsSuffix = ".ics"
+
+func (st *restH) GetEventICSHandler(w http.ResponseWriter, r *http.Request, eventIdStr string) {
+	eventId, err := strconv.Atoi(eventIdStr)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	viewer, err := OptionalUserID(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	data, err := st.services.Calendar.EventICS(r.Context(), eventId, viewer)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusNotFound, "Event not found")
+		return
+	}
+
+	respondWithICS(w, fmt.Sprintf("event-%d.ics", eventId), data)
+}
+
+// GetCalendarFeedHandler serves the feed by its secret token, calendar clients can not send bearer headers
+func (st *restH) GetCalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutSuffix(r.PathValue("token"), icsSuffix)
+	if !ok || token == "" {
+		RespondWithError(w, http.StatusNotFound, "Calendar not found")
+		return
+	}
+
+	data, err := st.services.Calendar.UserFeed(r.Context(), token)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusNotFound, "Calendar not found")
+		return
+	}
+
+	respondWithICS(w, "mapnu.ics", data)
+}
+
+func (st *restH) CreateCalendarTokenHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	var createToken calendarModel.CreateCalendarToken
+	if err := JsonBodyDecoding(r, &createToken); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	token, feedURL, err := st.services.Calendar.CreateFeedToken(r.Context(), createToken.UserID)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to create calendar token")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"token":    token,
+		"feed_url": feedURL,
+	})
+}
+
+func (st *restH) RevokeCalendarTokenHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := st.services.Calendar.RevokeFeedToken(r.Context(), userID); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusInternalServerError, "Failed to revoke calendar token")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"message": "Calendar token revoked successfully",
+	})
+}
+
+func respondWithICS(w http.ResponseWriter, filename string, data []byte) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,179 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	commentModel "github.com/quietguido/mapnu/mainservice/internal/repo/comment/model"
+)
+
+func (st *restH) CreateCommentHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	var createComment commentModel.CreateComment
+	if err := JsonBodyDecoding(r, &createComment); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if createComment.UserID == uuid.Nil {
+		RespondWithError(w, http.StatusBadRequest, "Missing user ID")
+		return
+	}
+	createComment.EventID = eventId
+
+	comment, err := st.services.Comment.Create(r.Context(), createComment)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusCreated, comment)
+}
+
+// GetCommentsHandler pages through top level comments with ?cursor=<next_cursor of the previous page>
+func (st *restH) GetCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	limit, err := QueryLimit(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+
+	viewer, err := OptionalUserID(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	page, err := st.services.Comment.GetThreads(r.Context(), eventId, viewer, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to retrieve comments")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, page)
+}
+
+func (st *restH) GetCommentRepliesHandler(w http.ResponseWriter, r *http.Request) {
+	commentId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	limit, err := QueryLimit(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+
+	viewer, err := OptionalUserID(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	page, err := st.services.Comment.GetReplies(r.Context(), commentId, viewer, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to retrieve replies")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, page)
+}
+
+func (st *restH) UpdateCommentHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	commentId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	var update commentModel.UpdateComment
+	if err := JsonBodyDecoding(r, &update); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if update.UserID == uuid.Nil {
+		RespondWithError(w, http.StatusBadRequest, "Missing user ID")
+		return
+	}
+
+	if err := st.services.Comment.Update(r.Context(), commentId, update); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"comment_id": commentId,
+		"message":    "Comment updated successfully",
+	})
+}
+
+func (st *restH) DeleteCommentHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	commentId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := st.services.Comment.Delete(r.Context(), commentId, userID); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"comment_id": commentId,
+		"message":    "Comment deleted successfully",
+	})
+}
+
+func (st *restH) PinCommentHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	commentId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+
+	var pin commentModel.PinComment
+	if err := JsonBodyDecoding(r, &pin); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if pin.UserID == uuid.Nil {
+		RespondWithError(w, http.StatusBadRequest, "Missing user ID")
+		return
+	}
+
+	if err := st.services.Comment.Pin(r.Context(), commentId, pin); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"comment_id": commentId,
+		"pinned":     pin.Pinned,
+	})
+}
@@ -0,0 +1,124 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	followModel "github.com/quietguido/mapnu/mainservice/internal/repo/follow/model"
+)
+
+func (st *restH) FollowOrganizerHandler(w http.ResponseWriter, r *http.Request) {
+	st.followHandler(w, r, followModel.TargetOrganizer)
+}
+
+func (st *restH) UnfollowOrganizerHandler(w http.ResponseWriter, r *http.Request) {
+	st.unfollowHandler(w, r, followModel.TargetOrganizer)
+}
+
+func (st *restH) FollowVenueHandler(w http.ResponseWriter, r *http.Request) {
+	st.followHandler(w, r, followModel.TargetVenue)
+}
+
+func (st *restH) UnfollowVenueHandler(w http.ResponseWriter, r *http.Request) {
+	st.unfollowHandler(w, r, followModel.TargetVenue)
+}
+
+func (st *restH) followHandler(w http.ResponseWriter, r *http.Request, target string) { // change for token
+	targetId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid "+target+" ID")
+		return
+	}
+
+	var createFollow followModel.CreateFollow
+	if err := JsonBodyDecoding(r, &createFollow); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if createFollow.UserID == uuid.Nil {
+		RespondWithError(w, http.StatusBadRequest, "Missing user ID")
+		return
+	}
+
+	if err := st.services.Follow.Follow(r.Context(), createFollow.UserID, target, targetId); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to follow "+target)
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		target + "_id": targetId,
+		"message":      "Followed successfully",
+	})
+}
+
+func (st *restH) unfollowHandler(w http.ResponseWriter, r *http.Request, target string) { // change for token
+	targetId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid "+target+" ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := st.services.Follow.Unfollow(r.Context(), userID, target, targetId); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to unfollow "+target)
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		target + "_id": targetId,
+		"message":      "Unfollowed successfully",
+	})
+}
+
+func (st *restH) GetFollowingHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	following, err := st.services.Follow.GetFollowing(r.Context(), userID)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve follows")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, following)
+}
+
+// GetFeedHandler pages through the feed with ?cursor=<next_cursor of the previous page>
+func (st *restH) GetFeedHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	query := r.URL.Query()
+
+	userID, err := uuid.Parse(query.Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var limit int
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	page, err := st.services.Follow.GetFeed(r.Context(), userID, query.Get("cursor"), limit)
+	if err != nil {
+		st.respondQueryError(w, err, "Failed to retrieve feed")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, page)
+}
@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/quietguido/mapnu/mainservice/internal/services/image"
+)
+
+// room for the multipart boundaries and the other form fields
+const multipartOverhead = 1 << 20
+
+// UploadEventImageHandler takes a multipart upload with the image in "file"
+func (st *restH) UploadEventImageHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, image.MaxUploadSize+multipartOverhead)
+	if err := r.ParseMultipartForm(image.MaxUploadSize); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid upload, at most 10 MB allowed")
+		return
+	}
+
+	userID, err := uuid.Parse(r.FormValue("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Missing file")
+		return
+	}
+	defer file.Close()
+
+	uploaded, err := st.services.Image.Upload(r.Context(), eventId, userID, file)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusCreated, uploaded)
+}
+
+func (st *restH) DeleteEventImageHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	imageId, err := strconv.ParseInt(r.PathValue("image_id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid image ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := st.services.Image.Delete(r.Context(), eventId, imageId, userID); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"image_id": imageId,
+		"message":  "Image deleted successfully",
+	})
+}
@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/internal/services/importer"
+)
+
+// ImportEventsHandler takes a multipart upload with the file and the import options as form fields
+func (st *restH) ImportEventsHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	r.Body = http.MaxBytesReader(w, r.Body, importer.MaxUploadSize)
+	if err := r.ParseMultipartForm(importer.MaxUploadSize); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid upload, at most 10 MB allowed")
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Missing file")
+		return
+	}
+	defer file.Close()
+
+	request := eventModel.ImportRequest{
+		Format:    r.FormValue("format"),
+		Organizer: r.FormValue("organizer"),
+		Timezone:  r.FormValue("timezone"),
+	}
+
+	// fall back to the file extension
+	if request.Format == "" {
+		request.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		if request.Format == "json" {
+			request.Format = eventModel.ImportFormatGeoJSON
+		}
+	}
+
+	if dryRunStr := r.FormValue("dry_run"); dryRunStr != "" {
+		request.DryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid dry_run parameter")
+			return
+		}
+	}
+
+	if mappingStr := r.FormValue("mapping"); mappingStr != "" {
+		if err := json.Unmarshal([]byte(mappingStr), &request.Mapping); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid mapping, expected a JSON object")
+			return
+		}
+	}
+
+	if userIDStr := r.FormValue("user_id"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+		request.CreatedBy = &userID
+	}
+
+	result, err := st.services.Import.Import(r.Context(), request, file)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, result)
+}
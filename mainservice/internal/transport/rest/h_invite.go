@@ -0,0 +1,191 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	inviteModel "github.com/quietguido/mapnu/mainservice/internal/repo/invite/model"
+)
+
+// InviteUsersHandler adds users to the invite list by invitee_ids or usernames
+func (st *restH) InviteUsersHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	var inviteUsers inviteModel.InviteUsers
+	if err := JsonBodyDecoding(r, &inviteUsers); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	invited, err := st.services.Invite.Invite(r.Context(), eventId, inviteUsers)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"event_id": eventId,
+		"invited":  invited,
+		"message":  "Users invited",
+	})
+}
+
+func (st *restH) GetInvitesHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	invites, err := st.services.Invite.GetInvites(r.Context(), eventId, userID)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, invites)
+}
+
+func (st *restH) RemoveInviteHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+	inviteeID, err := uuid.Parse(r.PathValue("invitee_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid invitee ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := st.services.Invite.RemoveInvite(r.Context(), eventId, userID, inviteeID); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"message": "Invite removed",
+	})
+}
+
+// CreateInviteLinkHandler creates an invite link, the response holds its URL, signed token and code
+func (st *restH) CreateInviteLinkHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	var createLink inviteModel.CreateLink
+	if err := JsonBodyDecoding(r, &createLink); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	link, err := st.services.Invite.CreateLink(r.Context(), eventId, createLink)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusCreated, link)
+}
+
+func (st *restH) GetInviteLinksHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	links, err := st.services.Invite.GetLinks(r.Context(), eventId, userID)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, links)
+}
+
+// RevokeInviteLinkHandler stops the link from being redeemed, users invited through it stay invited
+func (st *restH) RevokeInviteLinkHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+	linkId, err := strconv.ParseInt(r.PathValue("link_id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid invite link ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := st.services.Invite.RevokeLink(r.Context(), eventId, linkId, userID); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"message": "Invite link revoked",
+	})
+}
+
+// RedeemInviteHandler takes the token from an invite link or a typed in code and
+// responds with the event, which the user can see and book from then on
+func (st *restH) RedeemInviteHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	var redeem inviteModel.RedeemInvite
+	if err := JsonBodyDecoding(r, &redeem); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	event, err := st.services.Invite.Redeem(r.Context(), eventId, redeem)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, event)
+}
@@ -0,0 +1,127 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/internal/services/live"
+)
+
+const (
+	liveWriteWait    = 10 * time.Second
+	livePongWait     = 60 * time.Second
+	livePingPeriod   = livePongWait * 9 / 10
+	liveMaxReadBytes = 4096
+)
+
+var liveUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// the map is public, so are its updates
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// liveMessage is sent by the client, the viewport fields are named like GET /map
+type liveMessage struct {
+	Type string `json:"type"` // "subscribe"
+	eventModel.Viewport
+}
+
+// LiveMapHandler streams map deltas over a WebSocket. The client sends
+// {"type":"subscribe", "firstlon", "firstlat", "secondlon", "secondlat", "from", "to"}
+// and sends it again whenever the viewport changes. Only changes are streamed,
+// the current state comes from GET /map, as it does after a "resync" message.
+func (st *restH) LiveMapHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := liveUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already responded
+		st.lg.Error(err.Error())
+		return
+	}
+	defer conn.Close()
+
+	sub := st.services.Live.Subscribe()
+	defer sub.Close()
+
+	// gorilla allows a single writer, the reader hands its replies over
+	replies := make(chan any, 4)
+	go st.readLiveMap(conn, sub, replies)
+
+	ticker := time.NewTicker(livePingPeriod)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		select {
+		case message := <-sub.Messages():
+			conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			err = conn.WriteMessage(websocket.TextMessage, message)
+		case reply := <-replies:
+			conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			err = conn.WriteJSON(reply)
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			err = conn.WriteMessage(websocket.PingMessage, nil)
+		case <-sub.Done():
+			conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+				time.Now().Add(liveWriteWait),
+			)
+			return
+		}
+
+		if err != nil {
+			st.lg.Debug("Live map client gone", zap.Error(err))
+			return
+		}
+	}
+}
+
+// readLiveMap applies viewport changes until the client disconnects or misses its pongs
+func (st *restH) readLiveMap(conn *websocket.Conn, sub *live.Subscription, replies chan<- any) {
+	defer sub.Close()
+
+	conn.SetReadLimit(liveMaxReadBytes)
+	conn.SetReadDeadline(time.Now().Add(livePongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(livePongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var message liveMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			reply(replies, map[string]any{"type": "error", "error": "Invalid message"})
+			continue
+		}
+
+		switch message.Type {
+		case "subscribe":
+			if err := sub.SetViewport(message.Viewport); err != nil {
+				reply(replies, map[string]any{"type": "error", "error": err.Error()})
+				continue
+			}
+			reply(replies, map[string]any{"type": "subscribed", "viewport": message.Viewport})
+		default:
+			reply(replies, map[string]any{"type": "error", "error": "Unknown message type"})
+		}
+	}
+}
+
+// reply drops the reply when the writer is behind, like deltas it is best effort
+func reply(replies chan<- any, message any) {
+	select {
+	case replies <- message:
+	default:
+	}
+}
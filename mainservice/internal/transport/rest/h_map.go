@@ -2,10 +2,15 @@ package rest
 
 import (
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/pkg/errors"
+
 	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+	"github.com/quietguido/mapnu/mainservice/internal/services/event"
 )
 
 func (st *restH) CreateEventHandler(w http.ResponseWriter, r *http.Request) {
@@ -38,13 +43,25 @@ func (st *restH) GetEventByIdHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the mux can not match /event/{id}.ics on its own
+	if idStr, ok := strings.CutSuffix(eventIdStr, icsSuffix); ok {
+		st.GetEventICSHandler(w, r, idStr)
+		return
+	}
+
 	eventId, err := strconv.Atoi(eventIdStr)
 	if err != nil {
 		RespondWithError(w, http.StatusBadRequest, "bad request")
 		return
 	}
 
-	eventModel, err := st.services.Event.GetEventById(r.Context(), eventId)
+	viewer, err := OptionalUserID(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	eventModel, err := st.services.Event.GetEventById(r.Context(), eventId, viewer)
 	if err != nil {
 		st.lg.Error(err.Error())
 		RespondWithError(w, http.StatusBadRequest, "bad request")
@@ -54,56 +71,230 @@ func (st *restH) GetEventByIdHandler(w http.ResponseWriter, r *http.Request) {
 	RespondWithJson(w, http.StatusOK, eventModel)
 }
 
+// SetEventVisibilityHandler makes the event public, unlisted or invite only
+func (st *restH) SetEventVisibilityHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	var setVisibility eventModel.SetVisibility
+	if err := JsonBodyDecoding(r, &setVisibility); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := st.services.Event.SetVisibility(r.Context(), eventId, setVisibility); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"event_id":   eventId,
+		"visibility": setVisibility.Visibility,
+	})
+}
+
 func (st *restH) GetMapForQuadrantHandler(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
-	firstLon, err := strconv.ParseFloat(query.Get("firstlon"), 64)
+	firstLon, firstLat, secondLon, secondLat, err := parseQuadrant(query)
 	if err != nil {
-		http.Error(w, "Invalid firstlon parameter", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	queryParams := eventModel.GetMapQueryParams{
+		FirstQuadLon:  firstLon,
+		FirstQuadLat:  firstLat,
+		SecondQuadLon: secondLon,
+		SecondQuadLat: secondLat,
+		EventFilters:  parseEventFilters(query),
+	}
+
+	// either a whole day or an explicit from/to window
+	if query.Has("from") || query.Has("to") {
+		queryParams.From, err = time.Parse(time.RFC3339, query.Get("from"))
+		if err != nil {
+			http.Error(w, "Invalid from parameter (must be RFC3339 format)", http.StatusBadRequest)
+			return
+		}
+		queryParams.To, err = time.Parse(time.RFC3339, query.Get("to"))
+		if err != nil {
+			http.Error(w, "Invalid to parameter (must be RFC3339 format)", http.StatusBadRequest)
+			return
+		}
+	} else {
+		dateStr := query.Get("date")
+		queryParams.Date, err = time.Parse(time.RFC3339, dateStr)
+		if err != nil {
+			http.Error(w, "Invalid date parameter (must be RFC3339 format)", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if queryParams.FirstQuadLon == 0 || queryParams.FirstQuadLat == 0 || queryParams.SecondQuadLon == 0 || queryParams.SecondQuadLat == 0 {
+		RespondWithError(w, http.StatusBadRequest, "Missing or invalid quadrant parameters")
 		return
 	}
-	firstLat, err := strconv.ParseFloat(query.Get("firstlat"), 64)
+
+	viewer, err := OptionalUserID(r)
 	if err != nil {
-		http.Error(w, "Invalid firstlat parameter", http.StatusBadRequest)
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
-	secondLon, err := strconv.ParseFloat(query.Get("secondlon"), 64)
+
+	events, err := st.services.Event.GetMapForQuadrant(r.Context(), queryParams, viewer)
 	if err != nil {
-		http.Error(w, "Invalid secondlon parameter", http.StatusBadRequest)
+		st.respondQueryError(w, err, "Failed to retrieve events")
 		return
 	}
-	secondLat, err := strconv.ParseFloat(query.Get("secondlat"), 64)
+
+	RespondWithJson(w, http.StatusOK, events)
+}
+
+func (st *restH) GetTrendingHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	firstLon, firstLat, secondLon, secondLat, err := parseQuadrant(query)
 	if err != nil {
-		http.Error(w, "Invalid secondlat parameter", http.StatusBadRequest)
+		RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	dateStr := query.Get("date")
-	date, err := time.Parse(time.RFC3339, dateStr)
+	from, err := time.Parse(time.RFC3339, query.Get("from"))
 	if err != nil {
-		http.Error(w, "Invalid date parameter (must be RFC3339 format)", http.StatusBadRequest)
+		RespondWithError(w, http.StatusBadRequest, "Invalid from parameter (must be RFC3339 format)")
+		return
+	}
+	to, err := time.Parse(time.RFC3339, query.Get("to"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid to parameter (must be RFC3339 format)")
 		return
 	}
 
-	queryParams := eventModel.GetMapQueryParams{
+	var limit int
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	viewer, err := OptionalUserID(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	queryParams := eventModel.GetTrendingQueryParams{
 		FirstQuadLon:  firstLon,
 		FirstQuadLat:  firstLat,
 		SecondQuadLon: secondLon,
 		SecondQuadLat: secondLat,
-		Date:          date,
+		From:          from,
+		To:            to,
+		Limit:         limit,
+		EventFilters:  parseEventFilters(query),
 	}
 
-	if queryParams.FirstQuadLon == 0 || queryParams.FirstQuadLat == 0 || queryParams.SecondQuadLon == 0 || queryParams.SecondQuadLat == 0 {
-		RespondWithError(w, http.StatusBadRequest, "Missing or invalid quadrant parameters")
+	events, err := st.services.Event.GetTrending(r.Context(), queryParams, viewer)
+	if err != nil {
+		st.respondQueryError(w, err, "Failed to retrieve trending events")
 		return
 	}
 
-	events, err := st.services.Event.GetMapForQuadrant(r.Context(), queryParams)
+	RespondWithJson(w, http.StatusOK, events)
+}
+
+func (st *restH) SearchEventsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	queryParams := eventModel.SearchQueryParams{
+		Query:        query.Get("q"),
+		EventFilters: parseEventFilters(query),
+	}
+
+	var err error
+	if fromStr := query.Get("from"); fromStr != "" {
+		queryParams.From, err = time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid from parameter (must be RFC3339 format)")
+			return
+		}
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		queryParams.To, err = time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid to parameter (must be RFC3339 format)")
+			return
+		}
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		queryParams.Limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	viewer, err := OptionalUserID(r)
 	if err != nil {
-		st.lg.Error(err.Error())
-		RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve events")
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	events, err := st.services.Event.Search(r.Context(), queryParams, viewer)
+	if err != nil {
+		st.respondQueryError(w, err, "Failed to search events")
 		return
 	}
 
 	RespondWithJson(w, http.StatusOK, events)
 }
+
+func (st *restH) GetCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	RespondWithJson(w, http.StatusOK, event.Categories)
+}
+
+// respondQueryError answers 400 for parameters refused with a QueryError and 500 for anything else
+func (st *restH) respondQueryError(w http.ResponseWriter, err error, message string) {
+	var queryErr *event.QueryError
+	if errors.As(err, &queryErr) {
+		RespondWithError(w, http.StatusBadRequest, queryErr.Reason)
+		return
+	}
+
+	st.lg.Error(err.Error())
+	RespondWithError(w, http.StatusInternalServerError, message)
+}
+
+func parseEventFilters(query url.Values) eventModel.EventFilters {
+	return eventModel.EventFilters{
+		Category: query.Get("category"),
+		Tags:     query["tag"],
+	}
+}
+
+func parseQuadrant(query url.Values) (firstLon, firstLat, secondLon, secondLat float64, err error) {
+	firstLon, err = strconv.ParseFloat(query.Get("firstlon"), 64)
+	if err != nil {
+		return 0, 0, 0, 0, errors.New("Invalid firstlon parameter")
+	}
+	firstLat, err = strconv.ParseFloat(query.Get("firstlat"), 64)
+	if err != nil {
+		return 0, 0, 0, 0, errors.New("Invalid firstlat parameter")
+	}
+	secondLon, err = strconv.ParseFloat(query.Get("secondlon"), 64)
+	if err != nil {
+		return 0, 0, 0, 0, errors.New("Invalid secondlon parameter")
+	}
+	secondLat, err = strconv.ParseFloat(query.Get("secondlat"), 64)
+	if err != nil {
+		return 0, 0, 0, 0, errors.New("Invalid secondlat parameter")
+	}
+	return firstLon, firstLat, secondLon, secondLat, nil
+}
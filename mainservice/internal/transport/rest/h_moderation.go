@@ -0,0 +1,142 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	reportModel "github.com/quietguido/mapnu/mainservice/internal/repo/report/model"
+)
+
+func (st *restH) CreateReportHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	var createReport reportModel.CreateReport
+	if err := JsonBodyDecoding(r, &createReport); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if createReport.ReporterID == uuid.Nil {
+		RespondWithError(w, http.StatusBadRequest, "Missing user ID")
+		return
+	}
+
+	reportId, err := st.services.Moderation.Report(r.Context(), createReport)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusCreated, map[string]any{
+		"report_id": reportId,
+		"message":   "Report submitted successfully",
+	})
+}
+
+// GetModerationQueueHandler pages through reports with ?cursor=<next_cursor of the previous page>
+func (st *restH) GetModerationQueueHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	query := r.URL.Query()
+
+	moderatorID, err := uuid.Parse(query.Get("moderator_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid moderator ID")
+		return
+	}
+
+	var params reportModel.GetQueueQueryParams
+	if err := DecodeQuery(r, &params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if assignedTo := query.Get("assigned_to"); assignedTo != "" {
+		assigneeID, err := uuid.Parse(assignedTo)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid assignee ID")
+			return
+		}
+		params.AssignedTo = &assigneeID
+	}
+
+	page, err := st.services.Moderation.GetQueue(r.Context(), moderatorID, params, query.Get("cursor"))
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, page)
+}
+
+func (st *restH) AssignReportHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	reportId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	var assign reportModel.AssignReport
+	if err := JsonBodyDecoding(r, &assign); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := st.services.Moderation.Assign(r.Context(), reportId, assign); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"report_id":   reportId,
+		"assigned_to": assign.AssigneeID,
+	})
+}
+
+func (st *restH) ResolveReportHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	reportId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid report ID")
+		return
+	}
+
+	var resolve reportModel.ResolveReport
+	if err := JsonBodyDecoding(r, &resolve); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := st.services.Moderation.Resolve(r.Context(), reportId, resolve); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"report_id": reportId,
+		"hidden":    resolve.Hide,
+		"message":   "Report closed successfully",
+	})
+}
+
+// SetContentHiddenHandler hides or restores /moderation/content/{event|comment|user}/{id}
+func (st *restH) SetContentHiddenHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	targetType := r.PathValue("type")
+	targetId := r.PathValue("id")
+
+	var setHidden reportModel.SetContentHidden
+	if err := JsonBodyDecoding(r, &setHidden); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := st.services.Moderation.SetHidden(r.Context(), targetType, targetId, setHidden); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"target_type": targetType,
+		"target_id":   targetId,
+		"hidden":      setHidden.Hidden,
+	})
+}
@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	notificationModel "github.com/quietguido/mapnu/mainservice/internal/repo/notification/model"
+)
+
+// GetNotificationsHandler pages through the user's delivery log with ?cursor=<next_cursor of the previous page>
+func (st *restH) GetNotificationsHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	limit, err := QueryLimit(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+
+	page, err := st.services.Notification.GetDeliveries(r.Context(), userID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, page)
+}
+
+func (st *restH) GetNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	preferences, err := st.services.Notification.GetPreferences(r.Context(), userID)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to retrieve preferences")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, preferences)
+}
+
+func (st *restH) SetNotificationPreferenceHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	var setPreference notificationModel.SetPreference
+	if err := JsonBodyDecoding(r, &setPreference); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := st.services.Notification.SetPreference(r.Context(), setPreference); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"type":  setPreference.Type,
+		"email": setPreference.Email,
+		"push":  setPreference.Push,
+	})
+}
+
+func (st *restH) RegisterPushDeviceHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	var device notificationModel.RegisterPushDevice
+	if err := JsonBodyDecoding(r, &device); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deviceId, err := st.services.Notification.RegisterPushDevice(r.Context(), device)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusCreated, map[string]any{
+		"device_id": deviceId,
+		"message":   "Push device registered",
+	})
+}
+
+// RemovePushDeviceHandler unregisters ?token=, e.g. on logout
+func (st *restH) RemovePushDeviceHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	query := r.URL.Query()
+
+	userID, err := uuid.Parse(query.Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := st.services.Notification.RemovePushDevice(r.Context(), userID, query.Get("token")); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"message": "Push device removed",
+	})
+}
@@ -0,0 +1,136 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	organizerModel "github.com/quietguido/mapnu/mainservice/internal/repo/organizer/model"
+)
+
+func (st *restH) CreateOrganizerHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	var createOrganizer organizerModel.CreateOrganizer
+	if err := JsonBodyDecoding(r, &createOrganizer); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if createOrganizer.UserID == uuid.Nil {
+		RespondWithError(w, http.StatusBadRequest, "Missing user ID")
+		return
+	}
+
+	organizerId, err := st.services.Organizer.Create(r.Context(), createOrganizer)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to create organizer")
+		return
+	}
+
+	response := map[string]any{
+		"organizer_id": organizerId,
+		"message":      "Organizer created successfully",
+	}
+
+	RespondWithJson(w, http.StatusOK, response)
+}
+
+func (st *restH) GetOrganizerByIdHandler(w http.ResponseWriter, r *http.Request) {
+	organizerId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid organizer ID")
+		return
+	}
+
+	organizer, err := st.services.Organizer.GetOrganizerById(r.Context(), organizerId)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusNotFound, "Organizer not found")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, organizer)
+}
+
+func (st *restH) UpdateOrganizerHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	organizerId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid organizer ID")
+		return
+	}
+
+	var update organizerModel.UpdateOrganizer
+	if err := JsonBodyDecoding(r, &update); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := st.services.Organizer.Update(r.Context(), organizerId, update); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to update organizer")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"organizer_id": organizerId,
+		"message":      "Organizer updated successfully",
+	})
+}
+
+// SetOrganizerMemberHandler adds a member or changes the role of an existing one
+func (st *restH) SetOrganizerMemberHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	organizerId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid organizer ID")
+		return
+	}
+
+	var setMember organizerModel.SetMember
+	if err := JsonBodyDecoding(r, &setMember); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := st.services.Organizer.SetMember(r.Context(), organizerId, setMember); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"organizer_id": organizerId,
+		"member_id":    setMember.MemberID,
+		"role":         setMember.Role,
+	})
+}
+
+func (st *restH) RemoveOrganizerMemberHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	organizerId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid organizer ID")
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("member_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid member ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := st.services.Organizer.RemoveMember(r.Context(), organizerId, userID, memberID); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"organizer_id": organizerId,
+		"message":      "Member removed successfully",
+	})
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	outboxModel "github.com/quietguido/mapnu/mainservice/internal/repo/outbox/model"
+)
+
+// GetOutboxMessagesHandler pages through outbox messages, newest first, with ?cursor=<next_cursor of the previous page>
+func (st *restH) GetOutboxMessagesHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	query := r.URL.Query()
+
+	adminID, err := uuid.Parse(query.Get("admin_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid admin ID")
+		return
+	}
+
+	var params outboxModel.GetMessagesQueryParams
+	if err := DecodeQuery(r, &params); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := st.services.Outbox.GetMessages(r.Context(), adminID, params, query.Get("cursor"))
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, page)
+}
+
+func (st *restH) GetOutboxMessageHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	messageId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	adminID, err := uuid.Parse(r.URL.Query().Get("admin_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid admin ID")
+		return
+	}
+
+	message, err := st.services.Outbox.GetMessage(r.Context(), adminID, messageId)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, message)
+}
+
+// ReplayOutboxMessageHandler makes a pending or dead message due now, with a fresh attempt budget
+func (st *restH) ReplayOutboxMessageHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	messageId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid message ID")
+		return
+	}
+
+	var replay outboxModel.ReplayMessages
+	if err := JsonBodyDecoding(r, &replay); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := st.services.Outbox.Replay(r.Context(), replay.AdminID, messageId); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"message_id": messageId,
+		"message":    "Message replayed",
+	})
+}
+
+// ReplayDeadOutboxMessagesHandler replays every dead message, of {"topic"} when given
+func (st *restH) ReplayDeadOutboxMessagesHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	var replay outboxModel.ReplayMessages
+	if err := JsonBodyDecoding(r, &replay); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	replayed, err := st.services.Outbox.ReplayDead(r.Context(), replay)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"replayed": replayed,
+	})
+}
@@ -0,0 +1,134 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	reviewModel "github.com/quietguido/mapnu/mainservice/internal/repo/review/model"
+)
+
+func (st *restH) CreateReviewHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	var createReview reviewModel.CreateReview
+	if err := JsonBodyDecoding(r, &createReview); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if createReview.UserID == uuid.Nil {
+		RespondWithError(w, http.StatusBadRequest, "Missing user ID")
+		return
+	}
+
+	reviewId, err := st.services.Review.Create(r.Context(), createReview)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusCreated, map[string]any{
+		"review_id": reviewId,
+		"message":   "Review created successfully",
+	})
+}
+
+func (st *restH) GetReviewByIdHandler(w http.ResponseWriter, r *http.Request) {
+	reviewId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	review, err := st.services.Review.GetReviewById(r.Context(), reviewId)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusNotFound, "Review not found")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, review)
+}
+
+// GetEventReviewsHandler pages through reviews with ?cursor=<next_cursor of the previous page>
+func (st *restH) GetEventReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	limit, err := QueryLimit(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+
+	viewer, err := OptionalUserID(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	page, err := st.services.Review.GetReviewsForEvent(r.Context(), eventId, viewer, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to retrieve reviews")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, page)
+}
+
+func (st *restH) UpdateReviewHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	reviewId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	var update reviewModel.UpdateReview
+	if err := JsonBodyDecoding(r, &update); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if update.UserID == uuid.Nil {
+		RespondWithError(w, http.StatusBadRequest, "Missing user ID")
+		return
+	}
+
+	if err := st.services.Review.Update(r.Context(), reviewId, update); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"review_id": reviewId,
+		"message":   "Review updated successfully",
+	})
+}
+
+func (st *restH) DeleteReviewHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	reviewId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := st.services.Review.Delete(r.Context(), reviewId, userID); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"review_id": reviewId,
+		"message":   "Review deleted successfully",
+	})
+}
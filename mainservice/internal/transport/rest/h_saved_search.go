@@ -0,0 +1,96 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	savedSearchModel "github.com/quietguido/mapnu/mainservice/internal/repo/savedsearch/model"
+)
+
+// CreateSavedSearchHandler saves a search, new events matching it are sent in the daily digest
+func (st *restH) CreateSavedSearchHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	var save savedSearchModel.SaveSearch
+	if err := JsonBodyDecoding(r, &save); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	searchId, err := st.services.SavedSearch.Create(r.Context(), save)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusCreated, map[string]any{
+		"search_id": searchId,
+		"message":   "Search saved",
+	})
+}
+
+func (st *restH) GetSavedSearchesHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	searches, err := st.services.SavedSearch.GetSearches(r.Context(), userID)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to retrieve saved searches")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, searches)
+}
+
+func (st *restH) UpdateSavedSearchHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	searchId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid saved search ID")
+		return
+	}
+
+	var save savedSearchModel.SaveSearch
+	if err := JsonBodyDecoding(r, &save); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := st.services.SavedSearch.Update(r.Context(), searchId, save); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"search_id": searchId,
+		"message":   "Saved search updated",
+	})
+}
+
+func (st *restH) DeleteSavedSearchHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	searchId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid saved search ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := st.services.SavedSearch.Delete(r.Context(), searchId, userID); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"message": "Saved search deleted",
+	})
+}
@@ -0,0 +1,142 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	eventModel "github.com/quietguido/mapnu/mainservice/internal/repo/event/model"
+)
+
+func (st *restH) CreateSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	var createSeries eventModel.CreateSeries
+
+	if err := JsonBodyDecoding(r, &createSeries); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	seriesId, err := st.services.Event.CreateSeries(r.Context(), createSeries)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	response := map[string]any{
+		"series_id": seriesId,
+		"message":   "Series created successfully",
+	}
+
+	RespondWithJson(w, http.StatusOK, response)
+}
+
+func (st *restH) GetSeriesByIdHandler(w http.ResponseWriter, r *http.Request) {
+	seriesId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid series ID")
+		return
+	}
+
+	series, err := st.services.Event.GetSeriesById(r.Context(), seriesId)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusNotFound, "Series not found")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, series)
+}
+
+// UpdateSeriesHandler edits all occurrences starting at "from"
+func (st *restH) UpdateSeriesHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	seriesId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid series ID")
+		return
+	}
+
+	var update eventModel.UpdateSeries
+	if err := JsonBodyDecoding(r, &update); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = st.services.Event.UpdateSeries(r.Context(), seriesId, update)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to update series")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"series_id": seriesId,
+		"message":   "Series updated successfully",
+	})
+}
+
+// UpdateOccurrenceHandler edits a single occurrence of the series
+func (st *restH) UpdateOccurrenceHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	seriesId, eventId, ok := occurrencePath(w, r)
+	if !ok {
+		return
+	}
+
+	var update eventModel.UpdateOccurrence
+	if err := JsonBodyDecoding(r, &update); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := st.services.Event.UpdateOccurrence(r.Context(), seriesId, eventId, update)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to update occurrence")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"event_id": eventId,
+		"message":  "Occurrence updated successfully",
+	})
+}
+
+func (st *restH) CancelOccurrenceHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	seriesId, eventId, ok := occurrencePath(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	err = st.services.Event.CancelOccurrence(r.Context(), seriesId, eventId, userID)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to cancel occurrence")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"event_id": eventId,
+		"message":  "Occurrence cancelled successfully",
+	})
+}
+
+func occurrencePath(w http.ResponseWriter, r *http.Request) (seriesId, eventId int64, ok bool) {
+	seriesId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid series ID")
+		return 0, 0, false
+	}
+
+	eventId, err = strconv.ParseInt(r.PathValue("event_id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return 0, 0, false
+	}
+	return seriesId, eventId, true
+}
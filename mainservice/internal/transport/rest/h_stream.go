@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/quietguido/mapnu/mainservice/internal/services/stream"
+)
+
+const (
+	streamHeartbeat = 25 * time.Second
+	// how long EventSource clients wait before reconnecting, in milliseconds
+	streamRetry = 3000
+)
+
+// StreamEventsHandler streams booking status changes, booking applications and
+// cancellations as Server-Sent Events. The SSE id is the position in the user's
+// event log, a reconnect with Last-Event-ID (or ?last_event_id=) resumes after it.
+func (st *restH) StreamEventsHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = r.URL.Query().Get("last_event_id")
+	}
+
+	// subscribe before reading the log, so nothing appended in between is missed
+	sub := st.services.Stream.Subscribe(userID)
+	defer sub.Close()
+
+	afterId, err := st.services.Stream.Resume(r.Context(), userID, lastEventId)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// the stream outlives the server's read timeout
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		st.lg.Error(err.Error())
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		// drain the log, also after heartbeats in case a notification was lost
+		// while the listener reconnected
+		for {
+			events, err := st.services.Stream.GetEventsAfter(r.Context(), userID, afterId)
+			if err != nil {
+				st.lg.Error(err.Error())
+				return
+			}
+			for _, event := range events {
+				fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, event.Payload)
+				afterId = event.Seq
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+			if len(events) < stream.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-sub.Wake():
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-sub.Done():
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
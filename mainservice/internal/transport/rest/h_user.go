@@ -44,3 +44,22 @@ func (st *restH) GetUserByIdHandler(w http.ResponseWriter, r *http.Request) {
 
 	RespondWithJson(w, http.StatusOK, userModel)
 }
+
+func (st *restH) UpdateUserSettingsHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	var settings userModel.UpdateSettings
+	if err := JsonBodyDecoding(r, &settings); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := st.services.User.UpdateSettings(r.Context(), settings); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"locale":   settings.Locale,
+		"timezone": settings.Timezone,
+	})
+}
@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	venueModel "github.com/quietguido/mapnu/mainservice/internal/repo/venue/model"
+)
+
+func (st *restH) CreateVenueHandler(w http.ResponseWriter, r *http.Request) {
+	var createVenue venueModel.CreateVenue
+	if err := JsonBodyDecoding(r, &createVenue); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	venueId, err := st.services.Venue.Create(r.Context(), createVenue)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to create venue")
+		return
+	}
+
+	response := map[string]any{
+		"venue_id": venueId,
+		"message":  "Venue created successfully",
+	}
+
+	RespondWithJson(w, http.StatusOK, response)
+}
+
+func (st *restH) GetVenueByIdHandler(w http.ResponseWriter, r *http.Request) {
+	venueId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "bad request")
+		return
+	}
+
+	venue, err := st.services.Venue.GetVenueById(r.Context(), venueId)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusNotFound, "Venue not found")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, venue)
+}
+
+func (st *restH) GetVenuesMapHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	firstLon, firstLat, secondLon, secondLat, err := parseQuadrant(query)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	queryParams := venueModel.GetVenuesMapQueryParams{
+		FirstQuadLon:  firstLon,
+		FirstQuadLat:  firstLat,
+		SecondQuadLon: secondLon,
+		SecondQuadLat: secondLat,
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		queryParams.From, err = time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid from parameter (must be RFC3339 format)")
+			return
+		}
+	}
+	if limitStr := query.Get("events_limit"); limitStr != "" {
+		queryParams.EventsLimit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Invalid events_limit parameter")
+			return
+		}
+	}
+
+	venues, err := st.services.Venue.GetMapForQuadrant(r.Context(), queryParams)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to retrieve venues")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, venues)
+}
@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	voteModel "github.com/quietguido/mapnu/mainservice/internal/repo/vote/model"
+)
+
+func (st *restH) SetVoteHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	var setVote voteModel.SetVote
+	if err := JsonBodyDecoding(r, &setVote); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	setVote.EventID = eventId
+
+	if setVote.UserID == uuid.Nil {
+		RespondWithError(w, http.StatusBadRequest, "Missing user ID")
+		return
+	}
+
+	err = st.services.Event.Vote(r.Context(), setVote)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to vote")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"event_id": eventId,
+		"vote":     setVote.Vote,
+	})
+}
+
+func (st *restH) DeleteVoteHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	eventId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	err = st.services.Event.RemoveVote(r.Context(), userID, eventId)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, "Failed to remove vote")
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"event_id": eventId,
+		"message":  "Vote removed successfully",
+	})
+}
@@ -0,0 +1,181 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	webhookModel "github.com/quietguido/mapnu/mainservice/internal/repo/webhook/model"
+)
+
+// CreateWebhookHandler subscribes an endpoint of the organizer, the response
+// holds the signing secret, which isn't shown again
+func (st *restH) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	organizerId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid organizer ID")
+		return
+	}
+
+	var createWebhook webhookModel.CreateWebhook
+	if err := JsonBodyDecoding(r, &createWebhook); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	webhook, err := st.services.Webhook.Create(r.Context(), organizerId, createWebhook)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusCreated, webhook)
+}
+
+func (st *restH) GetWebhooksHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	organizerId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid organizer ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	webhooks, err := st.services.Webhook.GetWebhooks(r.Context(), organizerId, userID)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, webhooks)
+}
+
+// UpdateWebhookHandler replaces the URL, event types and active flag, reactivating a disabled webhook
+func (st *restH) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	organizerId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid organizer ID")
+		return
+	}
+	webhookId, err := strconv.ParseInt(r.PathValue("webhook_id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	var update webhookModel.UpdateWebhook
+	if err := JsonBodyDecoding(r, &update); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := st.services.Webhook.Update(r.Context(), organizerId, webhookId, update); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"webhook_id": webhookId,
+		"message":    "Webhook updated successfully",
+	})
+}
+
+func (st *restH) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	organizerId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid organizer ID")
+		return
+	}
+	webhookId, err := strconv.ParseInt(r.PathValue("webhook_id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := st.services.Webhook.Delete(r.Context(), organizerId, webhookId, userID); err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, map[string]any{
+		"message": "Webhook deleted",
+	})
+}
+
+// GetWebhookDeliveriesHandler pages through the delivery history with ?cursor=<next_cursor of the previous page>
+func (st *restH) GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	organizerId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid organizer ID")
+		return
+	}
+	webhookId, err := strconv.ParseInt(r.PathValue("webhook_id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	limit, err := QueryLimit(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid limit parameter")
+		return
+	}
+
+	page, err := st.services.Webhook.GetDeliveries(r.Context(), organizerId, webhookId, userID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, page)
+}
+
+// SendTestWebhookHandler sends a test delivery right away and responds with its outcome
+func (st *restH) SendTestWebhookHandler(w http.ResponseWriter, r *http.Request) { // change for token
+	organizerId, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid organizer ID")
+		return
+	}
+	webhookId, err := strconv.ParseInt(r.PathValue("webhook_id"), 10, 64)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	var test webhookModel.TestWebhook
+	if err := JsonBodyDecoding(r, &test); err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	delivery, err := st.services.Webhook.SendTest(r.Context(), organizerId, webhookId, test.UserID)
+	if err != nil {
+		st.lg.Error(err.Error())
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	RespondWithJson(w, http.StatusOK, delivery)
+}
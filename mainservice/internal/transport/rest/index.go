@@ -7,6 +7,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/quietguido/mapnu/mainservice/internal/services"
+	"github.com/quietguido/mapnu/mainservice/internal/services/image"
 	"github.com/quietguido/mapnu/mainservice/pkg/middleware"
 )
 
@@ -40,11 +41,104 @@ func GetHandler(
 	//user
 	router.HandleFunc("POST /user", restH.CreateUserHandler)
 	router.HandleFunc("GET /user/{id}", restH.GetUserByIdHandler)
+	router.HandleFunc("PUT /me/settings", restH.UpdateUserSettingsHandler)
 
 	//event
 	router.HandleFunc("POST /event", restH.CreateEventHandler)
 	router.HandleFunc("GET /event/{id}", restH.GetEventByIdHandler)
+	router.HandleFunc("PUT /event/{id}/visibility", restH.SetEventVisibilityHandler)
 	router.HandleFunc("GET /map", restH.GetMapForQuadrantHandler)
+	router.HandleFunc("GET /ws/map", restH.LiveMapHandler)
+	router.HandleFunc("GET /events/trending", restH.GetTrendingHandler)
+	router.HandleFunc("GET /events/search", restH.SearchEventsHandler)
+	router.HandleFunc("GET /events/categories", restH.GetCategoriesHandler)
+	router.HandleFunc("POST /events/import", restH.ImportEventsHandler)
+
+	//image
+	router.HandleFunc("POST /event/{id}/images", restH.UploadEventImageHandler)
+	router.HandleFunc("DELETE /event/{id}/images/{image_id}", restH.DeleteEventImageHandler)
+	if files := services.Image.FileHandler(); files != nil {
+		router.Handle("GET "+image.UploadsPath, http.StripPrefix(image.UploadsPath, files))
+	}
+
+	//venue
+	router.HandleFunc("POST /venue", restH.CreateVenueHandler)
+	router.HandleFunc("GET /venue/{id}", restH.GetVenueByIdHandler)
+	router.HandleFunc("GET /venues/map", restH.GetVenuesMapHandler)
+	router.HandleFunc("PUT /venue/{id}/follow", restH.FollowVenueHandler)
+	router.HandleFunc("DELETE /venue/{id}/follow", restH.UnfollowVenueHandler)
+
+	//organizer
+	router.HandleFunc("POST /organizer", restH.CreateOrganizerHandler)
+	router.HandleFunc("GET /organizer/{id}", restH.GetOrganizerByIdHandler)
+	router.HandleFunc("PUT /organizer/{id}", restH.UpdateOrganizerHandler)
+	router.HandleFunc("PUT /organizer/{id}/members", restH.SetOrganizerMemberHandler)
+	router.HandleFunc("DELETE /organizer/{id}/members/{member_id}", restH.RemoveOrganizerMemberHandler)
+	router.HandleFunc("PUT /organizer/{id}/follow", restH.FollowOrganizerHandler)
+	router.HandleFunc("DELETE /organizer/{id}/follow", restH.UnfollowOrganizerHandler)
+	router.HandleFunc("POST /organizer/{id}/webhooks", restH.CreateWebhookHandler)
+	router.HandleFunc("GET /organizer/{id}/webhooks", restH.GetWebhooksHandler)
+	router.HandleFunc("PUT /organizer/{id}/webhooks/{webhook_id}", restH.UpdateWebhookHandler)
+	router.HandleFunc("DELETE /organizer/{id}/webhooks/{webhook_id}", restH.DeleteWebhookHandler)
+	router.HandleFunc("GET /organizer/{id}/webhooks/{webhook_id}/deliveries", restH.GetWebhookDeliveriesHandler)
+	router.HandleFunc("POST /organizer/{id}/webhooks/{webhook_id}/test", restH.SendTestWebhookHandler)
+
+	//feed
+	router.HandleFunc("GET /feed", restH.GetFeedHandler)
+	router.HandleFunc("GET /following", restH.GetFollowingHandler)
+
+	//series
+	router.HandleFunc("POST /series", restH.CreateSeriesHandler)
+	router.HandleFunc("GET /series/{id}", restH.GetSeriesByIdHandler)
+	router.HandleFunc("PUT /series/{id}", restH.UpdateSeriesHandler)
+	router.HandleFunc("PUT /series/{id}/occurrences/{event_id}", restH.UpdateOccurrenceHandler)
+	router.HandleFunc("DELETE /series/{id}/occurrences/{event_id}", restH.CancelOccurrenceHandler)
+
+	//comment
+	router.HandleFunc("POST /event/{id}/comments", restH.CreateCommentHandler)
+	router.HandleFunc("GET /event/{id}/comments", restH.GetCommentsHandler)
+	router.HandleFunc("GET /comment/{id}/replies", restH.GetCommentRepliesHandler)
+	router.HandleFunc("PUT /comment/{id}", restH.UpdateCommentHandler)
+	router.HandleFunc("DELETE /comment/{id}", restH.DeleteCommentHandler)
+	router.HandleFunc("PUT /comment/{id}/pin", restH.PinCommentHandler)
+
+	//review
+	router.HandleFunc("POST /review", restH.CreateReviewHandler)
+	router.HandleFunc("GET /review/{id}", restH.GetReviewByIdHandler)
+	router.HandleFunc("PUT /review/{id}", restH.UpdateReviewHandler)
+	router.HandleFunc("DELETE /review/{id}", restH.DeleteReviewHandler)
+	router.HandleFunc("GET /event/{id}/reviews", restH.GetEventReviewsHandler)
+
+	//moderation
+	router.HandleFunc("POST /report", restH.CreateReportHandler)
+	router.HandleFunc("GET /moderation/reports", restH.GetModerationQueueHandler)
+	router.HandleFunc("PUT /moderation/reports/{id}/assign", restH.AssignReportHandler)
+	router.HandleFunc("PUT /moderation/reports/{id}/resolve", restH.ResolveReportHandler)
+	router.HandleFunc("PUT /moderation/content/{type}/{id}", restH.SetContentHiddenHandler)
+
+	//admin
+	router.HandleFunc("GET /admin/outbox", restH.GetOutboxMessagesHandler)
+	router.HandleFunc("GET /admin/outbox/{id}", restH.GetOutboxMessageHandler)
+	router.HandleFunc("POST /admin/outbox/{id}/replay", restH.ReplayOutboxMessageHandler)
+	router.HandleFunc("POST /admin/outbox/replay", restH.ReplayDeadOutboxMessagesHandler)
+
+	//vote
+	router.HandleFunc("PUT /event/{id}/vote", restH.SetVoteHandler)
+	router.HandleFunc("DELETE /event/{id}/vote", restH.DeleteVoteHandler)
+
+	//invite
+	router.HandleFunc("POST /event/{id}/invites", restH.InviteUsersHandler)
+	router.HandleFunc("GET /event/{id}/invites", restH.GetInvitesHandler)
+	router.HandleFunc("DELETE /event/{id}/invites/{invitee_id}", restH.RemoveInviteHandler)
+	router.HandleFunc("POST /event/{id}/invites/redeem", restH.RedeemInviteHandler)
+	router.HandleFunc("POST /event/{id}/invite-links", restH.CreateInviteLinkHandler)
+	router.HandleFunc("GET /event/{id}/invite-links", restH.GetInviteLinksHandler)
+	router.HandleFunc("DELETE /event/{id}/invite-links/{link_id}", restH.RevokeInviteLinkHandler)
+
+	//bookmark
+	router.HandleFunc("PUT /event/{id}/bookmark", restH.AddBookmarkHandler)
+	router.HandleFunc("DELETE /event/{id}/bookmark", restH.RemoveBookmarkHandler)
+	router.HandleFunc("GET /me/bookmarks", restH.GetBookmarksHandler)
 
 	//booking
 	router.HandleFunc("POST /booking", restH.CreateBookingHandler)
@@ -53,6 +147,27 @@ func GetHandler(
 	router.HandleFunc("POST /booking/status", restH.ChangeBookingStatusHandler)
 	router.HandleFunc("GET /booking/organizer", restH.GetBookingApplicationsForOrganizer)
 
+	//stream
+	router.HandleFunc("GET /me/events/stream", restH.StreamEventsHandler)
+
+	//notification
+	router.HandleFunc("GET /me/notifications", restH.GetNotificationsHandler)
+	router.HandleFunc("GET /me/notifications/preferences", restH.GetNotificationPreferencesHandler)
+	router.HandleFunc("PUT /me/notifications/preferences", restH.SetNotificationPreferenceHandler)
+	router.HandleFunc("POST /me/push-devices", restH.RegisterPushDeviceHandler)
+	router.HandleFunc("DELETE /me/push-devices", restH.RemovePushDeviceHandler)
+
+	//saved search
+	router.HandleFunc("POST /me/saved-searches", restH.CreateSavedSearchHandler)
+	router.HandleFunc("GET /me/saved-searches", restH.GetSavedSearchesHandler)
+	router.HandleFunc("PUT /me/saved-searches/{id}", restH.UpdateSavedSearchHandler)
+	router.HandleFunc("DELETE /me/saved-searches/{id}", restH.DeleteSavedSearchHandler)
+
+	//calendar
+	router.HandleFunc("GET /calendar/{token}", restH.GetCalendarFeedHandler)
+	router.HandleFunc("POST /calendar/token", restH.CreateCalendarTokenHandler)
+	router.HandleFunc("DELETE /calendar/token", restH.RevokeCalendarTokenHandler)
+
 	//oauth
 	router.HandleFunc("/api/user/profile", restH.oauthH.GetUserProfile)
 	router.HandleFunc("POST /auth/token/exchange", restH.oauthH.HandleTokenExchange)
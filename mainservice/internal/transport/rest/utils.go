@@ -3,6 +3,9 @@ package rest
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
 )
 
 type Err string
@@ -48,3 +51,26 @@ func DecodeQuery(r *http.Request, dest any) error {
 	}
 	return nil
 }
+
+// OptionalUserID reads the optional user_id query parameter identifying the caller.
+func OptionalUserID(r *http.Request) (*uuid.UUID, error) { // change for token
+	userIDStr := r.URL.Query().Get("user_id")
+	if userIDStr == "" {
+		return nil, nil
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, err
+	}
+	return &userID, nil
+}
+
+// QueryLimit reads the optional limit query parameter, 0 when it is not set.
+func QueryLimit(r *http.Request) (int, error) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(limitStr)
+}
@@ -0,0 +1,5 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS event_votes_event_id_idx;
+
+-- ❌ Drop event votes table
+DROP TABLE IF EXISTS event_votes;
@@ -0,0 +1,13 @@
+-- ✅ Create event votes table (one vote per user per event)
+CREATE TABLE IF NOT EXISTS event_votes (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL, -- Store event_id manually since we can't have FK to partitioned table
+    value SMALLINT NOT NULL CHECK (value IN (-1, 1)),
+    voted_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (user_id, event_id)
+);
+
+-- ✅ Add an index for faster event lookups in `event_votes`
+CREATE INDEX IF NOT EXISTS event_votes_event_id_idx ON event_votes (event_id);
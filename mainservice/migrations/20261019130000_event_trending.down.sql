@@ -0,0 +1,7 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS bookings_event_id_booked_at_idx;
+
+DROP INDEX IF EXISTS event_trending_score_idx;
+
+-- ❌ Drop trending score table
+DROP TABLE IF EXISTS event_trending;
@@ -0,0 +1,14 @@
+-- ✅ Create precomputed trending score table (refreshed by the service)
+CREATE TABLE IF NOT EXISTS event_trending (
+    event_id BIGINT PRIMARY KEY, -- Store event_id manually since we can't have FK to partitioned table
+    score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    computed_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ✅ Add an index for ordering by score
+CREATE INDEX IF NOT EXISTS event_trending_score_idx ON event_trending (score DESC);
+
+-- ✅ Add an index for booking velocity lookups
+CREATE INDEX IF NOT EXISTS bookings_event_id_booked_at_idx ON bookings (event_id, booked_at);
@@ -0,0 +1,18 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS event_name_trgm_index;
+
+DROP INDEX IF EXISTS event_tags_index;
+
+DROP INDEX IF EXISTS event_category_location_index;
+
+-- ❌ Drop columns
+ALTER TABLE event
+DROP COLUMN IF EXISTS tags;
+
+ALTER TABLE event
+DROP COLUMN IF EXISTS category;
+
+-- ❌ Disable required extensions
+DROP EXTENSION IF EXISTS pg_trgm;
+
+DROP EXTENSION IF EXISTS btree_gist;
@@ -0,0 +1,33 @@
+-- Enable btree_gist so category can share a GiST index with location
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- ✅ Add category and tags to events
+ALTER TABLE event
+ADD COLUMN IF NOT EXISTS category VARCHAR(32) NOT NULL DEFAULT 'other' CHECK (
+    category IN (
+        'music',
+        'sports',
+        'meetup',
+        'arts',
+        'food',
+        'education',
+        'tech',
+        'nightlife',
+        'family',
+        'other'
+    )
+);
+
+ALTER TABLE event
+ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- ✅ Category filter stays on the spatial index instead of a separate scan
+CREATE INDEX IF NOT EXISTS event_category_location_index ON event USING GIST (category, location);
+
+-- ✅ Tag containment (tags @> ARRAY[...]) lookups
+CREATE INDEX IF NOT EXISTS event_tags_index ON event USING GIN (tags);
+
+-- Enable trigram matching for text search on event names
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS event_name_trgm_index ON event USING GIN (name gin_trgm_ops);
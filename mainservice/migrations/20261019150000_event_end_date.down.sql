@@ -0,0 +1,10 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS event_effective_end_index;
+
+-- ❌ Drop constraints
+ALTER TABLE event
+DROP CONSTRAINT IF EXISTS event_end_after_start;
+
+-- ❌ Drop columns
+ALTER TABLE event
+DROP COLUMN IF EXISTS end_date;
@@ -0,0 +1,14 @@
+-- ✅ Add optional end date for multi-day events
+ALTER TABLE event
+ADD COLUMN IF NOT EXISTS end_date TIMESTAMP
+WITH
+    TIME ZONE;
+
+ALTER TABLE event
+ADD CONSTRAINT event_end_after_start CHECK (
+    end_date IS NULL
+    OR end_date > start_date
+);
+
+-- ✅ Overlap queries filter on the effective end of an event
+CREATE INDEX IF NOT EXISTS event_effective_end_index ON event (COALESCE(end_date, start_date));
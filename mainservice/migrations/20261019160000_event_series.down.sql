@@ -0,0 +1,17 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS event_series_occurrence_index;
+
+DROP INDEX IF EXISTS event_series_materialized_until_idx;
+
+-- ❌ Drop columns
+ALTER TABLE event
+DROP COLUMN IF EXISTS is_exception;
+
+ALTER TABLE event
+DROP COLUMN IF EXISTS occurrence_date;
+
+ALTER TABLE event
+DROP COLUMN IF EXISTS series_id;
+
+-- ❌ Drop series table
+DROP TABLE IF EXISTS event_series;
@@ -0,0 +1,49 @@
+-- ✅ Create recurring event series table (RFC 5545 RRULE)
+CREATE TABLE IF NOT EXISTS event_series (
+    series_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    location GEOMETRY (POINT, 4326) NOT NULL,
+    organizer VARCHAR(255) NOT NULL,
+    category VARCHAR(32) NOT NULL DEFAULT 'other',
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    start_date TIMESTAMP
+    WITH
+        TIME ZONE NOT NULL, -- DTSTART of the first occurrence
+        end_date TIMESTAMP
+    WITH
+        TIME ZONE, -- end of the first occurrence, gives the duration of every occurrence
+        timezone VARCHAR(64) NOT NULL DEFAULT 'UTC', -- TZID the rule is expanded in
+        rrule TEXT NOT NULL,
+        exdates TIMESTAMP
+    WITH
+        TIME ZONE[] NOT NULL DEFAULT '{}',
+        materialized_until TIMESTAMP
+    WITH
+        TIME ZONE NOT NULL,
+        created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS event_series_materialized_until_idx ON event_series (materialized_until);
+
+-- ✅ Link generated occurrences to their series
+ALTER TABLE event
+ADD COLUMN IF NOT EXISTS series_id BIGINT REFERENCES event_series (series_id) ON DELETE SET NULL;
+
+-- original start of the occurrence as generated by the rule, kept when the occurrence is moved
+ALTER TABLE event
+ADD COLUMN IF NOT EXISTS occurrence_date TIMESTAMP
+WITH
+    TIME ZONE;
+
+-- edited on its own ("this occurrence"), series edits leave it alone
+ALTER TABLE event
+ADD COLUMN IF NOT EXISTS is_exception BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS event_series_occurrence_index ON event (series_id, occurrence_date);
@@ -0,0 +1,12 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS event_venue_start_date_idx;
+
+-- ❌ Drop columns
+ALTER TABLE event_series
+DROP COLUMN IF EXISTS venue_id;
+
+ALTER TABLE event
+DROP COLUMN IF EXISTS venue_id;
+
+-- ❌ Drop venues table
+DROP TABLE IF EXISTS venues;
@@ -0,0 +1,25 @@
+-- ✅ Create venues table, events at the same venue share its point
+CREATE TABLE IF NOT EXISTS venues (
+    venue_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    address TEXT NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    location GEOMETRY (POINT, 4326) NOT NULL, -- pin on the map, inside area when both are set
+    area GEOMETRY (POLYGON, 4326), -- outline of larger venues (parks, stadiums)
+    capacity INTEGER CHECK (capacity > 0),
+    accessibility JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS venues_location_idx ON venues USING GIST (location);
+
+-- ✅ Link events and series to their venue
+ALTER TABLE event
+ADD COLUMN IF NOT EXISTS venue_id BIGINT REFERENCES venues (venue_id) ON DELETE SET NULL;
+
+ALTER TABLE event_series
+ADD COLUMN IF NOT EXISTS venue_id BIGINT REFERENCES venues (venue_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS event_venue_start_date_idx ON event (venue_id, start_date);
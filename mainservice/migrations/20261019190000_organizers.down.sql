@@ -0,0 +1,16 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS event_organizer_start_date_idx;
+
+DROP INDEX IF EXISTS organizer_members_user_idx;
+
+-- ❌ Drop columns
+ALTER TABLE event_series
+DROP COLUMN IF EXISTS organizer_id;
+
+ALTER TABLE event
+DROP COLUMN IF EXISTS organizer_id;
+
+-- ❌ Drop organizer tables
+DROP TABLE IF EXISTS organizer_members;
+
+DROP TABLE IF EXISTS organizers;
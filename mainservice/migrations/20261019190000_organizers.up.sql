@@ -0,0 +1,37 @@
+-- ✅ Create organizers table
+CREATE TABLE IF NOT EXISTS organizers (
+    organizer_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    logo_url TEXT NOT NULL DEFAULT '',
+    website TEXT NOT NULL DEFAULT '',
+    verified BOOLEAN NOT NULL DEFAULT FALSE, -- set by staff, not through the API
+    created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ✅ Create organizer members table
+CREATE TABLE IF NOT EXISTS organizer_members (
+    organizer_id BIGINT REFERENCES organizers (organizer_id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'admin', 'editor')),
+    added_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (organizer_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS organizer_members_user_idx ON organizer_members (user_id);
+
+-- ✅ Link events and series to their organizer, event.organizer keeps the display name
+ALTER TABLE event
+ADD COLUMN IF NOT EXISTS organizer_id BIGINT REFERENCES organizers (organizer_id) ON DELETE SET NULL;
+
+ALTER TABLE event_series
+ADD COLUMN IF NOT EXISTS organizer_id BIGINT REFERENCES organizers (organizer_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS event_organizer_start_date_idx ON event (organizer_id, start_date);
@@ -0,0 +1,13 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS friendships_user2_idx;
+
+DROP INDEX IF EXISTS bookings_user_id_idx;
+
+-- ❌ Drop columns
+ALTER TABLE organizers
+DROP COLUMN IF EXISTS follower_count;
+
+-- ❌ Drop follows tables
+DROP TABLE IF EXISTS venue_follows;
+
+DROP TABLE IF EXISTS organizer_follows;
@@ -0,0 +1,28 @@
+-- ✅ Create organizer follows table
+CREATE TABLE IF NOT EXISTS organizer_follows (
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE,
+    organizer_id BIGINT REFERENCES organizers (organizer_id) ON DELETE CASCADE,
+    followed_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (user_id, organizer_id)
+);
+
+-- ✅ Create venue follows table
+CREATE TABLE IF NOT EXISTS venue_follows (
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE,
+    venue_id BIGINT REFERENCES venues (venue_id) ON DELETE CASCADE,
+    followed_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (user_id, venue_id)
+);
+
+-- ✅ Follower counter, kept in sync with organizer_follows
+ALTER TABLE organizers
+ADD COLUMN IF NOT EXISTS follower_count INTEGER NOT NULL DEFAULT 0;
+
+-- ✅ Indexes for the feed
+CREATE INDEX IF NOT EXISTS bookings_user_id_idx ON bookings (user_id);
+
+CREATE INDEX IF NOT EXISTS friendships_user2_idx ON friendships (user2_id);
@@ -0,0 +1,7 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS event_comments_root_idx;
+
+DROP INDEX IF EXISTS event_comments_event_idx;
+
+-- ❌ Drop event comments table
+DROP TABLE IF EXISTS event_comments;
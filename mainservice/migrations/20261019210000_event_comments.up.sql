@@ -0,0 +1,24 @@
+-- ✅ Create event comments table
+CREATE TABLE IF NOT EXISTS event_comments (
+    comment_id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT NOT NULL, -- Store event_id manually since we can't have FK to partitioned table
+    root_id BIGINT REFERENCES event_comments (comment_id) ON DELETE CASCADE, -- top level comment of the thread, NULL for top level comments
+    parent_id BIGINT REFERENCES event_comments (comment_id) ON DELETE CASCADE, -- comment replied to
+    user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    mentions UUID[] NOT NULL DEFAULT '{}',
+    pinned BOOLEAN NOT NULL DEFAULT FALSE, -- pinned by the organizer
+    status VARCHAR(16) NOT NULL DEFAULT 'visible' CHECK (status IN ('visible', 'hidden', 'deleted')),
+    created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        edited_at TIMESTAMP
+    WITH
+        TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS event_comments_event_idx ON event_comments (event_id, created_at, comment_id)
+WHERE
+    root_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS event_comments_root_idx ON event_comments (root_id, created_at, comment_id);
@@ -0,0 +1,14 @@
+-- ❌ Drop columns
+ALTER TABLE users
+DROP COLUMN IF EXISTS rating_sum,
+DROP COLUMN IF EXISTS rating_count;
+
+ALTER TABLE event
+DROP COLUMN IF EXISTS rating_sum,
+DROP COLUMN IF EXISTS rating_count;
+
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS event_reviews_event_idx;
+
+-- ❌ Drop event reviews table
+DROP TABLE IF EXISTS event_reviews;
@@ -0,0 +1,27 @@
+-- ✅ Create event reviews table (one review per booking)
+CREATE TABLE IF NOT EXISTS event_reviews (
+    review_id BIGSERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL UNIQUE REFERENCES bookings (booking_id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL, -- Store event_id manually since we can't have FK to partitioned table
+    user_id UUID REFERENCES users (id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP
+    WITH
+        TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS event_reviews_event_idx ON event_reviews (event_id, created_at, review_id);
+
+-- ✅ Rating aggregates, kept in sync with event_reviews
+ALTER TABLE event
+ADD COLUMN IF NOT EXISTS rating_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS rating_sum INTEGER NOT NULL DEFAULT 0;
+
+-- ✅ Ratings of the events a user created, the user being the organizer
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS rating_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS rating_sum INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,17 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS reports_target_idx;
+
+DROP INDEX IF EXISTS reports_queue_idx;
+
+DROP INDEX IF EXISTS reports_open_unique_idx;
+
+-- ❌ Drop reports table
+DROP TABLE IF EXISTS reports;
+
+-- ❌ Drop columns
+ALTER TABLE event
+DROP COLUMN IF EXISTS hidden;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS hidden,
+DROP COLUMN IF EXISTS role;
@@ -0,0 +1,39 @@
+-- ✅ User roles, moderators work the report queue
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin')),
+ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- ✅ Hidden events are only visible to their creator
+ALTER TABLE event
+ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- ✅ Create reports table
+CREATE TABLE IF NOT EXISTS reports (
+    report_id BIGSERIAL PRIMARY KEY,
+    target_type VARCHAR(16) NOT NULL CHECK (target_type IN ('event', 'comment', 'user')),
+    target_id VARCHAR(64) NOT NULL, -- event_id, comment_id or user id as text
+    reporter_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    reason VARCHAR(32) NOT NULL CHECK (
+        reason IN ('spam', 'inappropriate', 'misleading', 'harassment', 'other')
+    ),
+    details TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved', 'dismissed')),
+    assigned_to UUID REFERENCES users (id) ON DELETE SET NULL,
+    resolved_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    resolution_note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        resolved_at TIMESTAMP
+    WITH
+        TIME ZONE
+);
+
+-- ✅ A user can only have one open report per target
+CREATE UNIQUE INDEX IF NOT EXISTS reports_open_unique_idx ON reports (reporter_id, target_type, target_id)
+WHERE
+    status = 'open';
+
+CREATE INDEX IF NOT EXISTS reports_queue_idx ON reports (status, created_at, report_id);
+
+CREATE INDEX IF NOT EXISTS reports_target_idx ON reports (target_type, target_id);
@@ -0,0 +1,5 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS event_images_event_idx;
+
+-- ❌ Drop event images table
+DROP TABLE IF EXISTS event_images;
@@ -0,0 +1,16 @@
+-- ✅ Create event images table, files live in storage under storage_key
+CREATE TABLE IF NOT EXISTS event_images (
+    image_id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT NOT NULL, -- Store event_id manually since we can't have FK to partitioned table
+    uploaded_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    storage_key VARCHAR(255) NOT NULL, -- prefix of the original and its thumbnails
+    format VARCHAR(8) NOT NULL CHECK (format IN ('jpeg', 'png')),
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS event_images_event_idx ON event_images (event_id, image_id);
@@ -0,0 +1,7 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS stream_events_created_at_idx;
+
+DROP INDEX IF EXISTS stream_events_user_idx;
+
+-- ❌ Drop stream events table
+DROP TABLE IF EXISTS stream_events;
@@ -0,0 +1,14 @@
+-- ✅ Create stream events table, the log behind GET /me/events/stream and its Last-Event-ID resume
+CREATE TABLE IF NOT EXISTS stream_events (
+    stream_event_id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS stream_events_user_idx ON stream_events (user_id, stream_event_id);
+
+CREATE INDEX IF NOT EXISTS stream_events_created_at_idx ON stream_events (created_at);
@@ -0,0 +1,7 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS outbox_messages_status_idx;
+
+DROP INDEX IF EXISTS outbox_messages_due_idx;
+
+-- ❌ Drop outbox table
+DROP TABLE IF EXISTS outbox_messages;
@@ -0,0 +1,28 @@
+-- ✅ Create outbox table, side effects written in the same transaction as the domain change
+-- and delivered by the dispatcher, which retries with backoff and dead-letters what keeps failing
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    message_id BIGSERIAL PRIMARY KEY,
+    topic VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (
+        status IN ('pending', 'delivered', 'dead')
+    ),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP
+    WITH
+        TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP
+    WITH
+        TIME ZONE
+);
+
+-- ✅ The dispatcher only scans due pending messages
+CREATE INDEX IF NOT EXISTS outbox_messages_due_idx ON outbox_messages (next_attempt_at, message_id)
+WHERE
+    status = 'pending';
+
+CREATE INDEX IF NOT EXISTS outbox_messages_status_idx ON outbox_messages (status, created_at DESC, message_id DESC);
@@ -0,0 +1,22 @@
+-- ❌ Drop outbox column
+ALTER TABLE outbox_messages
+DROP COLUMN IF EXISTS handled;
+
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS notification_deliveries_sent_idx;
+
+DROP INDEX IF EXISTS notification_deliveries_user_idx;
+
+DROP INDEX IF EXISTS push_devices_user_idx;
+
+-- ❌ Drop notification tables
+DROP TABLE IF EXISTS notification_deliveries;
+
+DROP TABLE IF EXISTS push_devices;
+
+DROP TABLE IF EXISTS notification_preferences;
+
+-- ❌ Drop user columns
+ALTER TABLE users
+DROP COLUMN IF EXISTS timezone,
+DROP COLUMN IF EXISTS locale;
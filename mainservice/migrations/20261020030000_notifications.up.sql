@@ -0,0 +1,64 @@
+-- ✅ Language and timezone notifications are rendered in
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS locale VARCHAR(16) NOT NULL DEFAULT 'en',
+ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+-- ✅ Create notification preferences table, a missing row means every channel is enabled
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type VARCHAR(64) NOT NULL,
+    email BOOLEAN NOT NULL DEFAULT TRUE,
+    push BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (user_id, type)
+);
+
+-- ✅ Create push devices table, the tokens handed out by the push provider
+CREATE TABLE IF NOT EXISTS push_devices (
+    device_id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token VARCHAR(512) NOT NULL UNIQUE,
+    platform VARCHAR(16) NOT NULL CHECK (
+        platform IN ('ios', 'android', 'web')
+    ),
+    created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS push_devices_user_idx ON push_devices (user_id);
+
+-- ✅ Create notification deliveries table, one row per attempt to reach a user on a channel
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type VARCHAR(64) NOT NULL,
+    channel VARCHAR(16) NOT NULL CHECK (channel IN ('email', 'push')),
+    recipient VARCHAR(512) NOT NULL, -- email address or device token
+    dedupe_key VARCHAR(128) NOT NULL, -- same key, same notification
+    status VARCHAR(16) NOT NULL CHECK (
+        status IN ('sent', 'failed', 'skipped')
+    ),
+    subject TEXT NOT NULL DEFAULT '',
+    error TEXT,
+    created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS notification_deliveries_user_idx ON notification_deliveries (
+    user_id,
+    created_at DESC,
+    delivery_id DESC
+);
+
+-- ✅ At most one successful (or skipped) delivery per notification and recipient
+CREATE UNIQUE INDEX IF NOT EXISTS notification_deliveries_sent_idx ON notification_deliveries (dedupe_key, channel, recipient)
+WHERE
+    status IN ('sent', 'skipped');
+
+-- ✅ Outbox consumers that already handled the message, a retry only reruns the others
+ALTER TABLE outbox_messages
+ADD COLUMN IF NOT EXISTS handled TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,5 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS booking_reminders_start_date_idx;
+
+-- ❌ Drop booking reminders table
+DROP TABLE IF EXISTS booking_reminders;
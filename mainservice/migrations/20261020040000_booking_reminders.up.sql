@@ -0,0 +1,18 @@
+-- ✅ Create booking reminders table, a row per reminder handed to the outbox so none is sent twice
+CREATE TABLE IF NOT EXISTS booking_reminders (
+    booking_id INTEGER NOT NULL REFERENCES bookings (booking_id) ON DELETE CASCADE,
+    remind_before INTEGER NOT NULL, -- seconds before start_date
+    start_date TIMESTAMP
+    WITH
+        TIME ZONE NOT NULL, -- the start reminded of, a moved event is reminded again
+        created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (
+            booking_id,
+            remind_before,
+            start_date
+        )
+);
+
+CREATE INDEX IF NOT EXISTS booking_reminders_start_date_idx ON booking_reminders (start_date);
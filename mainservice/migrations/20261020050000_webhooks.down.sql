@@ -0,0 +1,13 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS webhook_deliveries_webhook_idx;
+
+DROP INDEX IF EXISTS webhook_deliveries_due_idx;
+
+DROP INDEX IF EXISTS webhook_deliveries_source_idx;
+
+DROP INDEX IF EXISTS webhooks_organizer_idx;
+
+-- ❌ Drop webhook tables
+DROP TABLE IF EXISTS webhook_deliveries;
+
+DROP TABLE IF EXISTS webhooks;
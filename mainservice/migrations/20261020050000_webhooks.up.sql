@@ -0,0 +1,66 @@
+-- ✅ Create webhooks table, the endpoints organizers subscribe to booking and event changes
+CREATE TABLE IF NOT EXISTS webhooks (
+    webhook_id BIGSERIAL PRIMARY KEY,
+    organizer_id BIGINT NOT NULL REFERENCES organizers (organizer_id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    secret VARCHAR(128) NOT NULL, -- signs the deliveries, shown once on creation
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0, -- failed attempts since the last success
+    disabled_at TIMESTAMP
+    WITH
+        TIME ZONE,
+        disabled_reason TEXT,
+        created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+        created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_organizer_idx ON webhooks (organizer_id);
+
+-- ✅ Create webhook deliveries table, one row per change sent to a webhook, retried until it succeeds or fails for good
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    webhook_id BIGINT NOT NULL REFERENCES webhooks (webhook_id) ON DELETE CASCADE,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    source_id BIGINT, -- outbox message the delivery was created for, NULL for tests
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (
+        status IN (
+            'pending',
+            'succeeded',
+            'failed'
+        )
+    ),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER, -- of the last attempt
+    response_body TEXT,
+    error TEXT,
+    duration_ms INTEGER,
+    next_attempt_at TIMESTAMP
+    WITH
+        TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        last_attempt_at TIMESTAMP
+    WITH
+        TIME ZONE,
+        created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ✅ A redelivered outbox message doesn't create a second delivery
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_source_idx ON webhook_deliveries (webhook_id, source_id);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at)
+WHERE
+    status = 'pending';
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (
+    webhook_id,
+    created_at DESC,
+    delivery_id DESC
+);
@@ -0,0 +1,11 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS saved_search_matches_pending_idx;
+
+DROP INDEX IF EXISTS saved_searches_user_idx;
+
+-- ❌ Drop saved search tables
+DROP TABLE IF EXISTS saved_search_digests;
+
+DROP TABLE IF EXISTS saved_search_matches;
+
+DROP TABLE IF EXISTS saved_searches;
@@ -0,0 +1,52 @@
+-- ✅ Create saved searches table, unset criteria match any event
+CREATE TABLE IF NOT EXISTS saved_searches (
+    search_id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    query VARCHAR(255) NOT NULL DEFAULT '', -- matched against name and description
+    category VARCHAR(32),
+    location GEOMETRY (POINT, 4326), -- center of the area
+    radius_m INTEGER,
+    date_from TIMESTAMP
+    WITH
+        TIME ZONE,
+        date_to TIMESTAMP
+    WITH
+        TIME ZONE,
+        within_days INTEGER, -- rolling window, events starting within this many days of being matched
+        created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        CHECK ((location IS NULL) = (radius_m IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS saved_searches_user_idx ON saved_searches (user_id);
+
+-- ✅ Create saved search matches table, new events matching a search wait here for the user's digest
+CREATE TABLE IF NOT EXISTS saved_search_matches (
+    search_id BIGINT NOT NULL REFERENCES saved_searches (search_id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        digested_at TIMESTAMP
+    WITH
+        TIME ZONE, -- NULL until sent in a digest
+        PRIMARY KEY (search_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS saved_search_matches_pending_idx ON saved_search_matches (user_id)
+WHERE
+    digested_at IS NULL;
+
+-- ✅ Create saved search digests table, when each user was last sent a digest
+CREATE TABLE IF NOT EXISTS saved_search_digests (
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    last_sent_at TIMESTAMP
+    WITH
+        TIME ZONE NOT NULL
+);
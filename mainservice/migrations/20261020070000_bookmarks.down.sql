@@ -0,0 +1,9 @@
+-- ❌ Drop columns
+ALTER TABLE event
+DROP COLUMN IF EXISTS interested_count;
+
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS event_bookmarks_event_id_idx;
+
+-- ❌ Drop event bookmarks table
+DROP TABLE IF EXISTS event_bookmarks;
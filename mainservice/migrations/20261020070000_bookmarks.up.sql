@@ -0,0 +1,16 @@
+-- ✅ Create event bookmarks table (users saving events they are interested in)
+CREATE TABLE IF NOT EXISTS event_bookmarks (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL, -- Store event_id manually since we can't have FK to partitioned table
+    created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (user_id, event_id)
+);
+
+-- ✅ Add an index for faster event lookups in `event_bookmarks`
+CREATE INDEX IF NOT EXISTS event_bookmarks_event_id_idx ON event_bookmarks (event_id);
+
+-- ✅ Interested counter, kept in sync with event_bookmarks
+ALTER TABLE event
+ADD COLUMN IF NOT EXISTS interested_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS event_invites_user_idx;
+
+DROP INDEX IF EXISTS event_invite_links_event_idx;
+
+-- ❌ Drop invite tables
+DROP TABLE IF EXISTS event_invites;
+
+DROP TABLE IF EXISTS event_invite_links;
+
+-- ❌ Drop columns
+ALTER TABLE event_series
+DROP COLUMN IF EXISTS visibility;
+
+ALTER TABLE event
+DROP COLUMN IF EXISTS visibility;
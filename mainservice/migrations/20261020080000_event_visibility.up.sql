@@ -0,0 +1,45 @@
+-- ✅ Visibility of events and series, only public ones are listed on the map, in search and feeds
+ALTER TABLE event
+ADD COLUMN IF NOT EXISTS visibility VARCHAR(16) NOT NULL DEFAULT 'public' CHECK (
+    visibility IN ('public', 'unlisted', 'invite_only')
+);
+
+ALTER TABLE event_series
+ADD COLUMN IF NOT EXISTS visibility VARCHAR(16) NOT NULL DEFAULT 'public' CHECK (
+    visibility IN ('public', 'unlisted', 'invite_only')
+);
+
+-- ✅ Create event invite links table, a link carries a signed token and a short code
+CREATE TABLE IF NOT EXISTS event_invite_links (
+    link_id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT NOT NULL, -- Store event_id manually since we can't have FK to partitioned table
+    code VARCHAR(16) NOT NULL UNIQUE,
+    created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    max_uses INTEGER, -- unlimited when NULL
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP
+    WITH
+        TIME ZONE,
+        revoked_at TIMESTAMP
+    WITH
+        TIME ZONE,
+        created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS event_invite_links_event_idx ON event_invite_links (event_id);
+
+-- ✅ Create event invites table, the users allowed to see and book an invite-only event
+CREATE TABLE IF NOT EXISTS event_invites (
+    event_id BIGINT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    invited_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    link_id BIGINT REFERENCES event_invite_links (link_id) ON DELETE SET NULL, -- set when the user redeemed a link
+    created_at TIMESTAMP
+    WITH
+        TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (event_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS event_invites_user_idx ON event_invites (user_id);
@@ -0,0 +1,3 @@
+-- ❌ Drop columns
+ALTER TABLE event_series
+DROP COLUMN IF EXISTS hidden;
@@ -0,0 +1,3 @@
+-- ✅ A series held by screening keeps generating hidden occurrences until a moderator restores it
+ALTER TABLE event_series
+ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+-- ❌ Drop indexes
+DROP INDEX IF EXISTS event_series_occurrence_unique_idx;
@@ -0,0 +1,11 @@
+-- ✅ Drop occurrences materialized twice, the earliest row is kept
+DELETE FROM event e USING event d
+WHERE
+    e.series_id = d.series_id
+    AND e.occurrence_date = d.occurrence_date
+    AND e.start_date = d.start_date
+    AND e.event_id > d.event_id;
+
+-- ✅ An occurrence is materialized once, the partition key has to be part of a unique index
+-- on the partitioned table, generated occurrences start at their occurrence date
+CREATE UNIQUE INDEX IF NOT EXISTS event_series_occurrence_unique_idx ON event (series_id, occurrence_date, start_date);
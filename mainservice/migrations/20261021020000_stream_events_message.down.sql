@@ -0,0 +1,6 @@
+-- ❌ Drop index
+DROP INDEX IF EXISTS stream_events_message_idx;
+
+-- ❌ Drop columns
+ALTER TABLE stream_events
+DROP COLUMN IF EXISTS message_id;
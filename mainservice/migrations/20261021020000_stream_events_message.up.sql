@@ -0,0 +1,5 @@
+-- ✅ Remember the outbox message every stream event was appended for, redelivered messages append nothing
+ALTER TABLE stream_events
+ADD COLUMN IF NOT EXISTS message_id BIGINT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS stream_events_message_idx ON stream_events (user_id, message_id);
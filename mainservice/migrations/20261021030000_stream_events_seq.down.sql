@@ -0,0 +1,12 @@
+-- ❌ Restore index
+CREATE INDEX IF NOT EXISTS stream_events_user_idx ON stream_events (user_id, stream_event_id);
+
+-- ❌ Drop index
+DROP INDEX IF EXISTS stream_events_user_seq_idx;
+
+-- ❌ Drop columns
+ALTER TABLE stream_events
+DROP COLUMN IF EXISTS seq;
+
+-- ❌ Drop stream sequences table
+DROP TABLE IF EXISTS stream_sequences;
@@ -0,0 +1,36 @@
+-- ✅ Number every user's stream events in commit order. stream_event_id is drawn before commit, so
+-- concurrent appends can become visible out of id order and a resumed stream would skip the earlier one.
+-- Appends bump the user's row here and hold its lock until they commit.
+CREATE TABLE IF NOT EXISTS stream_sequences (
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    last_seq BIGINT NOT NULL
+);
+
+ALTER TABLE stream_events
+ADD COLUMN IF NOT EXISTS seq BIGINT;
+
+-- existing events keep their id as sequence number, so Last-Event-IDs already handed out stay valid
+UPDATE stream_events
+SET
+    seq = stream_event_id
+WHERE
+    seq IS NULL;
+
+ALTER TABLE stream_events
+ALTER COLUMN seq
+SET NOT NULL;
+
+INSERT INTO
+    stream_sequences (user_id, last_seq)
+SELECT
+    user_id,
+    MAX(seq)
+FROM
+    stream_events
+GROUP BY
+    user_id
+ON CONFLICT (user_id) DO NOTHING;
+
+CREATE UNIQUE INDEX IF NOT EXISTS stream_events_user_seq_idx ON stream_events (user_id, seq);
+
+DROP INDEX IF EXISTS stream_events_user_idx;
@@ -0,0 +1,41 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalid = errors.New("Invalid cursor")
+
+// Encode builds an opaque keyset cursor for a (time, id) sort key,
+// base64 of "<unix nanos>:<id>"
+func Encode(t time.Time, id int64) string {
+	raw := strconv.FormatInt(t.UnixNano(), 10) + ":" + strconv.FormatInt(id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode reverses Encode
+func Decode(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalid
+	}
+
+	tStr, idStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, 0, ErrInvalid
+	}
+	nanos, err := strconv.ParseInt(tStr, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalid
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalid
+	}
+	return time.Unix(0, nanos), id, nil
+}
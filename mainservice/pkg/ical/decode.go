@@ -0,0 +1,193 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component is a parsed VEVENT, Line is the line its BEGIN was on
+type Component struct {
+	Line       int
+	Properties []Property
+}
+
+func (c *Component) Get(name string) (Property, bool) {
+	for _, property := range c.Properties {
+		if property.Name == name {
+			return property, true
+		}
+	}
+	return Property{}, false
+}
+
+// DecodeEvents reads every VEVENT of an iCalendar stream. Nested components
+// (VALARM etc.) are skipped.
+func DecodeEvents(r io.Reader) ([]Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events  []Component
+		current *Component
+		depth   int // components nested inside the current VEVENT
+	)
+	for _, line := range lines {
+		property, err := parseLine(line.text)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", line.number)
+		}
+
+		switch {
+		case property.Name == "BEGIN" && strings.EqualFold(property.Value, "VEVENT") && current == nil:
+			current = &Component{Line: line.number}
+		case current == nil:
+			continue
+		case property.Name == "BEGIN":
+			depth++
+		case property.Name == "END" && depth > 0:
+			depth--
+		case property.Name == "END" && strings.EqualFold(property.Value, "VEVENT"):
+			events = append(events, *current)
+			current = nil
+		case depth == 0:
+			current.Properties = append(current.Properties, property)
+		}
+	}
+
+	if current != nil {
+		return nil, errors.Errorf("line %d: VEVENT is not closed", current.Line)
+	}
+	return events, nil
+}
+
+// ParseTime parses DATE-TIME and DATE values, honouring TZID. Floating times
+// are read in loc.
+func ParseTime(property Property, loc *time.Location) (time.Time, error) {
+	if tzid, ok := property.Params["TZID"]; ok {
+		tz, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "unknown TZID")
+		}
+		loc = tz
+	}
+
+	value := property.Value
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse(dateTimeLayout, value)
+	case len(value) == len("20060102"):
+		return time.ParseInLocation("20060102", value, loc)
+	default:
+		return time.ParseInLocation("20060102T150405", value, loc)
+	}
+}
+
+var textUnescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\;`, ";",
+	`\,`, ",",
+	`\n`, "\n",
+	`\N`, "\n",
+)
+
+// UnescapeText reverses escapeText
+func UnescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+// SplitList splits a comma separated multi-value (CATEGORIES) respecting escaped commas
+func SplitList(s string) []string {
+	var (
+		values []string
+		buf    strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			buf.WriteByte(s[i])
+			buf.WriteByte(s[i+1])
+			i++
+		case s[i] == ',':
+			values = append(values, UnescapeText(buf.String()))
+			buf.Reset()
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return append(values, UnescapeText(buf.String()))
+}
+
+type contentLine struct {
+	number int
+	text   string
+}
+
+// unfold joins folded lines (RFC 5545 3.1)
+func unfold(r io.Reader) ([]contentLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []contentLine
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		if (text[0] == ' ' || text[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		lines = append(lines, contentLine{number: number, text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// parseLine splits "NAME;PARAM=value:VALUE", colons inside quoted params are kept
+func parseLine(line string) (Property, error) {
+	inQuotes := false
+	split := -1
+	for i := 0; i < len(line); i++ {
+		if line[i] == '"' {
+			inQuotes = !inQuotes
+		}
+		if line[i] == ':' && !inQuotes {
+			split = i
+			break
+		}
+	}
+	if split <= 0 {
+		return Property{}, errors.New("malformed content line")
+	}
+
+	parts := strings.Split(line[:split], ";")
+	property := Property{
+		Name:   strings.ToUpper(parts[0]),
+		Params: make(map[string]string, len(parts)-1),
+		Value:  line[split+1:],
+	}
+	for _, param := range parts[1:] {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			continue
+		}
+		property.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return property, nil
+}
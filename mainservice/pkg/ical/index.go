@@ -0,0 +1,144 @@
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateTimeLayout = "20060102T150405Z"
+
+	// RFC 5545 3.1: lines should not be longer than 75 octets
+	maxLineOctets = 75
+)
+
+type Event struct {
+	UID            string
+	Summary        string
+	Description    string
+	Start          time.Time
+	End            *time.Time
+	Lat            float64
+	Lon            float64
+	Location       string
+	OrganizerName  string
+	OrganizerEmail string
+	OrganizerURL   string // used as the organizer address when there is no email
+	URL            string
+	Categories     []string
+	Created        time.Time
+}
+
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// Encode renders the calendar as an RFC 5545 iCalendar object.
+func (c *Calendar) Encode(now time.Time) []byte {
+	var buf bytes.Buffer
+
+	writeLine(&buf, "BEGIN:VCALENDAR")
+	writeLine(&buf, "VERSION:2.0")
+	writeLine(&buf, "PRODID:"+c.ProdID)
+	writeLine(&buf, "CALSCALE:GREGORIAN")
+	writeLine(&buf, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(&buf, "X-WR-CALNAME:"+escapeText(c.Name))
+	}
+
+	for _, event := range c.Events {
+		event.encode(&buf, now)
+	}
+
+	writeLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+func (e *Event) encode(buf *bytes.Buffer, now time.Time) {
+	writeLine(buf, "BEGIN:VEVENT")
+	writeLine(buf, "UID:"+e.UID)
+	writeLine(buf, "DTSTAMP:"+formatTime(now))
+	writeLine(buf, "DTSTART:"+formatTime(e.Start))
+	if e.End != nil {
+		writeLine(buf, "DTEND:"+formatTime(*e.End))
+	}
+	if !e.Created.IsZero() {
+		writeLine(buf, "CREATED:"+formatTime(e.Created))
+	}
+	writeLine(buf, "SUMMARY:"+escapeText(e.Summary))
+	if e.Description != "" {
+		writeLine(buf, "DESCRIPTION:"+escapeText(e.Description))
+	}
+	if e.Location != "" {
+		writeLine(buf, "LOCATION:"+escapeText(e.Location))
+	}
+	writeLine(buf, fmt.Sprintf("GEO:%.6f;%.6f", e.Lat, e.Lon))
+	address := e.OrganizerURL
+	if e.OrganizerEmail != "" {
+		address = "mailto:" + e.OrganizerEmail
+	}
+	if address != "" {
+		organizer := "ORGANIZER"
+		if e.OrganizerName != "" {
+			organizer += ";CN=" + quoteParam(e.OrganizerName)
+		}
+		writeLine(buf, organizer+":"+address)
+	}
+	if e.URL != "" {
+		writeLine(buf, "URL:"+e.URL)
+	}
+	if len(e.Categories) > 0 {
+		categories := make([]string, 0, len(e.Categories))
+		for _, category := range e.Categories {
+			categories = append(categories, escapeText(category))
+		}
+		writeLine(buf, "CATEGORIES:"+strings.Join(categories, ","))
+	}
+	writeLine(buf, "END:VEVENT")
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(dateTimeLayout)
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// escapeText escapes a TEXT value (RFC 5545 3.3.11)
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// quoteParam quotes a parameter value, DQUOTE is not allowed inside so it is dropped
+func quoteParam(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "") + `"`
+}
+
+// writeLine writes a content line folded at 75 octets without splitting UTF-8 sequences
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// continuation lines start with a space which counts towards the limit
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
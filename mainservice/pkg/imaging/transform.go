@@ -0,0 +1,101 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// ToNRGBA copies img into a zero-based NRGBA image
+func ToNRGBA(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
+
+// ApplyOrientation turns the image upright according to its EXIF orientation
+func ApplyOrientation(img *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 { // rotated by 90 degrees, sides swap
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // rotated 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // flipped
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // rotated 90 clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated 90 counter-clockwise
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], img.Pix[img.PixOffset(sx, sy):img.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// Fit scales the image down to fit within size x size keeping its aspect ratio,
+// smaller images are returned as they are. Every destination pixel is the
+// alpha weighted average of the source pixels it covers.
+func Fit(img *image.NRGBA, size int) *image.NRGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+
+	dw, dh := size, h*size/w
+	if h > w {
+		dw, dh = w*size/h, size
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	// r*a, g*a, b*a, a and pixel count per destination pixel
+	sums := make([]uint64, dw*dh*5)
+	for y := 0; y < h; y++ {
+		dy := y * dh / h
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < w; x++ {
+			dx := x * dw / w
+			p := row[x*4 : x*4+4]
+			a := uint64(p[3])
+
+			s := sums[(dy*dw+dx)*5:]
+			s[0] += uint64(p[0]) * a
+			s[1] += uint64(p[1]) * a
+			s[2] += uint64(p[2]) * a
+			s[3] += a
+			s[4]++
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for i := 0; i < dw*dh; i++ {
+		s := sums[i*5 : i*5+5]
+		if s[3] == 0 || s[4] == 0 {
+			continue // fully transparent
+		}
+		p := dst.Pix[i*4 : i*4+4]
+		p[0] = uint8(s[0] / s[3])
+		p[1] = uint8(s[1] / s[3])
+		p[2] = uint8(s[2] / s[3])
+		p[3] = uint8(s[3] / s[4])
+	}
+	return dst
+}
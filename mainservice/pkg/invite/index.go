@@ -0,0 +1,99 @@
+package invite
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidToken = errors.New("Invalid invite token")
+
+// codeAlphabet leaves out 0, 1, I and O so codes survive being read out loud
+const codeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+const codeLength = 10
+
+// Signer signs invite link tokens, a token can't be forged or pointed at another link
+// without the secret
+type Signer struct {
+	secret []byte
+}
+
+func NewSigner(secret []byte) *Signer {
+	return &Signer{secret: secret}
+}
+
+// Sign returns the token "<event id>.<link id>.<base64url HMAC-SHA256>"
+func (s *Signer) Sign(eventID, linkID int64) string {
+	payload := strconv.FormatInt(eventID, 10) + "." + strconv.FormatInt(linkID, 10)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(s.mac(payload))
+}
+
+// Verify checks the token signature and returns the ids it was signed for
+func (s *Signer) Verify(token string) (eventID, linkID int64, err error) {
+	payload, sig, ok := cutLast(token, ".")
+	if !ok {
+		return 0, 0, ErrInvalidToken
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.mac(payload)) {
+		return 0, 0, ErrInvalidToken
+	}
+
+	eventStr, linkStr, ok := strings.Cut(payload, ".")
+	if !ok {
+		return 0, 0, ErrInvalidToken
+	}
+	eventID, err = strconv.ParseInt(eventStr, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidToken
+	}
+	linkID, err = strconv.ParseInt(linkStr, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidToken
+	}
+	return eventID, linkID, nil
+}
+
+func (s *Signer) mac(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// IsToken tells a signed token from a short code
+func IsToken(invite string) bool {
+	return strings.Contains(invite, ".")
+}
+
+// NewCode returns a random code short enough to type in
+func NewCode() (string, error) {
+	raw := make([]byte, codeLength)
+	if _, err := rand.Read(raw); err != nil {
+		return "", errors.Wrap(err, "Failed to generate invite code")
+	}
+
+	code := make([]byte, codeLength)
+	for i, b := range raw {
+		code[i] = codeAlphabet[int(b)%len(codeAlphabet)] // 256 is a multiple of 32, no bias
+	}
+	return string(code), nil
+}
+
+// NormalizeCode accepts codes typed in lower case or split into groups
+func NormalizeCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,84 @@
+package invite
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestSignerVerify(t *testing.T) {
+	signer := NewSigner([]byte("test secret"))
+
+	token := signer.Sign(42, 7)
+	payload, sig, _ := cutLast(token, ".")
+	_, otherSig, _ := cutLast(signer.Sign(43, 7), ".")
+
+	// a signature with its first byte flipped, still valid base64url
+	raw, _ := base64.RawURLEncoding.DecodeString(sig)
+	raw[0] ^= 0xff
+	tampered := base64.RawURLEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name      string
+		signer    *Signer
+		token     string
+		wantEvent int64
+		wantLink  int64
+		wantErr   bool
+	}{
+		{"valid", signer, token, 42, 7, false},
+		{"zero ids", signer, signer.Sign(0, 0), 0, 0, false},
+		{"tampered signature", signer, payload + "." + tampered, 0, 0, true},
+		{"event id swapped in", signer, "43.7." + sig, 0, 0, true},
+		{"link id swapped in", signer, "42.8." + sig, 0, 0, true},
+		{"signature of another token", signer, payload + "." + otherSig, 0, 0, true},
+		{"wrong key", NewSigner([]byte("other secret")), token, 0, 0, true},
+		{"truncated signature", signer, token[:len(token)-4], 0, 0, true},
+		{"missing signature", signer, payload + ".", 0, 0, true},
+		{"missing link id", signer, "42." + sig, 0, 0, true},
+		{"no separator", signer, "42", 0, 0, true},
+		{"empty", signer, "", 0, 0, true},
+		{"signature not base64", signer, payload + ".!!!", 0, 0, true},
+		{"signed payload without ids", signer, "a.b." + signature(signer, "a.b"), 0, 0, true},
+		{"signed payload with one id", signer, "42." + signature(signer, "42"), 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventID, linkID, err := tt.signer.Verify(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Verify(%q) error = %v, want ErrInvalidToken", tt.token, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify(%q) error = %v", tt.token, err)
+			}
+			if eventID != tt.wantEvent || linkID != tt.wantLink {
+				t.Errorf("Verify(%q) = %d, %d, want %d, %d", tt.token, eventID, linkID, tt.wantEvent, tt.wantLink)
+			}
+		})
+	}
+}
+
+func TestSignerSign(t *testing.T) {
+	signer := NewSigner([]byte("test secret"))
+
+	if signer.Sign(42, 7) != signer.Sign(42, 7) {
+		t.Error("Sign is not deterministic")
+	}
+	if signer.Sign(42, 7) == NewSigner([]byte("other secret")).Sign(42, 7) {
+		t.Error("tokens signed with different keys are equal")
+	}
+
+	payload, _, _ := cutLast(signer.Sign(42, 7), ".")
+	if payload != "42.7" {
+		t.Errorf("Sign payload = %q, want %q", payload, "42.7")
+	}
+}
+
+// signature signs an arbitrary payload the way Sign does
+func signature(s *Signer, payload string) string {
+	return base64.RawURLEncoding.EncodeToString(s.mac(payload))
+}
@@ -0,0 +1,21 @@
+package mail
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidAddress = errors.New("Invalid email address")
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers emails, SMTP is the only implementation
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
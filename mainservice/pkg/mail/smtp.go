@@ -0,0 +1,139 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// SMTP sends through a relay. Without credentials it talks plain SMTP, which is
+// what local sinks like Mailpit or MailHog expect (localhost:1025). It upgrades
+// with STARTTLS whenever the server offers it.
+type SMTP struct {
+	host     string
+	addr     string
+	username string
+	password string
+	from     *mail.Address
+}
+
+func NewSMTP(host, port, username, password, from string) (*SMTP, error) {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidAddress, "From")
+	}
+	return &SMTP{
+		host:     host,
+		addr:     net.JoinHostPort(host, port),
+		username: username,
+		password: password,
+		from:     fromAddr,
+	}, nil
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return ErrInvalidAddress
+	}
+
+	data, err := s.build(to, msg)
+	if err != nil {
+		return err
+	}
+
+	dialer := net.Dialer{Timeout: defaultTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return errors.Wrap(err, "Failed to connect to SMTP server")
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return errors.Wrap(err, "Failed to set SMTP deadline")
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return errors.Wrap(err, "Failed to start SMTP session")
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return errors.Wrap(err, "Failed to start TLS")
+		}
+	}
+	if s.username != "" {
+		// PlainAuth refuses to send credentials unencrypted, except to localhost
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return errors.Wrap(err, "Failed to authenticate")
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return errors.Wrap(err, "Sender rejected")
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return errors.Wrap(err, "Recipient rejected")
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return errors.Wrap(err, "Failed to start message")
+	}
+	if _, err := w.Write(data); err != nil {
+		return errors.Wrap(err, "Failed to write message")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "Message rejected")
+	}
+	return client.Quit()
+}
+
+// build renders the message as UTF-8 quoted-printable text, header values are
+// encoded so they can't inject headers of their own
+func (s *SMTP) build(to *mail.Address, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	headers := [][2]string{
+		{"From", s.from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", strings.Join(strings.Fields(msg.Subject), " "))},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%d.%s>", time.Now().UnixNano(), s.from.Address)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", `text/plain; charset="utf-8"`},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", header[0], header[1])
+	}
+	buf.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, errors.Wrap(err, "Failed to encode message")
+	}
+	if err := qp.Close(); err != nil {
+		return nil, errors.Wrap(err, "Failed to encode message")
+	}
+	return buf.Bytes(), nil
+}
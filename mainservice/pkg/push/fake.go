@@ -0,0 +1,53 @@
+package push
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// the fake stands in for a real provider in production too, so it only keeps the latest pushes
+const maxKept = 100
+
+// Sent is a message the fake provider accepted
+type Sent struct {
+	Token   string
+	Message Message
+}
+
+// Fake logs pushes instead of sending them and keeps them for inspection.
+// Tokens starting with "invalid" are rejected with ErrInvalidToken.
+type Fake struct {
+	lg   *zap.Logger
+	mu   sync.Mutex
+	sent []Sent
+}
+
+func NewFake(lg *zap.Logger) *Fake {
+	return &Fake{lg: lg}
+}
+
+func (f *Fake) Send(_ context.Context, token string, msg Message) error {
+	if strings.HasPrefix(token, "invalid") {
+		return ErrInvalidToken
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.sent) == maxKept {
+		f.sent = f.sent[1:]
+	}
+	f.sent = append(f.sent, Sent{Token: token, Message: msg})
+	f.lg.Info("push sent", zap.String("token", token), zap.String("title", msg.Title))
+	return nil
+}
+
+// Sent returns the latest messages, oldest first
+func (f *Fake) Sent() []Sent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Sent(nil), f.sent...)
+}
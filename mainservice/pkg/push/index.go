@@ -0,0 +1,22 @@
+package push
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidToken is returned when the provider no longer knows the device, its token should be dropped
+var ErrInvalidToken = errors.New("Invalid push token")
+
+type Message struct {
+	Title string
+	Body  string
+	Data  map[string]string // delivered to the app, e.g. the event to open
+}
+
+// Provider delivers push notifications to devices by the token the provider
+// issued them. FCM or APNs can implement it next to the fake.
+type Provider interface {
+	Send(ctx context.Context, token string, msg Message) error
+}
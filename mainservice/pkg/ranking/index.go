@@ -0,0 +1,107 @@
+package ranking
+
+import (
+	"math"
+	"time"
+)
+
+// z-score for a 95% confidence interval
+const wilsonZ = 1.96
+
+type Inputs struct {
+	Upvotes        int
+	Downvotes      int
+	RecentBookings int // bookings made within Weights.VelocityWindow
+	Interested     int // users who bookmarked the event
+	CreatedAt      time.Time
+	RatingCount    int
+	RatingSum      int // sum of 1-5 star ratings
+	// ratings of all events by the same creator, rank upcoming events of well rated organizers higher
+	CreatorRatingCount int
+	CreatorRatingSum   int
+}
+
+type Weights struct {
+	Votes          float64
+	Velocity       float64
+	Interest       float64
+	Rating         float64
+	CreatorRating  float64
+	VelocityWindow time.Duration
+	HalfLife       time.Duration
+}
+
+var DefaultWeights = Weights{
+	Votes:          1.0,
+	Velocity:       0.5,
+	Interest:       0.25,
+	Rating:         0.5,
+	CreatorRating:  0.5,
+	VelocityWindow: 24 * time.Hour,
+	HalfLife:       72 * time.Hour,
+}
+
+// Score combines vote quality, booking velocity, interest and ratings, decayed by the event's age.
+// Bookmarks count less than bookings since saving an event costs nothing.
+func Score(in Inputs, now time.Time, w Weights) float64 {
+	votes := WilsonLowerBound(in.Upvotes, in.Downvotes)
+	velocity := BookingVelocity(in.RecentBookings, w.VelocityWindow)
+	rating := RatingLowerBound(in.RatingSum, in.RatingCount)
+	creatorRating := RatingLowerBound(in.CreatorRatingSum, in.CreatorRatingCount)
+
+	interest := math.Log1p(float64(max(in.Interested, 0)))
+
+	base := w.Votes*votes + w.Velocity*math.Log1p(velocity) + w.Interest*interest + w.Rating*rating + w.CreatorRating*creatorRating
+	return base * Decay(now.Sub(in.CreatedAt), w.HalfLife)
+}
+
+// WilsonLowerBound is the lower bound of the Wilson score interval for the share of upvotes.
+// It returns 0 when there are no votes.
+func WilsonLowerBound(up, down int) float64 {
+	n := float64(up + down)
+	if n <= 0 {
+		return 0
+	}
+	return wilson(float64(up)/n, n)
+}
+
+// RatingLowerBound scales the average of 1-5 star ratings to [0, 1] and takes the Wilson
+// lower bound, so a few perfect ratings do not outrank many good ones.
+// It returns 0 when there are no ratings.
+func RatingLowerBound(sum, count int) float64 {
+	if count <= 0 {
+		return 0
+	}
+	n := float64(count)
+	p := (float64(sum)/n - 1) / 4
+	return wilson(min(max(p, 0), 1), n)
+}
+
+// wilson is the lower bound of the Wilson score interval for share p of n observations
+func wilson(p, n float64) float64 {
+	z2 := wilsonZ * wilsonZ
+
+	center := p + z2/(2*n)
+	margin := wilsonZ * math.Sqrt((p*(1-p)+z2/(4*n))/n)
+	return (center - margin) / (1 + z2/n)
+}
+
+// BookingVelocity returns bookings per day over the given window.
+func BookingVelocity(recentBookings int, window time.Duration) float64 {
+	if recentBookings <= 0 || window <= 0 {
+		return 0
+	}
+	days := window.Hours() / 24
+	return float64(recentBookings) / days
+}
+
+// Decay halves the weight every halfLife. Negative ages (clock skew) are treated as zero.
+func Decay(age, halfLife time.Duration) float64 {
+	if halfLife <= 0 {
+		return 1
+	}
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, age.Hours()/halfLife.Hours())
+}
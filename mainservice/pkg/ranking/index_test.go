@@ -0,0 +1,125 @@
+package ranking
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const epsilon = 1e-9
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < epsilon
+}
+
+func TestWilsonLowerBound(t *testing.T) {
+	tests := []struct {
+		name     string
+		up, down int
+		want     float64
+	}{
+		{"no votes", 0, 0, 0},
+		{"negative counts", -3, 1, 0},
+		{"single upvote", 1, 0, 0.20654329147389294},
+		{"only downvotes", 0, 10, 0},
+		{"only upvotes", 10, 0, 0.7224598312333834},
+		{"split", 1, 1, 0.09452865480086611},
+		{"mostly upvotes", 90, 10, 0.8256326956323347},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WilsonLowerBound(tt.up, tt.down); !almostEqual(got, tt.want) {
+				t.Errorf("WilsonLowerBound(%d, %d) = %v, want %v", tt.up, tt.down, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRatingLowerBound(t *testing.T) {
+	tests := []struct {
+		name       string
+		sum, count int
+		want       float64
+	}{
+		{"no ratings", 0, 0, 0},
+		{"negative count", 5, -1, 0},
+		{"single five star", 5, 1, 0.20654329147389294},
+		{"all one star", 10, 10, 0},
+		{"four stars average", 16, 4, 0.30063605244263664},
+		{"sum below the scale is clamped", 0, 2, 0},
+		{"sum above the scale is clamped", 50, 1, 0.20654329147389294},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RatingLowerBound(tt.sum, tt.count); !almostEqual(got, tt.want) {
+				t.Errorf("RatingLowerBound(%d, %d) = %v, want %v", tt.sum, tt.count, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecay(t *testing.T) {
+	tests := []struct {
+		name          string
+		age, halfLife time.Duration
+		want          float64
+	}{
+		{"fresh", 0, 72 * time.Hour, 1},
+		{"one half life", 72 * time.Hour, 72 * time.Hour, 0.5},
+		{"two half lives", 144 * time.Hour, 72 * time.Hour, 0.25},
+		{"negative age", -time.Hour, 72 * time.Hour, 1},
+		{"no half life", 1000 * time.Hour, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Decay(tt.age, tt.halfLife); !almostEqual(got, tt.want) {
+				t.Errorf("Decay(%v, %v) = %v, want %v", tt.age, tt.halfLife, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		in   Inputs
+		want float64
+	}{
+		{"nothing", Inputs{CreatedAt: now}, 0},
+		{"votes", Inputs{Upvotes: 90, Downvotes: 10, CreatedAt: now}, 0.8256326956323347},
+		{"velocity", Inputs{RecentBookings: 2, CreatedAt: now}, 0.5 * math.Log1p(2)},
+		{"interested", Inputs{Interested: 3, CreatedAt: now}, 0.25 * math.Log1p(3)},
+		{"negative interested", Inputs{Interested: -3, CreatedAt: now}, 0},
+		{"rating", Inputs{RatingSum: 16, RatingCount: 4, CreatedAt: now}, 0.5 * 0.30063605244263664},
+		{"creator rating", Inputs{CreatorRatingSum: 16, CreatorRatingCount: 4, CreatedAt: now}, 0.5 * 0.30063605244263664},
+		{"decayed interest", Inputs{Interested: 3, CreatedAt: now.Add(-72 * time.Hour)}, 0.5 * 0.25 * math.Log1p(3)},
+		{
+			"combined",
+			Inputs{Upvotes: 1, RecentBookings: 2, Interested: 3, RatingSum: 5, RatingCount: 1, CreatedAt: now},
+			0.20654329147389294 + 0.5*math.Log1p(2) + 0.25*math.Log1p(3) + 0.5*0.20654329147389294,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(tt.in, now, DefaultWeights); !almostEqual(got, tt.want) {
+				t.Errorf("Score() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreRanksBookingsAboveBookmarks(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	booked := Score(Inputs{RecentBookings: 5, CreatedAt: now}, now, DefaultWeights)
+	bookmarked := Score(Inputs{Interested: 5, CreatedAt: now}, now, DefaultWeights)
+	if booked <= bookmarked {
+		t.Errorf("5 bookings scored %v, not above 5 bookmarks with %v", booked, bookmarked)
+	}
+}
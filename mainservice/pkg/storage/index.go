@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidKey = errors.New("Invalid storage key")
+
+// Storage keeps uploaded files under slash separated keys, e.g. "events/42/ab12/original.jpg".
+// An S3-compatible bucket can implement it next to the local filesystem.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Delete(ctx context.Context, key string) error
+	// URL is the public address the file is served from
+	URL(key string) string
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Local stores files in a directory and serves them over HTTP under its base URL
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocal(dir, baseURL string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "Failed to create storage directory")
+	}
+	return &Local{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+// Put writes to a temporary file first so readers never see a partial upload
+func (l *Local) Put(_ context.Context, key string, r io.Reader, _ string) error {
+	filePath, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return errors.Wrap(err, "Failed to create directory")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return errors.Wrap(err, "Failed to create file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "Failed to write file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "Failed to write file")
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return errors.Wrap(err, "Failed to write file")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), filePath), "Failed to move file into place")
+}
+
+// Delete removes the file, deleting a missing file is a no-op
+func (l *Local) Delete(_ context.Context, key string) error {
+	filePath, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "Failed to delete file")
+	}
+	return nil
+}
+
+func (l *Local) URL(key string) string {
+	return l.baseURL + "/" + key
+}
+
+// ServeHTTP serves stored files, the request path has to be stripped of the base URL's path.
+// Directory listings are not served.
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	http.FileServer(http.Dir(l.dir)).ServeHTTP(w, r)
+}
+
+// path maps the key into the storage directory, refusing keys that would escape it
+func (l *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || clean != "/"+key {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrPrivateAddress is returned when a webhook URL resolves to an address that
+// isn't publicly routable, e.g. the cluster network or the metadata service
+var ErrPrivateAddress = errors.New("Webhook URL resolves to a private address")
+
+const dialTimeout = 5 * time.Second
+
+// sharedAddressSpace is the carrier-grade NAT range, netip doesn't count it as private
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// NewClient returns the client deliveries are sent with. Redirects are not
+// followed and, unless allowPrivate is set for local development, connections
+// to non-public addresses are refused after DNS resolution, so organizers
+// can't point webhooks at internal services.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublic(addrPort.Addr()) {
+				return ErrPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: dialTimeout,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     time.Minute,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
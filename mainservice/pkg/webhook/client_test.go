@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},          // loopback
+		{"::1", false},                // loopback
+		{"::ffff:127.0.0.1", false},   // IPv4-mapped loopback
+		{"10.0.0.1", false},           // RFC1918
+		{"172.16.0.1", false},         // RFC1918
+		{"172.31.255.254", false},     // RFC1918
+		{"192.168.1.1", false},        // RFC1918
+		{"::ffff:192.168.1.1", false}, // IPv4-mapped RFC1918
+		{"169.254.169.254", false},    // link-local, cloud metadata
+		{"fe80::1", false},            // link-local
+		{"fc00::1", false},            // unique local
+		{"100.64.0.1", false},         // shared address space
+		{"0.0.0.0", false},            // unspecified
+		{"224.0.0.1", false},          // multicast
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isPublic(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("isPublic(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(time.Second, false)
+
+	// the dial is refused before connecting, so unreachable addresses fail right away too
+	urls := []string{
+		server.URL, // loopback
+		"http://[::1]:9/",
+		"http://10.0.0.1:9/",
+		"http://172.16.0.1:9/",
+		"http://192.168.1.1:9/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[fe80::1]:9/",
+		"http://localhost:9/",
+	}
+	for _, url := range urls {
+		t.Run(url, func(t *testing.T) {
+			resp, err := client.Get(url)
+			if err == nil {
+				resp.Body.Close()
+				t.Fatalf("GET %s succeeded, want ErrPrivateAddress", url)
+			}
+			if !errors.Is(err, ErrPrivateAddress) {
+				t.Errorf("GET %s error = %v, want ErrPrivateAddress", url, err)
+			}
+		})
+	}
+}
+
+func TestNewClientAllowPrivate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	resp, err := NewClient(time.Second, true).Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", server.URL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("GET %s status = %d, want %d", server.URL, resp.StatusCode, http.StatusNoContent)
+	}
+}
+
+func TestNewClientDoesNotFollowRedirects(t *testing.T) {
+	targets := []string{
+		"http://127.0.0.1:9/",
+		"http://10.0.0.1/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[fe80::1]/",
+	}
+
+	for _, target := range targets {
+		t.Run(target, func(t *testing.T) {
+			server := httptest.NewServer(http.RedirectHandler(target, http.StatusFound))
+			defer server.Close()
+
+			// private addresses are allowed here so the redirecting server itself can be reached
+			resp, err := NewClient(time.Second, true).Get(server.URL)
+			if err != nil {
+				t.Fatalf("GET %s error = %v, the redirect to %s was followed", server.URL, err, target)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusFound {
+				t.Errorf("GET %s status = %d, want the redirect itself", server.URL, resp.StatusCode)
+			}
+			if location := resp.Header.Get("Location"); location != target {
+				t.Errorf("Location = %q, want %q", location, target)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// request headers of a delivery
+const (
+	SignatureHeader = "X-Mapnu-Signature"
+	EventHeader     = "X-Mapnu-Event"
+	DeliveryHeader  = "X-Mapnu-Delivery" // stable across retries, receivers dedupe on it
+)
+
+const secretPrefix = "whsec_"
+
+// NewSecret returns a random signing secret
+func NewSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", errors.Wrap(err, "Failed to generate webhook secret")
+	}
+	return secretPrefix + hex.EncodeToString(raw), nil
+}
+
+// Sign returns the SignatureHeader value "t=<unix seconds>,v1=<hex HMAC-SHA256>"
+// of "<unix seconds>.<body>" keyed with the secret. Receivers recompute it and
+// reject old timestamps, so a captured request can't be replayed later.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
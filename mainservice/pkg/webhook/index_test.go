@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+var signaturePattern = regexp.MustCompile(`^t=\d+,v1=[0-9a-f]{64}$`)
+
+func TestSign(t *testing.T) {
+	timestamp := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		body   string
+		want   string
+	}{
+		{
+			"payload",
+			"whsec_test",
+			`{"event":"event.updated"}`,
+			"t=1700000000,v1=6e38d5a4e5a572fb3a65ccf98e482033b478a17b0474adfbab3b8d8c26450517",
+		},
+		{
+			"empty body",
+			"whsec_test",
+			"",
+			"t=1700000000,v1=5967f3c560522fa40cf2876ebc3c3a08551dd6959aaade3b413460591895bdcc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Sign(tt.secret, timestamp, []byte(tt.body))
+			if !signaturePattern.MatchString(got) {
+				t.Errorf("Sign() = %q, not in the t=..,v1=<hex> format", got)
+			}
+			if got != tt.want {
+				t.Errorf("Sign() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignDependsOnAllInputs(t *testing.T) {
+	timestamp := time.Unix(1700000000, 0)
+	body := []byte(`{"event":"event.updated"}`)
+	base := Sign("whsec_test", timestamp, body)
+
+	variants := map[string]string{
+		"secret":    Sign("whsec_other", timestamp, body),
+		"timestamp": Sign("whsec_test", timestamp.Add(time.Second), body),
+		"body":      Sign("whsec_test", timestamp, []byte(`{"event":"event.cancelled"}`)),
+	}
+	for changed, got := range variants {
+		if got == base {
+			t.Errorf("changing the %s kept the signature %q", changed, got)
+		}
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	first, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !regexp.MustCompile(`^whsec_[0-9a-f]{64}$`).MatchString(first) {
+		t.Errorf("NewSecret() = %q, want whsec_ and 32 hex encoded bytes", first)
+	}
+	if first == second {
+		t.Error("NewSecret() returned the same secret twice")
+	}
+}
@@ -0,0 +1,27 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Every calls job right away and then every interval until ctx is done. A failed
+// round is logged as "Failed to <name>" and retried with the next tick.
+func Every(ctx context.Context, lg *zap.Logger, interval time.Duration, name string, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job(ctx); err != nil {
+			lg.Error("Failed to "+name, zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}